@@ -0,0 +1,121 @@
+package krapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func backfillCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Fill in missing frontmatter across notes",
+	}
+	cmd.AddCommand(backfillCreatedCmd())
+	return cmd
+}
+
+func backfillCreatedCmd() *cobra.Command {
+	var (
+		from       []string
+		dryRun     bool
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "created [path|glob]...",
+		Short: "Set created on notes that do not have it",
+		Long: `Set created on notes that do not have it. The date is taken from the
+first source that has one: the file name, the body, the commit that added
+the file to git or the modification time of the file. The order can be
+changed with --from or created_sources in the config.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			names := from
+			if len(names) == 0 {
+				names = cfg.CreatedSources
+			}
+			sources := usecase.DefaultCreatedSources
+			if len(names) > 0 {
+				var err error
+				sources, err = usecase.ParseCreatedSources(names)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+			}
+
+			paths, err := usecase.ExpandNotePaths(cfg.BaseDir, args)
+			if err != nil {
+				fmt.Println("ノートが見つかりません:", err)
+				os.Exit(1)
+			}
+			results, edits, err := usecase.PlanCreatedBackfill(paths, sources)
+			if err != nil {
+				fmt.Println("作成日の推定に失敗しました:", err)
+				os.Exit(1)
+			}
+
+			var tx *usecase.Transaction
+			if !dryRun && len(edits) > 0 {
+				tx = getJournal().Begin("backfill")
+				if err := usecase.ApplyNoteEdits(edits, tx); err != nil {
+					fmt.Println("作成日の書き込みに失敗しました:", err)
+					os.Exit(1)
+				}
+			}
+
+			if jsonOutput {
+				for i := range results {
+					results[i].Path = absPath(results[i].Path)
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(results); err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			missing, failed := 0, 0
+			for _, result := range results {
+				path := relativePath(cfg.BaseDir, result.Path)
+				switch {
+				case result.Error != "":
+					failed++
+					fmt.Printf("%s\t-\t読み込めません: %s\n", path, result.Error)
+				case result.Renamed != "":
+					fmt.Printf("%s\t%s\t%s を created に変更\n", path, result.Created, result.Renamed)
+				case result.Source == "":
+					missing++
+					fmt.Printf("%s\t-\t日付が見つかりません\n", path)
+				default:
+					fmt.Printf("%s\t%s\t%s\n", path, result.Created, result.Source)
+				}
+			}
+			if dryRun {
+				fmt.Printf("%d件のノートに作成日を設定します（--dry-run）\n", len(edits))
+			} else {
+				fmt.Printf("%d件のノートに作成日を設定しました\n", len(edits))
+			}
+			if missing > 0 {
+				fmt.Printf("%d件のノートは日付が見つかりませんでした\n", missing)
+			}
+			if tx != nil {
+				printUndoHint(tx)
+			}
+			if failed > 0 {
+				fmt.Printf("%d件のノートは読み込めませんでした\n", failed)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringSliceVar(&from, "from", nil, "Sources to try in order (filename, body, git, mtime)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the dates without writing")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	return cmd
+}
@@ -0,0 +1,28 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func backlinksCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:     "backlinks <note>",
+		Short:   "List the notes linking to a note",
+		Aliases: []string{"bl"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			graph, path := loadLinkGraphFor(args[0])
+			if err := printLinks(os.Stdout, graph.Backlinks(path), graph.BaseDir, jsonOutput, true); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	return cmd
+}
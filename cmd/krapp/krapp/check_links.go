@@ -0,0 +1,48 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func checkLinksCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "check-links",
+		Short: "Report links that do not resolve to a note or file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			graph, err := usecase.BuildLinkGraph(cfg.BaseDir)
+			if err != nil {
+				fmt.Println("ノートの読み込みに失敗しました:", err)
+				os.Exit(1)
+			}
+
+			broken := graph.BrokenLinks()
+			if jsonOutput {
+				if err := printLinks(os.Stdout, broken, graph.BaseDir, true, false); err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+			} else {
+				// エディタで開けるように file:line:column の形式で出力する
+				for _, link := range broken {
+					fmt.Printf("%s:%d:%d: %s\n", link.Source, link.Line, link.Offset+1, link.Raw)
+				}
+				if len(broken) > 0 {
+					fmt.Fprintf(os.Stderr, "リンク切れ: %d件\n", len(broken))
+				}
+			}
+			if len(broken) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	return cmd
+}
@@ -3,13 +3,17 @@ package krapp
 import (
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/ishida722/krapp-go/usecase"
 	"github.com/spf13/cobra"
 )
 
 func createDailyCmd() *cobra.Command {
+	var (
+		templateName string
+		dateFlag     string
+	)
+
 	cmd := &cobra.Command{
 		Use:     "create-daily",
 		Short:   "Create today's daily note and print its path",
@@ -18,14 +22,33 @@ func createDailyCmd() *cobra.Command {
 			cfg := getConfig()
 			adapter := &configAdapter{&cfg}
 
-			now := time.Now()
-			filePath, err := usecase.CreateDailyNote(adapter, now)
+			date, err := noteDate(dateFlag)
+			if err != nil {
+				fmt.Println("日付の形式が正しくありません（YYYY-MM-DD）:", err)
+				os.Exit(1)
+			}
+			// ノートの作成と持ち越しをまとめて取り消せるようにする
+			tx := getJournal().Begin("create-daily")
+			filePath, err := usecase.CreateDailyNoteWithTemplate(adapter, date, templateName, tx)
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
 			fmt.Println(filePath)
 
+			if cfg.DailyRollover.Enabled {
+				result, err := usecase.RolloverDailyTasks(adapter, date, tx)
+				if err != nil {
+					fmt.Println("タスクの持ち越しに失敗しました:", err)
+					printUndoHintStderr(tx)
+					os.Exit(1)
+				}
+				if len(result.Tasks) > 0 {
+					fmt.Fprintf(os.Stderr, "%s から %d件のタスクを持ち越しました\n", result.Source, len(result.Tasks))
+				}
+			}
+			printUndoHintStderr(tx)
+
 			err = openFile(cmd, cfg, filePath)
 			if err != nil {
 				fmt.Println(err)
@@ -34,5 +57,7 @@ func createDailyCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolP("edit", "e", false, "Open the note in editor after creation")
+	cmd.Flags().StringVarP(&templateName, "template", "t", "", "Body template name in template_dir")
+	cmd.Flags().StringVarP(&dateFlag, "date", "d", "", "Create the daily note of this date (YYYY-MM-DD)")
 	return cmd
 }
@@ -10,6 +10,8 @@ import (
 )
 
 func createInboxCmd() *cobra.Command {
+	var templateName string
+
 	cmd := &cobra.Command{
 		Use:     "create-inbox [title]",
 		Short:   "Create a new inbox note with the given title and print its path",
@@ -21,12 +23,14 @@ func createInboxCmd() *cobra.Command {
 
 			title := args[0]
 			now := time.Now()
-			filePath, err := usecase.CreateInboxNote(adapter, now, title)
+			tx := getJournal().Begin("create-inbox")
+			filePath, err := usecase.CreateInboxNoteWithTemplate(adapter, now, title, templateName, tx)
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
 			fmt.Println(filePath)
+			printUndoHintStderr(tx)
 
 			err = openFile(cmd, cfg, filePath)
 			if err != nil {
@@ -36,5 +40,6 @@ func createInboxCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolP("edit", "e", false, "Open the note in editor after creation")
+	cmd.Flags().StringVarP(&templateName, "template", "t", "", "Body template name in template_dir")
 	return cmd
 }
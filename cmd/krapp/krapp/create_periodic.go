@@ -0,0 +1,72 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func createWeeklyCmd() *cobra.Command {
+	return periodicNoteCmd(usecase.PeriodWeekly, "create-weekly", "cw", "Create this week's note and print its path")
+}
+
+func createMonthlyCmd() *cobra.Command {
+	return periodicNoteCmd(usecase.PeriodMonthly, "create-monthly", "cm", "Create this month's note and print its path")
+}
+
+func createYearlyCmd() *cobra.Command {
+	return periodicNoteCmd(usecase.PeriodYearly, "create-yearly", "cy", "Create this year's note and print its path")
+}
+
+// periodicNoteCmd builds the create command of a weekly, monthly or yearly note.
+func periodicNoteCmd(period usecase.Period, use, alias, short string) *cobra.Command {
+	var (
+		templateName string
+		dateFlag     string
+	)
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   short,
+		Aliases: []string{alias},
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			adapter := &configAdapter{&cfg}
+
+			date, err := noteDate(dateFlag)
+			if err != nil {
+				fmt.Println("日付の形式が正しくありません（YYYY-MM-DD）:", err)
+				os.Exit(1)
+			}
+			tx := getJournal().Begin(use)
+			filePath, err := usecase.CreatePeriodicNote(adapter, period, date, templateName, tx)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Println(filePath)
+			printUndoHintStderr(tx)
+
+			err = openFile(cmd, cfg, filePath)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolP("edit", "e", false, "Open the note in editor after creation")
+	cmd.Flags().StringVarP(&templateName, "template", "t", "", "Body template name in template_dir")
+	cmd.Flags().StringVarP(&dateFlag, "date", "d", "", "Create the note of the period containing this date (YYYY-MM-DD)")
+	return cmd
+}
+
+// noteDate returns the date given by --date, or the current time when empty.
+func noteDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
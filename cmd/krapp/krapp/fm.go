@@ -0,0 +1,214 @@
+package krapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ishida722/krapp-go/models"
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+// fmOptions are the flags shared by the fm subcommands.
+type fmOptions struct {
+	where   []string
+	dryRun  bool
+	format  string
+	json    bool
+	verbose bool
+}
+
+func fmCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fm",
+		Short: "Read and edit frontmatter across notes",
+		Long: `Read and edit frontmatter across notes.
+
+Files may be paths, directories or glob patterns ("**" matches any number
+of directories), relative to the current directory or to base_dir. With no
+files, every note in the vault is used. --where keeps the notes matching
+key=value, key!=value, key<value, key>value (also <= and >=), key (the key
+exists) or !key (it does not).`,
+	}
+	cmd.AddCommand(fmGetCmd())
+	cmd.AddCommand(fmSetCmd())
+	cmd.AddCommand(fmUnsetCmd())
+	cmd.AddCommand(fmHasCmd())
+	return cmd
+}
+
+func fmGetCmd() *cobra.Command {
+	var opts fmOptions
+	cmd := &cobra.Command{
+		Use:   "get <key> [files...]",
+		Short: "Print the value of a frontmatter key",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			key := args[0]
+			notes := selectFmNotes(args[1:], opts)
+
+			type entry struct {
+				Path  string `json:"path"`
+				Value any    `json:"value"`
+			}
+			entries := []entry{}
+			for _, note := range notes {
+				if value, ok := note.FrontMatter[key]; ok {
+					entries = append(entries, entry{Path: absPath(note.FilePath), Value: value})
+				}
+			}
+			if opts.json {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(entries); err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				return
+			}
+			cfg := getConfig()
+			for _, e := range entries {
+				fmt.Printf("%s\t%s\n", relativePath(cfg.BaseDir, e.Path), usecase.FormatFrontMatterValue(e.Value))
+			}
+			if len(entries) == 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	addFmFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&opts.json, "json", false, "Output as JSON")
+	return cmd
+}
+
+func fmSetCmd() *cobra.Command {
+	var opts fmOptions
+	cmd := &cobra.Command{
+		Use:   "set <key> <value> [files...]",
+		Short: "Set a frontmatter key",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			value, err := usecase.ParseFrontMatterValue(args[1], opts.format)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			notes := selectFmNotes(args[2:], opts)
+			edits, err := usecase.PlanFrontMatterSet(notes, args[0], value)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			applyFmEdits(edits, opts, "fm set")
+		},
+	}
+	addFmFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Print a diff without writing the notes")
+	cmd.Flags().StringVar(&opts.format, "type", usecase.ValueFormatYAML, "How to read the value: yaml, json, string")
+	return cmd
+}
+
+func fmUnsetCmd() *cobra.Command {
+	var opts fmOptions
+	cmd := &cobra.Command{
+		Use:     "unset <key> [files...]",
+		Short:   "Remove a frontmatter key",
+		Aliases: []string{"delete", "rm"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			notes := selectFmNotes(args[1:], opts)
+			edits, err := usecase.PlanFrontMatterUnset(notes, args[0])
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			applyFmEdits(edits, opts, "fm unset")
+		},
+	}
+	addFmFlags(cmd, &opts)
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Print a diff without writing the notes")
+	return cmd
+}
+
+func fmHasCmd() *cobra.Command {
+	var opts fmOptions
+	cmd := &cobra.Command{
+		Use:   "has <key> [files...]",
+		Short: "List the notes that have a frontmatter key",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			found := false
+			for _, note := range selectFmNotes(args[1:], opts) {
+				if _, ok := note.FrontMatter[args[0]]; ok {
+					fmt.Println(relativePath(cfg.BaseDir, note.FilePath))
+					found = true
+				}
+			}
+			// grepと同じく見つからなければ終了コード1
+			if !found {
+				os.Exit(1)
+			}
+		},
+	}
+	addFmFlags(cmd, &opts)
+	return cmd
+}
+
+func addFmFlags(cmd *cobra.Command, opts *fmOptions) {
+	cmd.Flags().StringArrayVarP(&opts.where, "where", "w", nil, "Only notes matching the condition (repeatable, all must match)")
+	cmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Report files that could not be read")
+}
+
+// selectFmNotes loads the notes named on the command line that match the
+// --where conditions. It exits on error.
+func selectFmNotes(args []string, opts fmOptions) []*models.Note {
+	var conditions []usecase.FrontMatterCondition
+	for _, expr := range opts.where {
+		condition, err := usecase.ParseFrontMatterCondition(expr)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	cfg := getConfig()
+	paths, err := usecase.ExpandNotePaths(cfg.BaseDir, args)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	notes, failures := usecase.SelectNotes(paths, conditions)
+	for path, err := range failures {
+		if opts.verbose {
+			fmt.Fprintf(os.Stderr, "skip %s: %v\n", path, err)
+		}
+	}
+	return notes
+}
+
+func applyFmEdits(edits []usecase.NoteEdit, opts fmOptions, command string) {
+	if len(edits) == 0 {
+		fmt.Println("変更するノートはありません")
+		return
+	}
+	cfg := getConfig()
+	if opts.dryRun {
+		for _, edit := range edits {
+			fmt.Print(usecase.UnifiedDiff(relativePath(cfg.BaseDir, edit.Path), edit.Before, edit.After))
+		}
+		return
+	}
+
+	tx := getJournal().Begin(command)
+	if err := usecase.ApplyNoteEdits(edits, tx); err != nil {
+		fmt.Println("frontmatterの書き換えに失敗しました:", err)
+		os.Exit(1)
+	}
+	for _, edit := range edits {
+		fmt.Println(relativePath(cfg.BaseDir, edit.Path))
+	}
+	fmt.Printf("%d件のノートを更新しました\n", len(edits))
+	printUndoHint(tx)
+}
@@ -0,0 +1,53 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func graphCmd() *cobra.Command {
+	var (
+		filter usecase.GraphFilter
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export the link graph of the vault as DOT, Mermaid or JSON",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			graphFormat, err := usecase.ParseGraphFormat(format)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			cfg := getConfig()
+			graph, err := usecase.BuildLinkGraph(cfg.BaseDir)
+			if err != nil {
+				fmt.Println("ノートの読み込みに失敗しました:", err)
+				os.Exit(1)
+			}
+			exported, err := graph.Export(filter)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if err := exported.Write(os.Stdout, graphFormat); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "dot", "Output format (dot, mermaid, json)")
+	cmd.Flags().StringSliceVar(&filter.Tags, "tag", nil, "Only notes with the tag (repeatable, all must match)")
+	cmd.Flags().StringVar(&filter.Folder, "folder", "", "Only notes under the folder (relative to base_dir)")
+	cmd.Flags().StringVar(&filter.Focus, "note", "", "Only notes around the note")
+	cmd.Flags().IntVar(&filter.Depth, "depth", 1, "Number of links to follow from --note")
+	cmd.Flags().BoolVar(&filter.OrphansOnly, "orphans", false, "Only notes without links to or from other notes")
+	return cmd
+}
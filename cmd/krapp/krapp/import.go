@@ -4,26 +4,142 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ishida722/krapp-go/usecase"
 	"github.com/spf13/cobra"
 )
 
 func importCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:     "import-notes [directory]",
+	var (
+		opts      usecase.ImportNotesOptions
+		created   []string
+		noCreated bool
+		verbose   bool
+		from      string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "import-notes [directory|file]",
 		Short:   "import notes from a directory",
 		Aliases: []string{"in"},
-		Args:    cobra.ExactArgs(1),
+		Long: `Import .txt and .md files from a directory into the inbox. Files are
+converted to UTF-8 from Shift_JIS, EUC-JP, ISO-2022-JP or UTF-16, and notes
+without created get a date from the file name or modification time.
+
+With --from obsidian, the directory is read as an Obsidian vault:
+attachments are copied too, aliases and tags become frontmatter lists and
+daily notes are moved to daily_note_dir, with wikilinks to them updated.
+
+With --from enex, the .enex files given (or found in the directory) are
+imported from Evernote: each note becomes an inbox note in Markdown, with
+its attachments saved under attachments/ beside it.
+
+With --from notion, a Notion export (the zip file or its extracted folder)
+is imported: IDs are removed from file names and links, HTML pages are
+converted to Markdown and database rows become notes whose columns are
+frontmatter. Folders of other HTML exports can be imported the same way.`,
+		Args: cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg := getConfig()
 			directory := args[0]
-			if err := usecase.ImportNotes(directory, filepath.Join(cfg.BaseDir, cfg.Inbox)); err != nil {
+			if !noCreated {
+				sources, err := usecase.ParseCreatedSources(created)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				opts.Created = sources
+			}
+
+			var tx *usecase.Transaction
+			if !opts.DryRun {
+				tx = getJournal().Begin("import-notes")
+			}
+			dst := filepath.Join(cfg.BaseDir, cfg.Inbox)
+			var summary *usecase.ImportSummary
+			var err error
+			switch from {
+			case "text":
+				summary, err = usecase.ImportNotes(directory, dst, opts, tx)
+			case "obsidian":
+				summary, err = usecase.ImportObsidianVault(getConfigAdapter(), directory, dst, opts, tx)
+			case "notion":
+				summary, err = usecase.ImportNotionExport(directory, dst, opts, tx)
+			case "enex":
+				var files []string
+				files, err = usecase.FindEnexFiles(directory)
+				if err == nil {
+					summary, err = usecase.ImportEnex(getConfigAdapter(), files, opts, tx)
+				}
+			default:
+				fmt.Println("不明な取り込み元です:", from)
+				os.Exit(1)
+			}
+			if summary != nil {
+				printImportSummary(summary, cfg.BaseDir, opts.DryRun, verbose)
+			}
+			if err != nil {
 				fmt.Println("ノートのインポートに失敗しました:", err)
+				if tx != nil {
+					printUndoHint(tx)
+				}
+				os.Exit(1)
+			}
+			if tx != nil {
+				printUndoHint(tx)
+			}
+			if summary.Count(usecase.ImportFailed) > 0 {
 				os.Exit(1)
-			} else {
-				fmt.Println("ノートのインポートが完了しました")
 			}
 		},
 	}
+	cmd.Flags().StringVar(&from, "from", "text", "Kind of the source (text, obsidian, enex, notion)")
+	cmd.Flags().StringVar(&opts.Collision, "on-conflict", usecase.CollisionSkip, "What to do when the note exists (skip, rename, overwrite)")
+	cmd.Flags().StringVar(&opts.Encoding, "encoding", "", "Encoding of the files (detected when omitted)")
+	cmd.Flags().StringSliceVar(&created, "created", []string{"filename", "mtime"}, "Sources of the created date to set (filename, body, git, mtime)")
+	cmd.Flags().BoolVar(&noCreated, "no-created", false, "Do not set created")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show what would be imported without writing")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show skipped notes and encodings")
+	return cmd
+}
+
+// printImportSummary prints the imported notes and the totals.
+func printImportSummary(summary *usecase.ImportSummary, baseDir string, dryRun, verbose bool) {
+	for _, note := range summary.Notes {
+		if note.Status == usecase.ImportSkipped && !verbose {
+			continue
+		}
+		line := fmt.Sprintf("%-11s %s", note.Status, note.Source)
+		if note.Dest != "" && note.Status != usecase.ImportSkipped {
+			line += " -> " + relativePath(baseDir, note.Dest)
+		}
+		var details []string
+		if note.Attachment {
+			details = append(details, "attachment")
+		}
+		if note.Encoding != "" && (verbose || note.Encoding != "utf-8") {
+			details = append(details, note.Encoding)
+		}
+		if note.Created != "" {
+			details = append(details, "created: "+string(note.Created))
+		}
+		if note.Message != "" {
+			details = append(details, note.Message)
+		}
+		if len(details) > 0 {
+			line += fmt.Sprintf(" (%s)", strings.Join(details, ", "))
+		}
+		fmt.Println(line)
+	}
+
+	imported := summary.Count(usecase.ImportCreated) + summary.Count(usecase.ImportRenamed) + summary.Count(usecase.ImportOverwritten)
+	prefix := ""
+	if dryRun {
+		prefix = "（--dry-run）"
+	}
+	fmt.Printf("%s取り込み: %d件（新規 %d、名前を変更 %d、上書き %d）、スキップ: %d件、失敗: %d件、対象外: %d件\n",
+		prefix, imported,
+		summary.Count(usecase.ImportCreated), summary.Count(usecase.ImportRenamed), summary.Count(usecase.ImportOverwritten),
+		summary.Count(usecase.ImportSkipped), summary.Count(usecase.ImportFailed), summary.Ignored)
 }
@@ -13,20 +13,33 @@ func importIssuesCmd() *cobra.Command {
 		repo    string
 		dryRun  bool
 		noClose bool
+		since   string
 	)
 
 	cmd := &cobra.Command{
 		Use:     "import-issues",
 		Short:   "Import GitHub issues as inbox notes",
 		Aliases: []string{"ii"},
+		Long: `Import the open GitHub issues as inbox notes. Issues that already have
+a note in the vault, found by issue_url or issue_number, are not imported
+again: the note is left as it is, or, when the issue was updated on GitHub,
+gets the new comments appended and its frontmatter refreshed.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg := getConfigAdapter()
 			client := &usecase.GHClient{}
 
+			sinceDate, err := parseDateFlag(since)
+			if err != nil {
+				fmt.Println("--sinceの日付が不正です:", err)
+				os.Exit(1)
+			}
+
 			options := usecase.ImportOptions{
 				Repo:    repo,
 				DryRun:  dryRun,
 				NoClose: noClose,
+				Since:   sinceDate,
+				Journal: getJournal().Begin("import-issues"),
 			}
 
 			if err := usecase.ImportGitHubIssues(cfg, client, options); err != nil {
@@ -35,12 +48,14 @@ func importIssuesCmd() *cobra.Command {
 			}
 
 			fmt.Println("GitHub issueのインポートが完了しました")
+			printUndoHint(options.Journal)
 		},
 	}
 
 	cmd.Flags().StringVar(&repo, "repo", "", "Repository (owner/name)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Don't actually close issues")
 	cmd.Flags().BoolVar(&noClose, "no-close", false, "Import issues without closing them")
+	cmd.Flags().StringVar(&since, "since", "", "Only issues updated on or after this date (YYYY-MM-DD)")
 
 	return cmd
 }
@@ -0,0 +1,63 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func importMailCmd() *cobra.Command {
+	var (
+		opts    usecase.ImportNotesOptions
+		verbose bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "import-mail <file.mbox|file.eml|directory>",
+		Short:   "Import emails as inbox notes",
+		Aliases: []string{"im"},
+		Long: `Import the emails of an mbox file, an .eml file or a directory of them
+into the inbox. Each message becomes a note with From, To, Subject, Date and
+Message-ID in its frontmatter, and its attachments are saved under
+attachments/ beside it. Messages whose Message-ID is already in the vault
+are skipped, so a mailbox can be imported again to add new messages.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			files, err := usecase.FindMailFiles(args[0])
+			if err != nil {
+				fmt.Println("メールファイルが見つかりません:", err)
+				os.Exit(1)
+			}
+
+			var tx *usecase.Transaction
+			if !opts.DryRun {
+				tx = getJournal().Begin("import-mail")
+			}
+			summary, err := usecase.ImportMail(getConfigAdapter(), files, opts, tx)
+			if summary != nil {
+				printImportSummary(summary, cfg.BaseDir, opts.DryRun, verbose)
+			}
+			if err != nil {
+				fmt.Println("メールのインポートに失敗しました:", err)
+				if tx != nil {
+					printUndoHint(tx)
+				}
+				os.Exit(1)
+			}
+			if tx != nil {
+				printUndoHint(tx)
+			}
+			if summary.Count(usecase.ImportFailed) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	// 件名と日付が同じ別のメールもあるので、既定では名前を変えて取り込む
+	cmd.Flags().StringVar(&opts.Collision, "on-conflict", usecase.CollisionRename, "What to do when the note exists (skip, rename, overwrite)")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show what would be imported without writing")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show messages already imported")
+	return cmd
+}
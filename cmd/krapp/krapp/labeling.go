@@ -0,0 +1,146 @@
+package krapp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+const labelingSeparator = "─────────────────────────────────────────────────────"
+
+func labelingCmd() *cobra.Command {
+	var recursive bool
+
+	cmd := &cobra.Command{
+		Use:   "labeling [directory]",
+		Short: "Interactively add labels to unlabeled notes",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			dir := args[0]
+			if _, err := os.Stat(dir); err != nil {
+				fmt.Println("ディレクトリが存在しません:", dir)
+				os.Exit(1)
+			}
+
+			options := make([]usecase.LabelOption, len(cfg.Labels))
+			for i, label := range cfg.Labels {
+				options[i] = usecase.LabelOption{Key: label.Key, Name: label.Name}
+			}
+			labeler, err := usecase.NewNoteLabeler(dir, recursive, options)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			for path, err := range labeler.Failures {
+				fmt.Printf("warning: %s を読み込めませんでした: %v\n", path, err)
+			}
+			if len(labeler.Notes) == 0 {
+				fmt.Println("ラベルのないノートはありません")
+				return
+			}
+
+			tx := getJournal().Begin("labeling")
+			labeler.Journal = tx
+			if err := runLabeling(labeler, cmd.InOrStdin(), cmd.OutOrStdout()); err != nil {
+				fmt.Println(err)
+				printUndoHint(tx)
+				os.Exit(1)
+			}
+			printLabelingStats(cmd.OutOrStdout(), labeler.Stats())
+			printUndoHint(tx)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Process subdirectories recursively")
+	return cmd
+}
+
+// runLabeling drives the interactive loop until every note is visited or
+// the user quits.
+func runLabeling(labeler *usecase.NoteLabeler, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	prompt := labelingPrompt(labeler.Labels)
+
+	err := labeler.Next()
+	for err == nil {
+		current, total := labeler.Progress()
+		stats := labeler.Stats()
+		fmt.Fprintf(out, "\nFile: %s (%d/%d) [%d files skipped]\n", labeler.Current.FilePath, current, total, stats.Skipped)
+		fmt.Fprintln(out, labelingSeparator)
+		fmt.Fprintln(out, usecase.GeneratePreview(labeler.Current.Content, usecase.MaxPreviewLines))
+		fmt.Fprintln(out, labelingSeparator)
+		fmt.Fprintln(out, prompt)
+
+		for {
+			fmt.Fprint(out, "Your choice: ")
+			input, readErr := reader.ReadString('\n')
+			if readErr != nil && input == "" {
+				if errors.Is(readErr, io.EOF) {
+					return nil
+				}
+				return readErr
+			}
+			input = strings.TrimSpace(input)
+
+			switch strings.ToLower(input) {
+			case "s", "skip":
+				labeler.Skip()
+				err = labeler.Next()
+			case "b", "back":
+				if backErr := labeler.Back(); backErr != nil {
+					fmt.Fprintln(out, backErr)
+					continue
+				}
+			case "q", "quit":
+				return nil
+			default:
+				if _, ok := labeler.LabelFor(input); !ok {
+					fmt.Fprintf(out, "無効な入力です: %s\n", input)
+					continue
+				}
+				if applyErr := labeler.Apply(input); applyErr != nil {
+					return fmt.Errorf("ラベルの書き込みに失敗しました: %w", applyErr)
+				}
+				err = labeler.Next()
+			}
+			break
+		}
+	}
+	if errors.Is(err, usecase.ErrLabelingDone) {
+		return nil
+	}
+	return err
+}
+
+func labelingPrompt(labels []usecase.LabelOption) string {
+	parts := make([]string, 0, len(labels)+3)
+	for _, label := range labels {
+		parts = append(parts, label.Key+"="+label.Name)
+	}
+	parts = append(parts, "s=skip", "b=back", "q=quit")
+	return "Labels: " + strings.Join(parts, ", ")
+}
+
+func printLabelingStats(out io.Writer, stats usecase.LabelingStats) {
+	fmt.Fprintln(out, "\n"+labelingSeparator)
+	fmt.Fprintf(out, "labeled: %d, skipped: %d, remaining: %d, total: %d\n", stats.Labeled, stats.Skipped, stats.Remaining, stats.Total)
+	labels := make([]string, 0, len(stats.ByLabel))
+	for label := range stats.ByLabel {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		fmt.Fprintf(out, "  %s: %d\n", label, stats.ByLabel[label])
+	}
+	if stats.Failed > 0 {
+		fmt.Fprintf(out, "unreadable files: %d\n", stats.Failed)
+	}
+}
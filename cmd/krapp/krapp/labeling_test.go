@@ -0,0 +1,52 @@
+package krapp
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ishida722/krapp-go/models"
+	"github.com/ishida722/krapp-go/usecase"
+)
+
+func TestRunLabeling(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "b.md", "c.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("body of "+name), 0644); err != nil {
+			t.Fatalf("failed to write note: %v", err)
+		}
+	}
+
+	labeler, err := usecase.NewNoteLabeler(dir, false, []usecase.LabelOption{{Key: "1", Name: "diary"}, {Key: "2", Name: "idea"}})
+	if err != nil {
+		t.Fatalf("NewNoteLabeler: %v", err)
+	}
+
+	// a: 無効な入力のあとdiary, b: skip, c: quit
+	input := strings.NewReader("x\n1\ns\nq\n")
+	var out bytes.Buffer
+	if err := runLabeling(labeler, input, &out); err != nil {
+		t.Fatalf("runLabeling: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "無効な入力です: x") {
+		t.Errorf("invalid input should be reported")
+	}
+	if !strings.Contains(out.String(), "(3/3) [1 files skipped]") {
+		t.Errorf("progress should be displayed, got:\n%s", out.String())
+	}
+	stats := labeler.Stats()
+	if stats.Labeled != 1 || stats.Skipped != 1 || stats.Remaining != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	note, err := models.LoadNoteFromFile(filepath.Join(dir, "a.md"))
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if label, _ := note.FrontMatter.Label(); label != "diary" {
+		t.Errorf("expected a.md to be labeled diary, got %q", label)
+	}
+}
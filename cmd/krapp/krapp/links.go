@@ -0,0 +1,111 @@
+package krapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func linksCmd() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "links <note>",
+		Short: "List the links going out of a note",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			graph, path := loadLinkGraphFor(args[0])
+			if err := printLinks(os.Stdout, graph.Outgoing(path), graph.BaseDir, jsonOutput, false); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	return cmd
+}
+
+// loadLinkGraphFor builds the link graph of the vault and finds the note
+// given on the command line. It exits on error.
+func loadLinkGraphFor(arg string) (*usecase.LinkGraph, string) {
+	cfg := getConfig()
+	graph, err := usecase.BuildLinkGraph(cfg.BaseDir)
+	if err != nil {
+		fmt.Println("ノートの読み込みに失敗しました:", err)
+		os.Exit(1)
+	}
+	path, err := graph.FindNote(arg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return graph, path
+}
+
+// linkJSON is the JSON form of a link used by links and backlinks.
+type linkJSON struct {
+	Source   string `json:"source"`
+	Line     int    `json:"line"`
+	Kind     string `json:"kind"`
+	Target   string `json:"target"`
+	Heading  string `json:"heading,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+	Embed    bool   `json:"embed,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Resolved bool   `json:"resolved"`
+	Context  string `json:"context"`
+}
+
+// printLinks prints links one per line as "file:line  raw -> target", or as
+// a JSON array with absolute paths. bySource shows the linking note
+// instead of the link target.
+func printLinks(out io.Writer, links []usecase.ResolvedLink, baseDir string, jsonOutput, bySource bool) error {
+	if jsonOutput {
+		items := make([]linkJSON, 0, len(links))
+		for _, link := range links {
+			items = append(items, linkJSON{
+				Source:   absPath(link.Source),
+				Line:     link.Line,
+				Kind:     string(link.Kind),
+				Target:   link.Target,
+				Heading:  link.Heading,
+				Alias:    link.Alias,
+				Embed:    link.Embed,
+				Path:     absPath(link.Path),
+				Resolved: link.Resolved(),
+				Context:  link.Context,
+			})
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(items)
+	}
+
+	for _, link := range links {
+		if bySource {
+			fmt.Fprintf(out, "%s:%d  %s\n", relativePath(baseDir, link.Source), link.Line, link.Context)
+			continue
+		}
+		target := "(未解決)"
+		if link.Resolved() {
+			target = relativePath(baseDir, link.Path)
+		}
+		fmt.Fprintf(out, "%s:%d  %s -> %s\n", relativePath(baseDir, link.Source), link.Line, link.Raw, target)
+	}
+	return nil
+}
+
+func absPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
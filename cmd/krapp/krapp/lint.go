@@ -0,0 +1,101 @@
+package krapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func lintCmd() *cobra.Command {
+	var (
+		format string
+		fix    bool
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "lint [path|glob]...",
+		Short: "Check frontmatter against the schemas in the config",
+		Long: `Check the frontmatter of every note, or of the given notes, against
+frontmatter_schemas in the config. With --fix, key casing, date formats
+and the case of enum values are corrected.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if format != "text" && format != "json" {
+				fmt.Println("不明な出力形式です:", format)
+				os.Exit(1)
+			}
+			cfg := getConfig()
+			var paths []string
+			if len(args) > 0 {
+				var err error
+				paths, err = usecase.ExpandNotePaths(cfg.BaseDir, args)
+				if err != nil {
+					fmt.Println("ノートが見つかりません:", err)
+					os.Exit(1)
+				}
+			}
+
+			schemas := getConfigAdapter().GetFrontMatterSchemas()
+			result, err := usecase.LintVault(cfg.BaseDir, paths, schemas, fix || dryRun)
+			if err != nil {
+				fmt.Println("ノートの検査に失敗しました:", err)
+				os.Exit(1)
+			}
+			for i := range result.Issues {
+				result.Issues[i].Path = relativePath(cfg.BaseDir, result.Issues[i].Path)
+			}
+
+			if dryRun {
+				for _, edit := range result.Edits {
+					fmt.Print(usecase.UnifiedDiff(relativePath(cfg.BaseDir, edit.Path), edit.Before, edit.After))
+				}
+				// 書き込まないので直した扱いにしない
+				for i := range result.Issues {
+					result.Issues[i].Fixed = false
+				}
+			}
+			var tx *usecase.Transaction
+			if fix && !dryRun && len(result.Edits) > 0 {
+				tx = getJournal().Begin("lint")
+				if err := usecase.ApplyNoteEdits(result.Edits, tx); err != nil {
+					fmt.Println("ノートの修正に失敗しました:", err)
+					os.Exit(1)
+				}
+			}
+
+			if format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(result.Issues); err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+			} else if !dryRun {
+				// エディタで開けるように file:line の形式で出力する
+				for _, issue := range result.Issues {
+					status := ""
+					if issue.Fixed {
+						status = " (fixed)"
+					} else if issue.Fixable {
+						status = " (fixable)"
+					}
+					fmt.Printf("%s:%d: %s: %s%s\n", issue.Path, issue.Line, issue.Rule, issue.Message, status)
+				}
+				fmt.Fprintf(os.Stderr, "%d件のノートを検査し、%d件の問題が残っています\n", result.Notes, result.Remaining())
+			}
+			if tx != nil && format == "text" {
+				printUndoHint(tx)
+			}
+			if result.Remaining() > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text, json)")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Fix key casing, date formats and enum case")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the fixes as a diff without writing")
+	return cmd
+}
@@ -0,0 +1,77 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func listCmd() *cobra.Command {
+	var (
+		query   usecase.NoteQuery
+		since   string
+		until   string
+		verbose bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List notes in the vault filtered by frontmatter",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+
+			var err error
+			if query.Since, err = parseDateFlag(since); err != nil {
+				fmt.Println("--sinceの日付が不正です:", err)
+				os.Exit(1)
+			}
+			if query.Until, err = parseDateFlag(until); err != nil {
+				fmt.Println("--untilの日付が不正です:", err)
+				os.Exit(1)
+			}
+
+			index, err := usecase.BuildNoteIndex(cfg.BaseDir)
+			if err != nil {
+				fmt.Println("ノートの読み込みに失敗しました:", err)
+				os.Exit(1)
+			}
+			if verbose {
+				for path, err := range index.Failures {
+					fmt.Fprintf(os.Stderr, "skip %s: %v\n", path, err)
+				}
+			}
+
+			notes, err := index.Find(query)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			for _, note := range notes {
+				fmt.Println(note.FilePath)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&query.Status, "status", "", "Filter by status")
+	cmd.Flags().StringVar(&query.Label, "label", "", "Filter by label")
+	cmd.Flags().StringSliceVar(&query.Tags, "tag", nil, "Filter by tag (repeatable, all must match)")
+	cmd.Flags().StringVar(&since, "since", "", "Only notes created on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&until, "until", "", "Only notes created on or before this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&query.SortBy, "sort", "path", "Sort key: path, created, status, label")
+	cmd.Flags().BoolVarP(&query.Reverse, "reverse", "r", false, "Reverse the sort order")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Report files that could not be parsed")
+	return cmd
+}
+
+// parseDateFlag parses a YYYY-MM-DD flag value. An empty value yields the zero time.
+func parseDateFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
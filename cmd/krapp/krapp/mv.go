@@ -0,0 +1,55 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func mvCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "mv <note> <dest>",
+		Long:  "Move or rename a note and update the links to it.\n<dest> is a file or directory path relative to the vault root.",
+		Short: "Move or rename a note and update the links to it",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			graph, from := loadLinkGraphFor(args[0])
+
+			// 相対パスの移動先はボルトのルートからのパスとして扱う
+			dest := args[1]
+			if !filepath.IsAbs(dest) {
+				dest = filepath.Join(graph.BaseDir, dest)
+				if strings.HasSuffix(args[1], "/") {
+					dest += "/"
+				}
+			}
+			plan, err := usecase.PlanNoteMove(graph, from, dest)
+			if err != nil {
+				fmt.Println("移動できません:", err)
+				os.Exit(1)
+			}
+			fmt.Printf("move  %s -> %s\n", plan.From, plan.To)
+			for _, change := range plan.Changes {
+				fmt.Printf("link  %s:%d  %s -> %s\n", change.Source, change.Line, change.Old, change.New)
+			}
+			if dryRun {
+				return
+			}
+
+			tx := getJournal().Begin("mv")
+			if err := usecase.ApplyNoteMove(graph, plan, tx); err != nil {
+				fmt.Println("移動に失敗しました:", err)
+				os.Exit(1)
+			}
+			printUndoHint(tx)
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the changes without moving the note")
+	return cmd
+}
@@ -0,0 +1,113 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ishida722/krapp-go/models"
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func organizeCmd() *cobra.Command {
+	var (
+		by        string
+		dir       string
+		dest      string
+		recursive bool
+		dryRun    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "organize",
+		Short: "Move notes into directories by created date or label",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			if dir == "" {
+				dir = filepath.Join(cfg.BaseDir, cfg.Inbox)
+			}
+			if dest == "" {
+				dest = cfg.BaseDir
+			}
+
+			index, err := usecase.LoadNotesInDir(dir, recursive)
+			if err != nil {
+				fmt.Println("ノートの読み込みに失敗しました:", err)
+				os.Exit(1)
+			}
+			notes := make([]models.Note, len(index.Notes))
+			for i, note := range index.Notes {
+				notes[i] = *note
+			}
+
+			var plan []usecase.OrganizeMove
+			switch by {
+			case "created":
+				plan = usecase.PlanOrganizeByCreated(notes, dest)
+			case "label":
+				if len(cfg.LabelDirectoryMap) == 0 {
+					fmt.Println("label_directory_map が設定されていません")
+					os.Exit(1)
+				}
+				plan = usecase.PlanOrganizeByLabel(notes, dest, usecase.LabelDirectoryMap(cfg.LabelDirectoryMap))
+			default:
+				fmt.Println("--by には created か label を指定してください")
+				os.Exit(1)
+			}
+
+			for path, err := range index.Failures {
+				fmt.Printf("error %s: %v\n", path, err)
+			}
+
+			if dryRun {
+				for _, move := range plan {
+					printOrganizeMove(move)
+				}
+				return
+			}
+
+			// 移動するノートへのリンクも書き換える
+			graph, err := usecase.BuildLinkGraph(cfg.BaseDir)
+			if err != nil {
+				fmt.Println("リンクの読み込みに失敗しました:", err)
+				os.Exit(1)
+			}
+
+			tx := getJournal().Begin("organize")
+			results := usecase.ApplyOrganizePlanWithLinks(plan, graph, tx)
+			for _, result := range results {
+				if result.Err != nil {
+					fmt.Printf("error %s: %v\n", result.Move.Source, result.Err)
+					continue
+				}
+				printOrganizeMove(result.Move)
+				if result.Links > 0 {
+					fmt.Printf("      リンク %d件を更新\n", result.Links)
+				}
+			}
+			summary := usecase.SummarizeOrganizeResults(results)
+			fmt.Printf("moved: %d, skipped: %d, failed: %d\n", summary.Moved, summary.Skipped, summary.Failed)
+			printUndoHint(tx)
+			if summary.Failed > 0 || len(index.Failures) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&by, "by", "created", "Organize by created or label")
+	cmd.Flags().StringVar(&dir, "dir", "", "Directory to organize (default: inbox directory)")
+	cmd.Flags().StringVar(&dest, "dest", "", "Base directory to move notes into (default: base_dir)")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Include subdirectories")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the move plan without moving files")
+	return cmd
+}
+
+func printOrganizeMove(move usecase.OrganizeMove) {
+	if move.SkipReason != "" {
+		fmt.Printf("skip  %s (%s)\n", move.Source, move.SkipReason)
+		return
+	}
+	fmt.Printf("move  %s -> %s (%s)\n", move.Source, move.Destination, move.Reason)
+}
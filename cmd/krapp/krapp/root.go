@@ -3,8 +3,10 @@ package krapp
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/ishida722/krapp-go/config"
+	"github.com/ishida722/krapp-go/usecase"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +21,45 @@ func (c *configAdapter) GetInboxDir() string {
 }
 func (c *configAdapter) GetDailyTemplate() map[string]any { return c.DailyTemplate }
 func (c *configAdapter) GetInboxTemplate() map[string]any { return c.InboxTemplate }
+func (c *configAdapter) GetInboxFilenamePattern() string  { return c.InboxFilenamePattern }
+func (c *configAdapter) GetIssueFilenamePattern() string  { return c.IssueFilenamePattern }
+
+// GetTemplateDir returns the template directory, resolved against BaseDir when relative.
+func (c *configAdapter) GetTemplateDir() string {
+	if c.TemplateDir == "" || filepath.IsAbs(c.TemplateDir) {
+		return c.TemplateDir
+	}
+	return filepath.Join(c.BaseDir, c.TemplateDir)
+}
+
+// GetPeriodicNoteSettings returns the settings of period from periodic_notes.
+// Daily notes keep using daily_note_dir and daily_template.
+func (c *configAdapter) GetPeriodicNoteSettings(period usecase.Period) usecase.PeriodicNoteSettings {
+	periodic := c.PeriodicNotes[string(period)]
+	settings := usecase.PeriodicNoteSettings{
+		Dir:             periodic.Dir,
+		FilenamePattern: periodic.FilenamePattern,
+		FrontMatter:     periodic.Template,
+		Template:        periodic.BodyTemplate,
+	}
+	if period == usecase.PeriodDaily {
+		settings.Dir = c.DailyNoteDir
+		settings.FrontMatter = c.DailyTemplate
+		if c.DailyPathPattern != "" {
+			settings.FilenamePattern = c.DailyPathPattern
+		}
+	}
+	return settings
+}
+
+func (c *configAdapter) GetDailyRollover() usecase.DailyRolloverSettings {
+	return usecase.DailyRolloverSettings{
+		Enabled:      c.DailyRollover.Enabled,
+		Section:      c.DailyRollover.Section,
+		MarkMoved:    c.DailyRollover.MarkMoved,
+		LookbackDays: c.DailyRollover.LookbackDays,
+	}
+}
 
 var rootCmd = &cobra.Command{
 	Use:     "krapp",
@@ -39,10 +80,29 @@ func Execute() error {
 
 	rootCmd.AddCommand(printConfigCmd())
 	rootCmd.AddCommand(createDailyCmd())
+	rootCmd.AddCommand(createWeeklyCmd())
+	rootCmd.AddCommand(createMonthlyCmd())
+	rootCmd.AddCommand(createYearlyCmd())
 	rootCmd.AddCommand(createInboxCmd())
 	rootCmd.AddCommand(syncCmd())
 	rootCmd.AddCommand(importCmd())
 	rootCmd.AddCommand(importIssuesCmd())
+	rootCmd.AddCommand(listCmd())
+	rootCmd.AddCommand(searchCmd())
+	rootCmd.AddCommand(organizeCmd())
+	rootCmd.AddCommand(undoCmd())
+	rootCmd.AddCommand(labelingCmd())
+	rootCmd.AddCommand(tasksCmd())
+	rootCmd.AddCommand(linksCmd())
+	rootCmd.AddCommand(backlinksCmd())
+	rootCmd.AddCommand(checkLinksCmd())
+	rootCmd.AddCommand(mvCmd())
+	rootCmd.AddCommand(graphCmd())
+	rootCmd.AddCommand(tagsCmd())
+	rootCmd.AddCommand(fmCmd())
+	rootCmd.AddCommand(lintCmd())
+	rootCmd.AddCommand(backfillCmd())
+	rootCmd.AddCommand(importMailCmd())
 
 	return rootCmd.Execute()
 }
@@ -51,6 +111,18 @@ func getConfig() config.Config {
 	return cfg
 }
 
+func (c *configAdapter) GetFrontMatterSchemas() map[string]usecase.FrontMatterSchema {
+	schemas := map[string]usecase.FrontMatterSchema{}
+	for folder, schema := range c.FrontMatterSchemas {
+		fields := map[string]usecase.FieldSchema{}
+		for key, field := range schema.Fields {
+			fields[key] = usecase.FieldSchema{Type: field.Type, Enum: field.Enum, Format: field.Format}
+		}
+		schemas[folder] = usecase.FrontMatterSchema{Required: schema.Required, Fields: fields}
+	}
+	return schemas
+}
+
 func getConfigAdapter() *configAdapter {
 	return &configAdapter{&cfg}
 }
@@ -0,0 +1,90 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ishida722/krapp-go/config"
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+const (
+	highlightStart = "\x1b[1;31m"
+	highlightEnd   = "\x1b[0m"
+)
+
+func searchCmd() *cobra.Command {
+	var (
+		limit   int
+		rebuild bool
+		noColor bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "search [query]",
+		Short:   "Full-text search over notes",
+		Aliases: []string{"s"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			indexPath := usecase.SearchIndexPath(config.GetCacheDir(), cfg.BaseDir)
+
+			index := usecase.NewSearchIndex(cfg.BaseDir)
+			if !rebuild {
+				var err error
+				index, err = usecase.LoadSearchIndex(indexPath, cfg.BaseDir)
+				if err != nil {
+					fmt.Println("検索インデックスの読み込みに失敗しました:", err)
+					os.Exit(1)
+				}
+			}
+			stats, err := index.Update()
+			if err != nil {
+				fmt.Println("検索インデックスの更新に失敗しました:", err)
+				os.Exit(1)
+			}
+			if stats.Added+stats.Updated+stats.Removed > 0 || rebuild {
+				if err := index.Save(indexPath); err != nil {
+					fmt.Println("検索インデックスの保存に失敗しました:", err)
+					os.Exit(1)
+				}
+			}
+
+			results, err := index.Search(strings.Join(args, " "), limit)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			color := !noColor && isTerminal(os.Stdout)
+			for _, result := range results {
+				fmt.Println(result.Path)
+				snippet := result.Snippet
+				if snippet.Match == "" {
+					continue
+				}
+				match := "[" + snippet.Match + "]"
+				if color {
+					match = highlightStart + snippet.Match + highlightEnd
+				}
+				fmt.Printf("  %s%s%s\n", snippet.Before, match, snippet.After)
+			}
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Maximum number of results (0 for no limit)")
+	cmd.Flags().BoolVar(&rebuild, "rebuild", false, "Rebuild the search index from scratch")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "Disable match highlighting with colors")
+	return cmd
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,127 @@
+package krapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func tagsCmd() *cobra.Command {
+	var flat bool
+
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "List the tags in the vault as a tree with note counts",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			tree := usecase.BuildTagTree(loadNoteIndex().Notes)
+			if len(tree.Children) == 0 {
+				fmt.Println("タグはありません")
+				return
+			}
+			printTagTree(os.Stdout, tree, flat, 0)
+		},
+	}
+	cmd.Flags().BoolVar(&flat, "flat", false, "Print full tag names instead of a tree")
+
+	cmd.AddCommand(tagsRenameCmd())
+	cmd.AddCommand(tagsMergeCmd())
+	return cmd
+}
+
+func tagsRenameCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "rename <old> <new>",
+		Short: "Rename a tag and its child tags in frontmatter and note bodies",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			index := loadNoteIndex()
+			tree := usecase.BuildTagTree(index.Notes)
+			if tree.Find(args[0]) == nil {
+				fmt.Println("タグが見つかりません:", args[0])
+				os.Exit(1)
+			}
+			if tree.Find(args[1]) != nil {
+				fmt.Printf("タグ %s はすでに使われています。まとめるには krapp tags merge を使ってください\n", args[1])
+				os.Exit(1)
+			}
+			runTagRename(index, []string{args[0]}, args[1], dryRun, "tags rename")
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the notes to change without writing them")
+	return cmd
+}
+
+func tagsMergeCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "merge <tag>... <into>",
+		Short: "Merge tags into another tag",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			index := loadNoteIndex()
+			runTagRename(index, args[:len(args)-1], args[len(args)-1], dryRun, "tags merge")
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the notes to change without writing them")
+	return cmd
+}
+
+func runTagRename(index *usecase.NoteIndex, from []string, to string, dryRun bool, command string) {
+	edits, err := usecase.PlanTagRename(index, from, to)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if len(edits) == 0 {
+		fmt.Println("変更するノートはありません")
+		return
+	}
+	total := 0
+	for _, edit := range edits {
+		fmt.Printf("%s  (%d件)\n", relativePath(index.BaseDir, edit.Path), edit.Count)
+		total += edit.Count
+	}
+	if dryRun {
+		return
+	}
+
+	tx := getJournal().Begin(command)
+	if err := usecase.ApplyTagRename(edits, tx); err != nil {
+		fmt.Println("タグの書き換えに失敗しました:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d件のノートで%d個のタグを %s に書き換えました\n", len(edits), total, strings.TrimPrefix(to, "#"))
+	printUndoHint(tx)
+}
+
+// loadNoteIndex loads every note of the vault. It exits on error.
+func loadNoteIndex() *usecase.NoteIndex {
+	cfg := getConfig()
+	index, err := usecase.BuildNoteIndex(cfg.BaseDir)
+	if err != nil {
+		fmt.Println("ノートの読み込みに失敗しました:", err)
+		os.Exit(1)
+	}
+	return index
+}
+
+// printTagTree prints the children of node, indented by depth. The count
+// shown is the number of notes with the tag or one of its children.
+func printTagTree(out io.Writer, node *usecase.TagNode, flat bool, depth int) {
+	for _, child := range node.Children {
+		if flat {
+			fmt.Fprintf(out, "#%s (%d)\n", child.Path, child.Total)
+		} else {
+			fmt.Fprintf(out, "%s#%s (%d)\n", strings.Repeat("  ", depth), child.Name, child.Total)
+		}
+		printTagTree(out, child, flat, depth+1)
+	}
+}
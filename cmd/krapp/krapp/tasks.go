@@ -0,0 +1,125 @@
+package krapp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func tasksCmd() *cobra.Command {
+	var (
+		query     usecase.TaskQuery
+		groupBy   string
+		dueBefore string
+		verbose   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "tasks",
+		Short: "List open tasks across the vault",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+
+			var err error
+			if query.DueBefore, err = parseDateFlag(dueBefore); err != nil {
+				fmt.Println("--due-beforeの日付が不正です:", err)
+				os.Exit(1)
+			}
+
+			tasks, failures, err := usecase.CollectTasks(cfg.BaseDir)
+			if err != nil {
+				fmt.Println("タスクの読み込みに失敗しました:", err)
+				os.Exit(1)
+			}
+			if verbose {
+				for path, err := range failures {
+					fmt.Fprintf(os.Stderr, "skip %s: %v\n", path, err)
+				}
+			}
+
+			groups, err := usecase.GroupTasks(usecase.FilterTasks(tasks, query), groupBy)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			printTaskGroups(os.Stdout, groups, groupBy, cfg.BaseDir)
+		},
+	}
+
+	cmd.Flags().StringVarP(&groupBy, "group", "g", usecase.TaskGroupByNote, "Group tasks by: note, due")
+	cmd.Flags().BoolVarP(&query.IncludeClosed, "all", "a", false, "Include done and cancelled tasks")
+	cmd.Flags().StringVar(&query.Tag, "tag", "", "Only tasks with this tag")
+	cmd.Flags().StringVar(&dueBefore, "due-before", "", "Only tasks due on or before this date (YYYY-MM-DD)")
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Report files that could not be read")
+
+	cmd.AddCommand(tasksToggleCmd())
+	return cmd
+}
+
+func tasksToggleCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "toggle <id>",
+		Short:   "Mark a task done, or open again if it is done",
+		Aliases: []string{"done"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := getConfig()
+			tx := getJournal().Begin("tasks toggle")
+
+			task, err := usecase.ToggleTask(cfg.BaseDir, args[0], tx)
+			if err != nil {
+				fmt.Println("タスクの更新に失敗しました:", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s:%d [%s] %s\n", task.Path, task.Line, task.Status, task.Text)
+			printUndoHint(tx)
+		},
+	}
+}
+
+// printTaskGroups prints each group followed by its tasks, one per line
+// with the ID used by "krapp tasks toggle".
+func printTaskGroups(out io.Writer, groups []usecase.TaskGroup, groupBy, baseDir string) {
+	if len(groups) == 0 {
+		fmt.Fprintln(out, "タスクはありません")
+		return
+	}
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintln(out, taskGroupTitle(group, groupBy, baseDir))
+		for _, task := range group.Tasks {
+			line := fmt.Sprintf("  %s [%s] %s", task.ID, task.Status, task.Text)
+			if groupBy == usecase.TaskGroupByDue {
+				line += "  (" + relativePath(baseDir, task.Path) + ")"
+			}
+			fmt.Fprintln(out, line)
+		}
+	}
+}
+
+func taskGroupTitle(group usecase.TaskGroup, groupBy, baseDir string) string {
+	if groupBy != usecase.TaskGroupByDue {
+		return relativePath(baseDir, group.Key)
+	}
+	if group.Key == "" {
+		return "期日なし"
+	}
+	return "📅 " + group.Key
+}
+
+// relativePath returns path relative to baseDir when possible.
+func relativePath(baseDir, path string) string {
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,61 @@
+package krapp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ishida722/krapp-go/usecase"
+	"github.com/spf13/cobra"
+)
+
+func undoCmd() *cobra.Command {
+	var list bool
+
+	cmd := &cobra.Command{
+		Use:   "undo [txid]",
+		Short: "Revert the last (or the given) krapp operation",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			journal := getJournal()
+
+			if list {
+				transactions, err := journal.List()
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				for _, tx := range transactions {
+					state := ""
+					if tx.Undone {
+						state = " (undone)"
+					}
+					fmt.Printf("%s  %-14s %d ops%s\n", tx.ID, tx.Command, len(tx.Ops), state)
+				}
+				return
+			}
+
+			var (
+				tx  *usecase.Transaction
+				err error
+			)
+			if len(args) == 1 {
+				tx, err = journal.Load(args[0])
+			} else {
+				tx, err = journal.Last()
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			if err := journal.Undo(tx, &usecase.GHClient{}); err != nil {
+				fmt.Println("取り消しに失敗しました:", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s (%s) を取り消しました: %d件の操作\n", tx.ID, tx.Command, len(tx.Ops))
+		},
+	}
+
+	cmd.Flags().BoolVarP(&list, "list", "l", false, "List recorded transactions")
+	return cmd
+}
@@ -2,6 +2,8 @@ package krapp
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/ishida722/krapp-go/config"
 	"github.com/ishida722/krapp-go/usecase"
@@ -18,3 +20,24 @@ func openFile(cmd *cobra.Command, config config.Config, filePath string) error {
 	}
 	return nil
 }
+
+func getJournal() *usecase.Journal {
+	return usecase.NewJournal(filepath.Join(config.GetStateDir(), "journal"))
+}
+
+// printUndoHint tells the user how to revert a transaction that recorded something.
+func printUndoHint(tx *usecase.Transaction) {
+	if len(tx.Ops) == 0 {
+		return
+	}
+	fmt.Printf("取り消すには: krapp undo %s\n", tx.ID)
+}
+
+// printUndoHintStderr is printUndoHint for the create commands, whose
+// standard output is only the path of the note.
+func printUndoHintStderr(tx *usecase.Transaction) {
+	if len(tx.Ops) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "取り消すには: krapp undo %s\n", tx.ID)
+}
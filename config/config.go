@@ -10,14 +10,59 @@ import (
 )
 
 type Config struct {
-	BaseDir              string         `yaml:"base_dir"`
-	DailyNoteDir         string         `yaml:"daily_note_dir"`
-	Inbox                string         `yaml:"inbox_dir"`
-	Editor               string         `yaml:"editor"`
-	WithAlwaysOpenEditor bool           `yaml:"with_always_open_editor"` // trueなら常にエディタを開く
-	EditorOption         string         `yaml:"editor_option"`           // エディタのオプション
-	DailyTemplate        map[string]any `yaml:"daily_template"`          // デイリーノート用テンプレート
-	InboxTemplate        map[string]any `yaml:"inbox_template"`          // インボックスノート用テンプレート
+	BaseDir              string                        `yaml:"base_dir"`
+	DailyNoteDir         string                        `yaml:"daily_note_dir"`
+	Inbox                string                        `yaml:"inbox_dir"`
+	Editor               string                        `yaml:"editor"`
+	WithAlwaysOpenEditor bool                          `yaml:"with_always_open_editor"` // trueなら常にエディタを開く
+	EditorOption         string                        `yaml:"editor_option"`           // エディタのオプション
+	DailyTemplate        map[string]any                `yaml:"daily_template"`          // デイリーノート用テンプレート
+	InboxTemplate        map[string]any                `yaml:"inbox_template"`          // インボックスノート用テンプレート
+	TemplateDir          string                        `yaml:"template_dir"`            // 本文テンプレートのディレクトリ（base_dirからの相対パスも可）
+	LabelDirectoryMap    map[string]string             `yaml:"label_directory_map"`     // ラベルと移動先ディレクトリの対応
+	Labels               []LabelDefinition             `yaml:"labels"`                  // labelingコマンドで選択できるラベル
+	DailyPathPattern     string                        `yaml:"daily_path_pattern"`      // daily_note_dirからのパス（例: %Y/%m/%Y-%m-%d）
+	InboxFilenamePattern string                        `yaml:"inbox_filename_pattern"`  // 例: %Y-%m-%d-{{.Title}}
+	IssueFilenamePattern string                        `yaml:"issue_filename_pattern"`  // 例: %Y-%m-%d-issue-{{.Number}}-{{.Slug}}
+	DailyRollover        DailyRolloverConfig           `yaml:"daily_rollover"`          // 未完了タスクの持ち越し
+	PeriodicNotes        map[string]PeriodicNoteConfig `yaml:"periodic_notes"`          // 定期ノート（daily/weekly/monthly/yearly）の設定
+	FrontMatterSchemas   map[string]FrontMatterSchema  `yaml:"frontmatter_schemas"`     // フォルダごとのfrontmatterの規約（"."はvault全体）
+	CreatedSources       []string                      `yaml:"created_sources"`         // backfill createdで日付を探す順序（filename, body, git, mtime）
+}
+
+// DailyRolloverConfig はデイリーノート作成時に前のノートの未完了タスクを持ち越す設定
+type DailyRolloverConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	Section      string `yaml:"section"`       // タスクを追加する見出し（例: "## 持ち越し"）
+	MarkMoved    bool   `yaml:"mark_moved"`    // 元のノートのタスクを [>] にする
+	LookbackDays int    `yaml:"lookback_days"` // 前のデイリーノートを探す日数
+}
+
+// PeriodicNoteConfig は定期ノートの種類ごとの設定
+type PeriodicNoteConfig struct {
+	Dir             string         `yaml:"dir"`              // base_dirからのディレクトリ
+	FilenamePattern string         `yaml:"filename_pattern"` // 例: %G/%G-W%V（strftime形式）
+	Template        map[string]any `yaml:"template"`         // frontmatterのテンプレート
+	BodyTemplate    string         `yaml:"body_template"`    // template_dir内の本文テンプレート名
+}
+
+// FrontMatterSchema はフォルダ内のノートのfrontmatterの規約
+type FrontMatterSchema struct {
+	Required []string                    `yaml:"required"` // 必須のキー
+	Fields   map[string]FrontMatterField `yaml:"fields"`   // キーごとの値の規約
+}
+
+// FrontMatterField はfrontmatterのキーの値の規約
+type FrontMatterField struct {
+	Type   string   `yaml:"type"`   // string, number, integer, boolean, list, map, date
+	Enum   []string `yaml:"enum"`   // 許される値
+	Format string   `yaml:"format"` // dateの書式（例: %Y-%m-%d）
+}
+
+// LabelDefinition はlabelingコマンドのキーとラベル名の対応
+type LabelDefinition struct {
+	Key  string `yaml:"key"`
+	Name string `yaml:"name"`
 }
 
 var defaultConfig = Config{
@@ -27,6 +72,7 @@ var defaultConfig = Config{
 	Editor:               "vim",   // デフォルトのエディタ
 	WithAlwaysOpenEditor: false,   // デフォルトでは常にエディタを開かない
 	EditorOption:         "",      // デフォルトのエディタオプション
+	TemplateDir:          "templates",
 	DailyTemplate: map[string]any{
 		"tags": []string{},
 	},
@@ -34,6 +80,11 @@ var defaultConfig = Config{
 		"tags":   []string{},
 		"status": "new",
 	},
+	Labels: []LabelDefinition{
+		{Key: "1", Name: "diary"},
+		{Key: "2", Name: "idea"},
+		{Key: "3", Name: "review"},
+	},
 }
 
 type ConfigPaths struct {
@@ -51,6 +102,26 @@ func getXDGConfigPath() string {
 	return filepath.Join(os.Getenv("HOME"), ".config", "krapp", "config.yaml")
 }
 
+// GetCacheDir returns the XDG-compliant cache directory for krapp
+func GetCacheDir() string {
+	// Check XDG_CACHE_HOME first
+	if xdgCacheHome := os.Getenv("XDG_CACHE_HOME"); xdgCacheHome != "" {
+		return filepath.Join(xdgCacheHome, "krapp")
+	}
+	// Fall back to ~/.cache/krapp
+	return filepath.Join(os.Getenv("HOME"), ".cache", "krapp")
+}
+
+// GetStateDir returns the XDG-compliant state directory for krapp
+func GetStateDir() string {
+	// Check XDG_STATE_HOME first
+	if xdgStateHome := os.Getenv("XDG_STATE_HOME"); xdgStateHome != "" {
+		return filepath.Join(xdgStateHome, "krapp")
+	}
+	// Fall back to ~/.local/state/krapp
+	return filepath.Join(os.Getenv("HOME"), ".local", "state", "krapp")
+}
+
 var defaultConfigPaths = ConfigPaths{
 	Global: getXDGConfigPath(),
 	Local:  ".krapp_config.yaml",
@@ -176,6 +247,7 @@ func LoadConfig() (Config, error) {
 		mergedConfig := MergeConfig(defaultConfig, globalConfig)
 		// BaseDir内の~をホームディレクトリに展開
 		mergedConfig.BaseDir = expandHomePath(mergedConfig.BaseDir)
+		mergedConfig.TemplateDir = expandHomePath(mergedConfig.TemplateDir)
 		return mergedConfig, nil
 	}
 
@@ -187,6 +259,7 @@ func LoadConfig() (Config, error) {
 
 	// BaseDir内の~をホームディレクトリに展開
 	fixedConfig.BaseDir = expandHomePath(fixedConfig.BaseDir)
+	fixedConfig.TemplateDir = expandHomePath(fixedConfig.TemplateDir)
 
 	return fixedConfig, nil
 }
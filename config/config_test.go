@@ -135,6 +135,28 @@ func TestXDGConfigPath(t *testing.T) {
 	assert.Equal(t, "/home/user/.config/krapp/config.yaml", path)
 }
 
+// TestGetCacheDir tests the XDG cache directory resolution
+func TestGetCacheDir(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	t.Setenv("XDG_CACHE_HOME", "/custom/cache")
+	assert.Equal(t, "/custom/cache/krapp", GetCacheDir())
+
+	t.Setenv("XDG_CACHE_HOME", "")
+	assert.Equal(t, "/home/user/.cache/krapp", GetCacheDir())
+}
+
+// TestGetStateDir tests the XDG state directory resolution
+func TestGetStateDir(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+
+	t.Setenv("XDG_STATE_HOME", "/custom/state")
+	assert.Equal(t, "/custom/state/krapp", GetStateDir())
+
+	t.Setenv("XDG_STATE_HOME", "")
+	assert.Equal(t, "/home/user/.local/state/krapp", GetStateDir())
+}
+
 // TestMigrateLegacyConfig tests the legacy config migration
 func TestMigrateLegacyConfig(t *testing.T) {
 	tempDir := t.TempDir()
@@ -284,3 +306,24 @@ func TestLoadConfigWithHomeExpansion(t *testing.T) {
 		ResetConfigPaths()
 	})
 }
+
+func TestLoadConfig_LabelDirectoryMap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "label_directory_map:\n  diary: journal/diary\n  idea: ideas\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	cfg, err := loadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"diary": "journal/diary", "idea": "ideas"}, cfg.LabelDirectoryMap)
+}
+
+func TestMergeConfig_Labels(t *testing.T) {
+	global := GetDefaultConfig()
+	local := Config{Labels: []LabelDefinition{{Key: "m", Name: "meeting"}}}
+
+	merged := MergeConfig(global, local)
+	assert.Equal(t, []LabelDefinition{{Key: "m", Name: "meeting"}}, merged.Labels)
+
+	merged = MergeConfig(global, Config{})
+	assert.Equal(t, global.Labels, merged.Labels)
+}
@@ -2,7 +2,9 @@ package models
 
 import (
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"gopkg.in/yaml.v3"
 )
@@ -65,3 +67,84 @@ func (fm FrontMatter) Label() (string, error) {
 func (fm FrontMatter) Exists() bool {
 	return len(fm) > 0
 }
+
+func (fm FrontMatter) Status() (string, error) {
+	statusValue, ok := fm["status"]
+	if !ok {
+		return "", fmt.Errorf("status field not found")
+	}
+	switch v := statusValue.(type) {
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("status field is not a string")
+	}
+}
+
+// Tags returns the tags field as a list. A string value is split on commas
+// and whitespace so that both `tags: [a, b]` and `tags: a, b` are accepted.
+func (fm FrontMatter) Tags() []string {
+	tagsValue, ok := fm["tags"]
+	if !ok || tagsValue == nil {
+		return []string{}
+	}
+	var tags []string
+	switch v := tagsValue.(type) {
+	case string:
+		tags = strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+	case []string:
+		tags = append(tags, v...)
+	case []any:
+		for _, item := range v {
+			if item == nil {
+				continue
+			}
+			tags = append(tags, fmt.Sprint(item))
+		}
+	default:
+		tags = []string{fmt.Sprint(v)}
+	}
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// Aliases returns the alternative names of the note from "aliases" (or
+// "alias"), given as a list or a comma separated string.
+func (fm FrontMatter) Aliases() []string {
+	value, ok := fm["aliases"]
+	if !ok {
+		value = fm["alias"]
+	}
+	var aliases []string
+	switch v := value.(type) {
+	case nil:
+		return []string{}
+	case string:
+		aliases = strings.Split(v, ",")
+	case []string:
+		aliases = v
+	case []any:
+		for _, item := range v {
+			if item != nil {
+				aliases = append(aliases, fmt.Sprint(item))
+			}
+		}
+	default:
+		aliases = []string{fmt.Sprint(v)}
+	}
+	result := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			result = append(result, alias)
+		}
+	}
+	return result
+}
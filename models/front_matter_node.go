@@ -0,0 +1,272 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterDocument keeps the parsed YAML of a note's frontmatter so that
+// saving the note only touches the keys that changed. Key order, comments
+// and scalar styles of everything else are left as they were.
+type frontMatterDocument struct {
+	node     *yaml.Node // ドキュメントノード（空のfrontmatterならnil）
+	raw      string     // 区切り行を含む元のfrontmatter
+	closing  string     // 終わりの区切り（"---" または "..."）
+	modified bool       // ノードを直接書き換えた（rawは使えない）
+}
+
+// parseFrontMatterDocument parses the YAML of a scanned frontmatter block.
+func parseFrontMatterDocument(block frontMatterBlock) (FrontMatter, *frontMatterDocument, error) {
+	doc := &frontMatterDocument{raw: block.raw, closing: block.closing}
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(block.yaml), &node); err != nil {
+		return nil, nil, frontMatterYAMLError(err)
+	}
+	var fm FrontMatter
+	if err := node.Decode(&fm); err != nil {
+		return nil, nil, frontMatterYAMLError(err)
+	}
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+		doc.node = &node
+	}
+	return fm, doc, nil
+}
+
+// encode returns the frontmatter for fm with the delimiters, reusing the
+// original text when nothing changed and the original nodes otherwise.
+func (doc *frontMatterDocument) encode(fm FrontMatter) (string, error) {
+	if doc.node == nil {
+		if len(fm) == 0 {
+			return doc.raw, nil
+		}
+		return doc.marshal(fm)
+	}
+	var original FrontMatter
+	if err := doc.node.Decode(&original); err == nil && !doc.modified && sameFrontMatter(original, fm) {
+		return doc.raw, nil
+	}
+
+	mapping := doc.node.Content[0]
+	changed := false
+
+	content := make([]*yaml.Node, 0, len(mapping.Content))
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		seen[key.Value] = true
+		newValue, ok := fm[key.Value]
+		if !ok {
+			// 削除されたキー
+			changed = true
+			continue
+		}
+		var oldValue any
+		if err := value.Decode(&oldValue); err == nil && sameFrontMatterValue(oldValue, newValue) {
+			content = append(content, key, value)
+			continue
+		}
+		node, err := frontMatterValueNode(newValue, value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", key.Value, err)
+		}
+		content = append(content, key, node)
+		changed = true
+	}
+
+	// 追加されたキーは末尾に名前順で足す
+	var added []string
+	for key := range fm {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		node, err := frontMatterValueNode(fm[key], nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", key, err)
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, node)
+		changed = true
+	}
+
+	if !changed && !doc.modified {
+		return doc.raw, nil
+	}
+	if len(content) == 0 {
+		return "---\n{}\n" + doc.closing + "\n", nil
+	}
+
+	updated := *mapping
+	updated.Content = content
+	document := *doc.node
+	document.Content = []*yaml.Node{&updated}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return "---\n" + buf.String() + doc.closing + "\n", nil
+}
+
+// marshal writes fm as a new frontmatter keeping the closing delimiter.
+func (doc *frontMatterDocument) marshal(fm FrontMatter) (string, error) {
+	out, err := yaml.Marshal(fm)
+	if err != nil {
+		return "", err
+	}
+	return "---\n" + string(out) + doc.closing + "\n", nil
+}
+
+// keyNode returns the node of key in the frontmatter mapping, or nil.
+func (doc *frontMatterDocument) keyNode(key string) *yaml.Node {
+	if doc == nil || doc.node == nil {
+		return nil
+	}
+	mapping := doc.node.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i]
+		}
+	}
+	return nil
+}
+
+// FrontMatterKeyLine returns the line of the file where key is written, or
+// 0 when the note was not read from a file or does not have the key.
+func (note Note) FrontMatterKeyLine(key string) int {
+	if note.source == nil {
+		return 0
+	}
+	if node := note.source.frontMatter.keyNode(key); node != nil {
+		// YAMLの1行目はファイルの2行目
+		return node.Line + 1
+	}
+	return 0
+}
+
+// RenameFrontMatterKey renames the key from to to, keeping its place in
+// the frontmatter. It returns false when from does not exist or to already
+// does.
+func (note *Note) RenameFrontMatterKey(from, to string) bool {
+	value, ok := note.FrontMatter[from]
+	if !ok {
+		return false
+	}
+	if _, exists := note.FrontMatter[to]; exists {
+		return false
+	}
+	delete(note.FrontMatter, from)
+	note.FrontMatter[to] = value
+	if note.source != nil {
+		if node := note.source.frontMatter.keyNode(from); node != nil {
+			node.Value = to
+			note.source.frontMatter.modified = true
+		}
+	}
+	return true
+}
+
+// frontMatterValueNode encodes value as a node. When it replaces old, the
+// comments and, where the value allows it, the style of old are kept.
+func frontMatterValueNode(value any, old *yaml.Node) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return node, nil
+	}
+	node.HeadComment = old.HeadComment
+	node.LineComment = old.LineComment
+	node.FootComment = old.FootComment
+	if node.Kind != old.Kind {
+		return node, nil
+	}
+	switch node.Kind {
+	case yaml.SequenceNode, yaml.MappingNode:
+		// [a, b] のようなフロー形式を保つ
+		node.Style = old.Style
+	case yaml.ScalarNode:
+		if node.ShortTag() == old.ShortTag() {
+			node.Style = old.Style
+		} else if old.ShortTag() == "!!timestamp" && old.Style == 0 && node.ShortTag() == "!!str" && isDateString(node.Value) {
+			// 引用符なしの日付は日付のまま書き換える
+			node.Style = 0
+			node.Tag = ""
+		}
+	}
+	return node, nil
+}
+
+func sameFrontMatter(a, b FrontMatter) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		other, ok := b[key]
+		if !ok || !sameFrontMatterValue(value, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameFrontMatterValue reports whether a value read from the file and the
+// value in the map are the same. Dates read as time.Time are equal to the
+// strings SetCreated writes.
+func sameFrontMatterValue(old, value any) bool {
+	if t, ok := old.(time.Time); ok {
+		if s, ok := value.(string); ok {
+			return s == t.Format("2006-01-02") || s == t.Format(time.RFC3339)
+		}
+	}
+	return reflect.DeepEqual(normalizeFrontMatterValue(old), normalizeFrontMatterValue(value))
+}
+
+// normalizeFrontMatterValue converts typed slices and maps to the []any and
+// map[string]any forms produced by decoding.
+func normalizeFrontMatterValue(value any) any {
+	switch v := value.(type) {
+	case []string:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = normalizeFrontMatterValue(item)
+		}
+		return items
+	case FrontMatter:
+		return normalizeFrontMatterValue(map[string]any(v))
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[key] = normalizeFrontMatterValue(item)
+		}
+		return m
+	default:
+		return value
+	}
+}
+
+func isDateString(s string) bool {
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return true
+	}
+	_, err := time.Parse(time.RFC3339, s)
+	return err == nil
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNote_ToString_PreservesFrontMatter(t *testing.T) {
+	raw := "---\n# ノートの情報\ntitle: \"会議メモ\"  # 題名\ncreated: 2025-06-01\ntags: [meeting, work]\nstatus: new\n---\n本文"
+
+	tests := []struct {
+		name   string
+		edit   func(fm FrontMatter)
+		wantFM string
+	}{
+		{
+			name:   "unchanged",
+			edit:   func(fm FrontMatter) {},
+			wantFM: "---\n# ノートの情報\ntitle: \"会議メモ\"  # 題名\ncreated: 2025-06-01\ntags: [meeting, work]\nstatus: new\n---\n",
+		},
+		{
+			name: "same values written back",
+			edit: func(fm FrontMatter) {
+				fm.SetCreated(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+				fm["tags"] = []string{"meeting", "work"}
+			},
+			wantFM: "---\n# ノートの情報\ntitle: \"会議メモ\"  # 題名\ncreated: 2025-06-01\ntags: [meeting, work]\nstatus: new\n---\n",
+		},
+		{
+			name: "changed values keep order, comments and style",
+			edit: func(fm FrontMatter) {
+				fm["title"] = "議事録"
+				fm.SetCreated(time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC))
+				fm["tags"] = []string{"meeting"}
+			},
+			wantFM: "---\n# ノートの情報\ntitle: \"議事録\" # 題名\ncreated: 2025-06-02\ntags: [meeting]\nstatus: new\n---\n",
+		},
+		{
+			name: "added and removed keys",
+			edit: func(fm FrontMatter) {
+				delete(fm, "status")
+				fm["label"] = "diary"
+				fm["aliases"] = []string{"a"}
+			},
+			wantFM: "---\n# ノートの情報\ntitle: \"会議メモ\" # 題名\ncreated: 2025-06-01\ntags: [meeting, work]\naliases:\n  - a\nlabel: diary\n---\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note, err := ParseNote(raw)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.edit(note.FrontMatter)
+			got, err := note.ToString()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := tt.wantFM + "本文"; got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseNote_FrontMatterGetters(t *testing.T) {
+	note, err := ParseNote("---\ncreated: 2025-06-01\nlabel: diary\n---\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	created, err := note.FrontMatter.Created()
+	if err != nil || created.Format("2006-01-02") != "2025-06-01" {
+		t.Errorf("unexpected created: %v, %v", created, err)
+	}
+	if label, err := note.FrontMatter.Label(); err != nil || label != "diary" {
+		t.Errorf("unexpected label: %q, %v", label, err)
+	}
+}
+
+func TestNote_RenameFrontMatterKey(t *testing.T) {
+	note, err := ParseNote("---\ntitle: memo\nCreated: 2025-06-01  # 作成日\nstatus: new\n---\n本文")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := note.FrontMatterKeyLine("Created"); line != 3 {
+		t.Errorf("expected Created on line 3, got %d", line)
+	}
+	if note.RenameFrontMatterKey("status", "title") {
+		t.Error("renaming to an existing key should fail")
+	}
+	if !note.RenameFrontMatterKey("Created", "created") {
+		t.Fatal("failed to rename Created")
+	}
+	if line := note.FrontMatterKeyLine("created"); line != 3 {
+		t.Errorf("expected created on line 3, got %d", line)
+	}
+
+	got, err := note.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\ntitle: memo\ncreated: 2025-06-01 # 作成日\nstatus: new\n---\n本文"
+	if got != want {
+		t.Errorf("unexpected note:\n%q\nwant:\n%q", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const utf8BOM = "\ufeff"
+
+// FrontMatterError is a frontmatter parse error. Line is the line of the
+// file, counting from 1.
+type FrontMatterError struct {
+	Line int
+	Msg  string
+}
+
+func (e *FrontMatterError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// frontMatterBlock is the top of a note file split into its parts. The
+// text is the file with any BOM removed and, for CRLF files, the line
+// endings converted to "\n".
+type frontMatterBlock struct {
+	found   bool
+	yaml    string // 区切り行の間のYAML
+	raw     string // 区切り行を含むfrontmatter全体
+	closing string // "---" または "..."
+	body    string
+}
+
+var (
+	yamlErrorLinePattern  = regexp.MustCompile(`^line (\d+): `)
+	yamlLineNumberPattern = regexp.MustCompile(`line \d+`)
+)
+
+// scanFrontMatter splits text into frontmatter and body. The frontmatter
+// starts with a "---" line at the very top of the file and ends with the
+// next "---" or "..." line; "---" anywhere else is part of the body.
+func scanFrontMatter(text string) (frontMatterBlock, error) {
+	firstEnd := strings.IndexByte(text, '\n')
+	if firstEnd < 0 || !isFrontMatterDelimiter(text[:firstEnd], "---") {
+		return frontMatterBlock{body: text}, nil
+	}
+
+	pos := firstEnd + 1
+	for pos <= len(text) {
+		end := strings.IndexByte(text[pos:], '\n')
+		line := text[pos:]
+		next := len(text) + 1
+		if end >= 0 {
+			line = text[pos : pos+end]
+			next = pos + end + 1
+		}
+		for _, closing := range []string{"---", "..."} {
+			if isFrontMatterDelimiter(line, closing) {
+				rawEnd := min(next, len(text))
+				return frontMatterBlock{
+					found:   true,
+					yaml:    text[firstEnd+1 : pos],
+					raw:     text[:rawEnd],
+					closing: closing,
+					body:    text[rawEnd:],
+				}, nil
+			}
+		}
+		if end < 0 {
+			break
+		}
+		pos = next
+	}
+	return frontMatterBlock{}, &FrontMatterError{Line: 1, Msg: "frontmatter is not closed with --- or ..."}
+}
+
+// isFrontMatterDelimiter reports whether line is delimiter, ignoring
+// trailing spaces and a carriage return.
+func isFrontMatterDelimiter(line, delimiter string) bool {
+	return strings.TrimRight(line, " \t\r") == delimiter
+}
+
+// frontMatterYAMLError converts an error from the YAML parser, whose line
+// numbers count from the first line after the opening delimiter, to a
+// FrontMatterError with the line numbers of the file.
+func frontMatterYAMLError(err error) error {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+	}
+
+	line := 2
+	if m := yamlErrorLinePattern.FindStringSubmatchIndex(msg); m != nil {
+		n, _ := strconv.Atoi(msg[m[2]:m[3]])
+		line = n + 1
+		msg = msg[m[1]:]
+	}
+	// メッセージ中の他の行番号もファイルの行番号にする
+	msg = yamlLineNumberPattern.ReplaceAllStringFunc(msg, func(s string) string {
+		n, _ := strconv.Atoi(strings.TrimPrefix(s, "line "))
+		return "line " + strconv.Itoa(n+1)
+	})
+	return &FrontMatterError{Line: line, Msg: strings.TrimSpace(msg)}
+}
+
+// usesCRLF reports whether every line of raw ends with "\r\n", so that the
+// text can be read with "\n" and written back unchanged.
+func usesCRLF(raw string) bool {
+	if !strings.Contains(raw, "\r\n") {
+		return false
+	}
+	return strings.Count(raw, "\n") == strings.Count(raw, "\r\n")
+}
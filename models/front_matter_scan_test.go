@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseNote_FrontMatterSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		title   any
+		content string
+	}{
+		{"dots terminator", "---\ntitle: a\n...\nbody", "a", "body"},
+		{"crlf", "---\r\ntitle: a\r\n---\r\n\r\nbody\r\nline2\r\n", "a", "body\nline2"},
+		{"bom", "\ufeff---\ntitle: a\n---\nbody", "a", "body"},
+		{"delimiter with trailing spaces", "--- \ntitle: a\n---  \nbody", "a", "body"},
+		{"dashes in body", "---\ntitle: a\n---\nbody\n---\nmore --- text", "a", "body\n---\nmore --- text"},
+		{"dashes in value", "---\ntitle: a---b\n---\nbody", "a---b", "body"},
+		{"no frontmatter", "body\n---\nx: y", nil, "body\n---\nx: y"},
+		{"not at top", "\n---\ntitle: a\n---\n", nil, "---\ntitle: a\n---"},
+		{"empty frontmatter", "---\n---\nbody", nil, "body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note, err := ParseNote(tt.raw)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if note.FrontMatter["title"] != tt.title {
+				t.Errorf("title = %v, want %v", note.FrontMatter["title"], tt.title)
+			}
+			if note.Content != tt.content {
+				t.Errorf("content = %q, want %q", note.Content, tt.content)
+			}
+			out, err := note.ToString()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != tt.raw {
+				t.Errorf("round trip changed the note:\n%q\n%q", tt.raw, out)
+			}
+		})
+	}
+}
+
+func TestParseNote_FrontMatterErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		line int
+	}{
+		{"not closed", "---\ntitle: a\nbody", 1},
+		{"invalid yaml", "---\ntitle: a\nb: c: d\n---\nbody", 3},
+		{"duplicate key", "---\na: 1\na: 2\n---\n", 3},
+		{"bad indentation", "---\ntitle: a\n  b: c\n---\n", 3},
+		{"not a mapping", "---\n- a\n- b\n---\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseNote(tt.raw)
+			var fmErr *FrontMatterError
+			if !errors.As(err, &fmErr) {
+				t.Fatalf("expected FrontMatterError, got %v", err)
+			}
+			if fmErr.Line != tt.line {
+				t.Errorf("line = %d, want %d (%v)", fmErr.Line, tt.line, err)
+			}
+		})
+	}
+}
+
+func TestNote_ToString_KeepsLayoutWhenEdited(t *testing.T) {
+	raw := "\ufeff---\r\ntitle: a\r\n...\r\n\r\n\r\nbody\r\n"
+	note, err := ParseNote(raw)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	note.FrontMatter["label"] = "diary"
+	note.Content += "\nmore"
+	out, err := note.ToString()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "\ufeff---\r\ntitle: a\r\nlabel: diary\r\n...\r\n\r\n\r\nbody\r\nmore\r\n"
+	if out != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	// frontmatterのないノートに追加する
+	note, err = ParseNote("\nbody\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	note.FrontMatter["label"] = "diary"
+	if out, _ := note.ToString(); out != "---\nlabel: diary\n---\n\n\nbody\n" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
+
+func FuzzParseNoteRoundTrip(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"body only",
+		"---\ntitle: test\n---\nBody here",
+		"---\ntitle: a\n...\nbody\n---\nmore",
+		"---\r\ntitle: a\r\ntags: [x, y]\r\n---\r\n\r\nbody\r\n",
+		"\ufeff---\n# comment\ncreated: 2025-06-01\n---\n",
+		"---\n---\n",
+		"---\n{}\n---\n\n  indented body  \n\n",
+		"---\nlist:\n  - a\n  - \"b\"\nnested:\n  k: v\n---\nmixed\r\nendings\n",
+		"---\ntitle: a",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		note, err := ParseNote(raw)
+		if err != nil {
+			return
+		}
+		out, err := note.ToString()
+		if err != nil {
+			t.Fatalf("ToString failed: %v", err)
+		}
+		if out != raw {
+			t.Fatalf("round trip changed the note:\n%q\n%q", raw, out)
+		}
+		if strings.Contains(note.Content, "\r\n") && !strings.Contains(raw, "\r\n") {
+			t.Fatalf("unexpected CRLF in content")
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFrontMatter_Tags(t *testing.T) {
+	tests := []struct {
+		name     string
+		fm       FrontMatter
+		expected []string
+	}{
+		{"missing", FrontMatter{}, []string{}},
+		{"list", FrontMatter{"tags": []any{"a", "#b"}}, []string{"a", "b"}},
+		{"string list", FrontMatter{"tags": []string{"a"}}, []string{"a"}},
+		{"comma separated", FrontMatter{"tags": "a, b c"}, []string{"a", "b", "c"}},
+		{"null", FrontMatter{"tags": nil}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fm.Tags(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Tags() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFrontMatter_Status(t *testing.T) {
+	if _, err := (FrontMatter{}).Status(); err == nil {
+		t.Error("expected error when status is missing")
+	}
+	status, err := FrontMatter{"status": "new"}.Status()
+	if err != nil || status != "new" {
+		t.Errorf("Status() = %q, %v", status, err)
+	}
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LinkKind is the syntax a link was written in.
+type LinkKind string
+
+const (
+	LinkWiki     LinkKind = "wikilink" // [[note#heading|alias]]
+	LinkMarkdown LinkKind = "markdown" // [text](path.md#heading)
+)
+
+// Link is a link to another note or file found in a note body.
+type Link struct {
+	Kind    LinkKind
+	Target  string // 見出しと別名を除いたリンク先
+	Heading string // #以降（ブロック参照の^idを含む）
+	Alias   string // 表示テキスト
+	Embed   bool   // ![[...]] / ![...](...)
+	Raw     string // 元の記述
+	Line    int    // 1始まりの行番号
+	Offset  int    // 行内のバイト位置
+}
+
+var (
+	wikiLinkPattern     = regexp.MustCompile(`(!?)\[\[([^\[\]\n]+?)\]\]`)
+	markdownLinkPattern = regexp.MustCompile(`(!?)\[([^\[\]\n]*)\]\((<[^>\n]+>|[^)\s]+)(?:\s+"[^"\n]*")?\)`)
+	urlSchemePattern    = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	inlineCodePattern   = regexp.MustCompile("`[^`\n]*`")
+)
+
+// ParseLinks returns the internal links in content. External URLs and
+// links inside code are ignored. Line numbers are relative to content.
+func ParseLinks(content string) []Link {
+	return parseLinkLines(strings.Split(content, "\n"), 0)
+}
+
+// ParseFileLinks returns the links of a raw note file, skipping its
+// frontmatter. Line numbers are those of the file.
+func ParseFileLinks(raw string) []Link {
+	lines := strings.Split(raw, "\n")
+	return parseLinkLines(lines, frontMatterLineCount(lines))
+}
+
+// Links returns the links in the body of the note.
+func (note Note) Links() []Link {
+	return ParseLinks(note.Content)
+}
+
+func parseLinkLines(lines []string, start int) []Link {
+	var links []Link
+	inCode := false
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		for _, link := range ParseLinkLine(line) {
+			link.Line = i + 1
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// ParseLinkLine returns the links in a single line, in order of appearance.
+func ParseLinkLine(line string) []Link {
+	// インラインコードは同じ長さの空白に置き換えて位置を保つ
+	masked := inlineCodePattern.ReplaceAllStringFunc(line, func(code string) string {
+		return strings.Repeat(" ", len(code))
+	})
+
+	var links []Link
+	wikiRanges := wikiLinkPattern.FindAllStringSubmatchIndex(masked, -1)
+	for _, m := range wikiRanges {
+		link := Link{
+			Kind:   LinkWiki,
+			Embed:  m[3] > m[2],
+			Raw:    line[m[0]:m[1]],
+			Offset: m[0],
+		}
+		inner := line[m[4]:m[5]]
+		if target, alias, ok := strings.Cut(inner, "|"); ok {
+			inner = target
+			link.Alias = strings.TrimSpace(alias)
+		}
+		if target, heading, ok := strings.Cut(inner, "#"); ok {
+			inner = target
+			link.Heading = strings.TrimSpace(heading)
+		}
+		link.Target = strings.TrimSpace(inner)
+		links = append(links, link)
+	}
+
+	for _, m := range markdownLinkPattern.FindAllStringSubmatchIndex(masked, -1) {
+		if insideRanges(m[0], wikiRanges) {
+			continue
+		}
+		target := strings.TrimSuffix(strings.TrimPrefix(line[m[6]:m[7]], "<"), ">")
+		if target == "" || strings.HasPrefix(target, "#") || urlSchemePattern.MatchString(target) {
+			continue
+		}
+		link := Link{
+			Kind:   LinkMarkdown,
+			Alias:  line[m[4]:m[5]],
+			Embed:  m[3] > m[2],
+			Raw:    line[m[0]:m[1]],
+			Offset: m[0],
+		}
+		if path, heading, ok := strings.Cut(target, "#"); ok {
+			target = path
+			link.Heading = heading
+		}
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		link.Target = target
+		links = append(links, link)
+	}
+
+	// 出現順に並べる
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].Offset < links[j].Offset
+	})
+	return links
+}
+
+func insideRanges(pos int, ranges [][]int) bool {
+	for _, r := range ranges {
+		if pos >= r[0] && pos < r[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestParseLinkLine(t *testing.T) {
+	line := "see [[note#見出し|別名]], ![[image.png]] and [doc](../docs/My%20Doc.md#intro) `[[code]]` [web](https://example.com) [top](#top)"
+	links := ParseLinkLine(line)
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links, got %d: %+v", len(links), links)
+	}
+
+	wiki := links[0]
+	if wiki.Kind != LinkWiki || wiki.Target != "note" || wiki.Heading != "見出し" || wiki.Alias != "別名" || wiki.Embed {
+		t.Errorf("unexpected wikilink: %+v", wiki)
+	}
+	if line[wiki.Offset:wiki.Offset+len(wiki.Raw)] != wiki.Raw {
+		t.Errorf("offset does not point at raw link: %d", wiki.Offset)
+	}
+
+	embed := links[1]
+	if !embed.Embed || embed.Target != "image.png" {
+		t.Errorf("unexpected embed: %+v", embed)
+	}
+
+	md := links[2]
+	if md.Kind != LinkMarkdown || md.Target != "../docs/My Doc.md" || md.Heading != "intro" || md.Alias != "doc" {
+		t.Errorf("unexpected markdown link: %+v", md)
+	}
+}
+
+func TestParseFileLinks(t *testing.T) {
+	raw := "---\naliases: [x]\n---\n[[a]]\n```\n[[b]]\n```\ntext [[c]] [[d|D]]\n"
+	links := ParseFileLinks(raw)
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links, got %d: %+v", len(links), links)
+	}
+	if links[0].Target != "a" || links[0].Line != 4 {
+		t.Errorf("unexpected first link: %+v", links[0])
+	}
+	if links[2].Target != "d" || links[2].Line != 8 {
+		t.Errorf("unexpected last link: %+v", links[2])
+	}
+}
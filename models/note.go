@@ -6,14 +6,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
 type Note struct {
 	FrontMatter FrontMatter
 	Content     string
 	FilePath    string // ファイルパス
+
+	// 読み込んだときのファイルの形。保存時に順序やコメント、改行を保つために使う
+	source *noteSource
 }
 
 type NewNote struct {
@@ -71,6 +72,9 @@ func CreateNewNoteWithFrontMatter(newNote NewNoteWithFrontMatter) (*Note, error)
 }
 
 func (note Note) ToString() (string, error) {
+	if note.source != nil {
+		return note.source.render(note)
+	}
 	if note.FrontMatter == nil {
 		return note.Content, nil
 	}
@@ -81,32 +85,87 @@ func (note Note) ToString() (string, error) {
 	return fmStr + "\n" + note.Content, nil
 }
 
-func ParseNote(raw string) (*Note, error) {
-	// frontmatterがあるか判定
-	if !strings.HasPrefix(raw, "---\n") {
-		// なければbodyと空のフロントマターを返す
-		return &Note{
-			FrontMatter: FrontMatter{},
-			Content:     strings.TrimSpace(raw),
-		}, nil
+// noteSource records how a parsed note was laid out in its file, so that
+// saving it writes back the same bytes for everything that did not change.
+type noteSource struct {
+	bom         bool
+	crlf        bool
+	frontMatter *frontMatterDocument // frontmatterがなければnil
+	bodyPrefix  string               // 本文の前の空白・空行
+	bodySuffix  string               // 本文の後の空白・改行
+}
+
+func (source *noteSource) render(note Note) (string, error) {
+	var b strings.Builder
+	if source.bom {
+		b.WriteString(utf8BOM)
 	}
+	switch {
+	case source.frontMatter != nil:
+		fmStr, err := source.frontMatter.encode(note.FrontMatter)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(fmStr)
+	case source.frontMatter == nil && len(note.FrontMatter) > 0:
+		// frontmatterのなかったノートに追加する
+		fmStr, err := note.FrontMatter.ToYAML()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(fmStr + "\n")
+	}
+	b.WriteString(source.bodyPrefix)
+	b.WriteString(note.Content)
+	b.WriteString(source.bodySuffix)
 
-	// --- で分割
-	parts := strings.SplitN(raw, "---", 3)
-	if len(parts) < 3 {
-		// フロントマターのフォーマットが不正
-		return nil, errors.New("invalid format")
+	if source.crlf {
+		return strings.ReplaceAll(b.String(), "\n", "\r\n"), nil
 	}
+	return b.String(), nil
+}
 
-	var fm FrontMatter
-	err := yaml.Unmarshal([]byte(parts[1]), &fm)
+// ParseNote parses a note file. The frontmatter is the YAML between a "---"
+// line at the top of the file and the next "---" or "..." line. A UTF-8
+// BOM and CRLF line endings are accepted and kept when the note is saved.
+// Errors in the frontmatter are reported as *FrontMatterError.
+func ParseNote(raw string) (*Note, error) {
+	source := &noteSource{}
+	text := raw
+	if strings.HasPrefix(text, utf8BOM) {
+		source.bom = true
+		text = text[len(utf8BOM):]
+	}
+	if usesCRLF(text) {
+		source.crlf = true
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+	}
+
+	block, err := scanFrontMatter(text)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
 	}
+	// frontmatterがなければ空のフロントマターを返す
+	fm := FrontMatter{}
+	if block.found {
+		fm, source.frontMatter, err = parseFrontMatterDocument(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+		}
+	}
+
+	content := strings.TrimSpace(block.body)
+	prefixLen := strings.Index(block.body, content)
+	if content == "" {
+		prefixLen = 0
+	}
+	source.bodyPrefix = block.body[:prefixLen]
+	source.bodySuffix = block.body[prefixLen+len(content):]
 
 	return &Note{
 		FrontMatter: fm,
-		Content:     strings.TrimSpace(parts[2]),
+		Content:     content,
+		source:      source,
 	}, nil
 }
 
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// inlineTagPattern matches "#tag" and "#parent/child" preceded by the start
+// of the line or a space, so that "issue#1" and URL fragments are not tags.
+var inlineTagPattern = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_/-]+)`)
+
+// ParseInlineTags returns the #tags in content in order of appearance,
+// without the "#". Tags in code and purely numeric ones such as "#1" are
+// ignored, as are headings ("# title").
+func ParseInlineTags(content string) []string {
+	var tags []string
+	forEachTagLine(strings.Split(content, "\n"), 0, func(i int, line, masked string) {
+		for _, m := range inlineTagPattern.FindAllStringSubmatchIndex(masked, -1) {
+			if tag := strings.TrimRight(line[m[4]:m[5]], "/"); isTag(tag) {
+				tags = append(tags, tag)
+			}
+		}
+	})
+	return tags
+}
+
+// AllTags returns the frontmatter tags followed by the inline tags of the
+// body. Duplicates are removed ignoring case; the first spelling is kept.
+func (note Note) AllTags() []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, tag := range append(note.FrontMatter.Tags(), ParseInlineTags(note.Content)...) {
+		key := strings.ToLower(tag)
+		if !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// TagMatches reports whether tag is query or one of its children, so that
+// "project" matches "project/krapp". Case is ignored.
+func TagMatches(tag, query string) bool {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	query = strings.ToLower(strings.Trim(strings.TrimPrefix(query, "#"), "/"))
+	return tag == query || strings.HasPrefix(tag, query+"/")
+}
+
+// RenameTag returns raw with the tag from, and its children, renamed to to
+// in both the frontmatter tags and the body. "#from/child" becomes
+// "#to/child". Frontmatter tags that become duplicates are removed. It
+// also returns the number of tags rewritten.
+func RenameTag(raw, from, to string) (string, int) {
+	from = strings.Trim(strings.TrimPrefix(from, "#"), "/")
+	to = strings.Trim(strings.TrimPrefix(to, "#"), "/")
+	rename := func(tag string) (string, bool) {
+		if !TagMatches(tag, from) {
+			return tag, false
+		}
+		return to + tag[len(from):], true
+	}
+
+	lines := strings.Split(raw, "\n")
+	start := frontMatterLineCount(lines)
+	count := 0
+	if start > 0 {
+		var fmLines []string
+		fmLines, count = renameFrontMatterTags(lines[1:start-1], rename)
+		lines = append(append(append([]string{lines[0]}, fmLines...), lines[start-1:start]...), lines[start:]...)
+		start = len(fmLines) + 2
+	}
+
+	forEachTagLine(lines, start, func(i int, line, masked string) {
+		matches := inlineTagPattern.FindAllStringSubmatchIndex(masked, -1)
+		// 後ろから置き換えて位置を保つ
+		for j := len(matches) - 1; j >= 0; j-- {
+			m := matches[j]
+			tag := line[m[4]:m[5]]
+			if !isTag(tag) {
+				continue
+			}
+			if renamed, ok := rename(tag); ok {
+				line = line[:m[4]] + renamed + line[m[5]:]
+				count++
+			}
+		}
+		lines[i] = line
+	})
+	return strings.Join(lines, "\n"), count
+}
+
+// forEachTagLine calls fn for every line from start that is outside code
+// blocks, with inline code masked by spaces.
+func forEachTagLine(lines []string, start int, fn func(i int, line, masked string)) {
+	inCode := false
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		masked := inlineCodePattern.ReplaceAllStringFunc(line, func(code string) string {
+			return strings.Repeat(" ", len(code))
+		})
+		fn(i, line, masked)
+	}
+}
+
+// isTag reports whether s is a valid tag: it needs at least one character
+// that is not a digit.
+func isTag(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) && r != '/' && r != '-' && r != '_' {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	frontMatterKeyPattern = regexp.MustCompile(`^([A-Za-z0-9_-]+)\s*:(.*)$`)
+	blockListItemPattern  = regexp.MustCompile(`^(\s*-\s+)(.*?)(\s*)$`)
+)
+
+// renameFrontMatterTags rewrites the "tags" value in the frontmatter lines
+// as text, so that the rest of the frontmatter keeps its formatting. Flow
+// lists ("[a, b]"), block lists and comma or space separated strings are
+// supported.
+func renameFrontMatterTags(lines []string, rename func(string) (string, bool)) ([]string, int) {
+	result := make([]string, 0, len(lines))
+	count := 0
+	for i := 0; i < len(lines); i++ {
+		m := frontMatterKeyPattern.FindStringSubmatch(lines[i])
+		if m == nil || m[1] != "tags" {
+			result = append(result, lines[i])
+			continue
+		}
+		value := strings.TrimSpace(m[2])
+		prefix := lines[i][:len(lines[i])-len(m[2])] + " "
+		// CRLFの \r などの行末の空白はそのまま残す
+		suffix := m[2][len(strings.TrimRightFunc(m[2], unicode.IsSpace)):]
+
+		if value != "" {
+			renamed, n := renameTagList(value, rename)
+			if n == 0 {
+				result = append(result, lines[i])
+				continue
+			}
+			count += n
+			result = append(result, prefix+renamed+suffix)
+			continue
+		}
+
+		// ブロック形式のリスト
+		result = append(result, lines[i])
+		seen := map[string]bool{}
+		for i+1 < len(lines) {
+			item := blockListItemPattern.FindStringSubmatch(lines[i+1])
+			if item == nil {
+				break
+			}
+			i++
+			tag, quote := unquoteTag(item[2])
+			renamed, ok := rename(tag)
+			if ok {
+				count++
+			}
+			if seen[strings.ToLower(renamed)] {
+				continue
+			}
+			seen[strings.ToLower(renamed)] = true
+			result = append(result, item[1]+quote+renamed+quote+item[3])
+		}
+	}
+	return result, count
+}
+
+// renameTagList renames the tags in a flow list or a separated string.
+func renameTagList(value string, rename func(string) (string, bool)) (string, int) {
+	open, close, sep := "", "", ", "
+	body := value
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		open, close = "[", "]"
+		body = value[1 : len(value)-1]
+	} else if !strings.Contains(value, ",") {
+		sep = " "
+	}
+	quote := ""
+	if open == "" {
+		// 文字列全体が引用符で囲まれている場合
+		body, quote = unquoteTag(body)
+	}
+
+	items := strings.FieldsFunc(body, func(r rune) bool {
+		return r == ',' || (open == "" && sep == " " && unicode.IsSpace(r))
+	})
+	seen := map[string]bool{}
+	renamedItems := []string{}
+	count := 0
+	for _, item := range items {
+		tag, itemQuote := unquoteTag(strings.TrimSpace(item))
+		if tag == "" {
+			continue
+		}
+		renamed, ok := rename(strings.TrimPrefix(tag, "#"))
+		if ok {
+			count++
+			if strings.HasPrefix(tag, "#") {
+				renamed = "#" + renamed
+			}
+		} else {
+			renamed = tag
+		}
+		if seen[strings.ToLower(renamed)] {
+			continue
+		}
+		seen[strings.ToLower(renamed)] = true
+		renamedItems = append(renamedItems, itemQuote+renamed+itemQuote)
+	}
+	if count == 0 {
+		return value, 0
+	}
+	return open + quote + strings.Join(renamedItems, sep) + quote + close, count
+}
+
+func unquoteTag(s string) (string, string) {
+	for _, quote := range []string{`"`, `'`} {
+		if len(s) >= 2 && strings.HasPrefix(s, quote) && strings.HasSuffix(s, quote) {
+			return s[1 : len(s)-1], quote
+		}
+	}
+	return s, ""
+}
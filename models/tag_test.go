@@ -0,0 +1,121 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInlineTags(t *testing.T) {
+	content := "# 見出し\n#project/krapp の作業 #idea\nissue#1 と #123 と [link](note.md#heading)\n`#code` は除く\n```\n#fenced\n```\n末尾の #work/"
+	got := ParseInlineTags(content)
+	want := []string{"project/krapp", "idea", "work"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestNote_AllTags(t *testing.T) {
+	note := Note{
+		FrontMatter: FrontMatter{"tags": "daily, Idea"},
+		Content:     "#idea と #project/krapp",
+	}
+	got := note.AllTags()
+	want := []string{"daily", "Idea", "project/krapp"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTagMatches(t *testing.T) {
+	tests := []struct {
+		tag, query string
+		want       bool
+	}{
+		{"project", "project", true},
+		{"project/krapp", "#project", true},
+		{"Project/Krapp", "project/krapp", true},
+		{"projects", "project", false},
+		{"project", "project/krapp", false},
+	}
+	for _, tt := range tests {
+		if got := TagMatches(tt.tag, tt.query); got != tt.want {
+			t.Errorf("TagMatches(%q, %q) = %v, want %v", tt.tag, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		from  string
+		to    string
+		want  string
+		count int
+	}{
+		{
+			name:  "flow list and body",
+			raw:   "---\ntitle: t # comment\ntags: [work, project/krapp]\n---\n#project の作業 #project/krapp/cli #projects\n`#project`\n",
+			from:  "project",
+			to:    "area/dev",
+			want:  "---\ntitle: t # comment\ntags: [work, area/dev/krapp]\n---\n#area/dev の作業 #area/dev/krapp/cli #projects\n`#project`\n",
+			count: 3,
+		},
+		{
+			name:  "block list merge removes duplicates",
+			raw:   "---\ntags:\n  - idea\n  - \"memo\"\ncreated: 2025-06-01\n---\n#memo\n",
+			from:  "idea",
+			to:    "memo",
+			want:  "---\ntags:\n  - memo\ncreated: 2025-06-01\n---\n#memo\n",
+			count: 1,
+		},
+		{
+			name:  "string value",
+			raw:   "---\ntags: daily, idea\n---\n",
+			from:  "#idea",
+			to:    "memo",
+			want:  "---\ntags: daily, memo\n---\n",
+			count: 1,
+		},
+		{
+			name:  "CRLF",
+			raw:   "---\r\ntags: [idea, work]\r\n---\r\n#idea\r\n",
+			from:  "idea",
+			to:    "memo",
+			want:  "---\r\ntags: [memo, work]\r\n---\r\n#memo\r\n",
+			count: 2,
+		},
+		{
+			name:  "CRLF block list",
+			raw:   "---\r\ntags:\r\n  - idea\r\n---\r\n",
+			from:  "idea",
+			to:    "memo",
+			want:  "---\r\ntags:\r\n  - memo\r\n---\r\n",
+			count: 1,
+		},
+		{
+			name:  "no frontmatter",
+			raw:   "tags: idea\n#idea",
+			from:  "idea",
+			to:    "memo",
+			want:  "tags: idea\n#memo",
+			count: 1,
+		},
+		{
+			name:  "unchanged",
+			raw:   "---\ntags:  [a]\n---\n#b\n",
+			from:  "c",
+			to:    "d",
+			want:  "---\ntags:  [a]\n---\n#b\n",
+			count: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, count := RenameTag(tt.raw, tt.from, tt.to)
+			if got != tt.want || count != tt.count {
+				t.Errorf("got %q (%d), want %q (%d)", got, count, tt.want, tt.count)
+			}
+		})
+	}
+}
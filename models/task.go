@@ -0,0 +1,191 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TaskStatus is the character inside the checkbox of a task.
+type TaskStatus string
+
+const (
+	TaskOpen      TaskStatus = " "
+	TaskDone      TaskStatus = "x"
+	TaskCancelled TaskStatus = "-"
+	TaskForwarded TaskStatus = ">" // 別のノートへ持ち越したタスク
+)
+
+// TaskPriority orders tasks from lowest to highest. The zero value means
+// no priority was given.
+type TaskPriority int
+
+const (
+	PriorityNone TaskPriority = iota
+	PriorityLowest
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityHighest
+)
+
+func (p TaskPriority) String() string {
+	switch p {
+	case PriorityLowest:
+		return "lowest"
+	case PriorityLow:
+		return "low"
+	case PriorityMedium:
+		return "medium"
+	case PriorityHigh:
+		return "high"
+	case PriorityHighest:
+		return "highest"
+	default:
+		return ""
+	}
+}
+
+// Task is a checkbox list item such as "- [ ] 資料を作る 📅 2025-06-10 #work".
+type Task struct {
+	Line        int    // 1始まりの行番号
+	Indent      string // 行頭の空白
+	Status      TaskStatus
+	Text        string // チェックボックス以降の本文
+	Description string // 期日・優先度を取り除いた本文
+	Due         time.Time
+	Priority    TaskPriority
+	Tags        []string
+}
+
+var (
+	taskLinePattern = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)]) \[(.)\](?:\s+(.*))?$`)
+	taskDuePattern  = regexp.MustCompile(`(?:📅\s*|\bdue:)(\d{4}-\d{2}-\d{2})`)
+	taskTagPattern  = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_/-]+)`)
+	// 優先度の表記（Obsidian Tasks形式の絵文字とpriority:キーワード）
+	taskPriorityMarks = []struct {
+		mark     string
+		priority TaskPriority
+	}{
+		{"🔺", PriorityHighest},
+		{"⏫", PriorityHigh},
+		{"🔼", PriorityMedium},
+		{"🔽", PriorityLow},
+		{"⏬", PriorityLowest},
+		{"priority:highest", PriorityHighest},
+		{"priority:high", PriorityHigh},
+		{"priority:medium", PriorityMedium},
+		{"priority:lowest", PriorityLowest},
+		{"priority:low", PriorityLow},
+	}
+)
+
+// IsOpen reports whether the task still needs to be done.
+func (task Task) IsOpen() bool {
+	return task.Status == TaskOpen
+}
+
+// HasDue reports whether the task has a due date.
+func (task Task) HasDue() bool {
+	return !task.Due.IsZero()
+}
+
+// ParseTaskLine parses a single line. It returns false when the line is
+// not a task.
+func ParseTaskLine(line string) (Task, bool) {
+	match := taskLinePattern.FindStringSubmatchIndex(line)
+	if match == nil {
+		return Task{}, false
+	}
+	task := Task{
+		Indent: line[match[2]:match[3]],
+		Status: TaskStatus(strings.ToLower(line[match[4]:match[5]])),
+	}
+	if match[6] >= 0 {
+		task.Text = line[match[6]:match[7]]
+	}
+
+	description := task.Text
+	if due := taskDuePattern.FindStringSubmatch(description); due != nil {
+		if t, err := time.ParseInLocation("2006-01-02", due[1], time.Local); err == nil {
+			task.Due = t
+			description = strings.Replace(description, due[0], "", 1)
+		}
+	}
+	for _, mark := range taskPriorityMarks {
+		if strings.Contains(description, mark.mark) {
+			if task.Priority == PriorityNone {
+				task.Priority = mark.priority
+			}
+			description = strings.Replace(description, mark.mark, "", 1)
+		}
+	}
+	for _, tag := range taskTagPattern.FindAllStringSubmatch(task.Text, -1) {
+		task.Tags = append(task.Tags, tag[1])
+	}
+	task.Description = strings.Join(strings.Fields(description), " ")
+	return task, true
+}
+
+// ParseTasks returns the tasks in content. Lines inside fenced code blocks
+// are ignored. Line numbers are relative to content.
+func ParseTasks(content string) []Task {
+	return parseTaskLines(strings.Split(content, "\n"), 0)
+}
+
+// Tasks returns the tasks in the body of the note.
+func (note Note) Tasks() []Task {
+	return ParseTasks(note.Content)
+}
+
+// ParseFileTasks returns the tasks of a raw note file, skipping its
+// frontmatter. Line numbers are those of the file.
+func ParseFileTasks(raw string) []Task {
+	lines := strings.Split(raw, "\n")
+	return parseTaskLines(lines, frontMatterLineCount(lines))
+}
+
+func parseTaskLines(lines []string, start int) []Task {
+	var tasks []Task
+	inCode := false
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+		if task, ok := ParseTaskLine(line); ok {
+			task.Line = i + 1
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// frontMatterLineCount returns the number of lines taken by the
+// frontmatter at the top of lines, including both delimiters. It follows
+// the same rules as ParseNote.
+func frontMatterLineCount(lines []string) int {
+	if len(lines) == 0 || !isFrontMatterDelimiter(strings.TrimPrefix(lines[0], utf8BOM), "---") {
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		if isFrontMatterDelimiter(lines[i], "---") || isFrontMatterDelimiter(lines[i], "...") {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// SetTaskStatus returns line with the checkbox replaced by status. It
+// returns false when line is not a task.
+func SetTaskStatus(line string, status TaskStatus) (string, bool) {
+	match := taskLinePattern.FindStringSubmatchIndex(line)
+	if match == nil {
+		return line, false
+	}
+	return line[:match[4]] + string(status) + line[match[5]:], true
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTaskLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		ok          bool
+		status      TaskStatus
+		description string
+		due         string
+		priority    TaskPriority
+		tags        []string
+	}{
+		{"open", "- [ ] 資料を作る", true, TaskOpen, "資料を作る", "", PriorityNone, nil},
+		{"done upper", "* [X] done", true, TaskDone, "done", "", PriorityNone, nil},
+		{"emoji due", "- [ ] 提出 📅 2025-06-10 #work", true, TaskOpen, "提出 #work", "2025-06-10", PriorityNone, []string{"work"}},
+		{"due keyword", "  1. [ ] pay due:2025-07-01 ⏫", true, TaskOpen, "pay", "2025-07-01", PriorityHigh, nil},
+		{"priority keyword", "- [>] later priority:low #a/b", true, TaskForwarded, "later #a/b", "", PriorityLow, []string{"a/b"}},
+		{"empty text", "- [ ]", true, TaskOpen, "", "", PriorityNone, nil},
+		{"not a task", "- item", false, "", "", "", PriorityNone, nil},
+		{"no space", "-[ ] item", false, "", "", "", PriorityNone, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, ok := ParseTaskLine(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("ParseTaskLine(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if task.Status != tt.status {
+				t.Errorf("status = %q, want %q", task.Status, tt.status)
+			}
+			if task.Description != tt.description {
+				t.Errorf("description = %q, want %q", task.Description, tt.description)
+			}
+			due := ""
+			if task.HasDue() {
+				due = task.Due.Format("2006-01-02")
+			}
+			if due != tt.due {
+				t.Errorf("due = %q, want %q", due, tt.due)
+			}
+			if task.Priority != tt.priority {
+				t.Errorf("priority = %v, want %v", task.Priority, tt.priority)
+			}
+			if !reflect.DeepEqual(task.Tags, tt.tags) {
+				t.Errorf("tags = %v, want %v", task.Tags, tt.tags)
+			}
+		})
+	}
+}
+
+func TestParseFileTasks(t *testing.T) {
+	raw := "---\ntags:\n- [ ] not a task\n---\n# Title\n- [ ] first\n```\n- [ ] code\n```\n- [x] second\n"
+	tasks := ParseFileTasks(raw)
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d: %+v", len(tasks), tasks)
+	}
+	if tasks[0].Line != 6 || tasks[1].Line != 10 {
+		t.Errorf("unexpected line numbers: %d, %d", tasks[0].Line, tasks[1].Line)
+	}
+
+	note := Note{Content: "- [ ] a\n- [x] b"}
+	if got := note.Tasks(); len(got) != 2 || got[0].Line != 1 {
+		t.Errorf("unexpected tasks from content: %+v", got)
+	}
+}
+
+func TestSetTaskStatus(t *testing.T) {
+	line, ok := SetTaskStatus("  - [ ] [link](x) 📅 2025-06-10", TaskDone)
+	if !ok || line != "  - [x] [link](x) 📅 2025-06-10" {
+		t.Errorf("unexpected line: %q (ok: %v)", line, ok)
+	}
+	if _, ok := SetTaskStatus("plain", TaskDone); ok {
+		t.Error("expected false for a non-task line")
+	}
+}
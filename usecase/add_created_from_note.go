@@ -2,17 +2,133 @@ package usecase
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/ishida722/krapp-go/models"
 )
 
 var datePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
 
+// CreatedSource is where InferCreated found the date a note was created.
+type CreatedSource string
+
+// Sources of the created date.
+const (
+	CreatedFromFilename CreatedSource = "filename" // ファイル名の YYYY-MM-DD
+	CreatedFromBody     CreatedSource = "body"     // 本文の最初の YYYY-MM-DD
+	CreatedFromGit      CreatedSource = "git"      // ファイルを追加したコミットの日付
+	CreatedFromMtime    CreatedSource = "mtime"    // ファイルの更新日時
+)
+
+// DefaultCreatedSources is the order the sources are tried in by default.
+var DefaultCreatedSources = []CreatedSource{CreatedFromFilename, CreatedFromBody, CreatedFromGit, CreatedFromMtime}
+
+// ParseCreatedSources converts source names such as "filename" or "git".
+func ParseCreatedSources(names []string) ([]CreatedSource, error) {
+	sources := make([]CreatedSource, 0, len(names))
+	for _, name := range names {
+		source := CreatedSource(strings.ToLower(strings.TrimSpace(name)))
+		switch source {
+		case CreatedFromFilename, CreatedFromBody, CreatedFromGit, CreatedFromMtime:
+			sources = append(sources, source)
+		default:
+			return nil, fmt.Errorf("unknown created source %q (filename, body, git, mtime)", name)
+		}
+	}
+	return sources, nil
+}
+
+// InferCreated returns the date the note was created, taken from the first
+// of sources that has one.
+func InferCreated(n *models.Note, sources []CreatedSource) (time.Time, CreatedSource, error) {
+	for _, source := range sources {
+		var (
+			t  time.Time
+			ok bool
+		)
+		switch source {
+		case CreatedFromFilename:
+			if n.FilePath != "" {
+				t, ok = findDate(filepath.Base(n.FilePath))
+			}
+		case CreatedFromBody:
+			t, ok = findDate(n.Content)
+		case CreatedFromGit:
+			if n.FilePath != "" {
+				t, ok = gitAddedDate(n.FilePath)
+			}
+		case CreatedFromMtime:
+			if n.FilePath != "" {
+				if info, err := os.Stat(n.FilePath); err == nil {
+					t, ok = info.ModTime(), true
+				}
+			}
+		}
+		if ok {
+			return t, source, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("date string not found")
+}
+
+// findDate returns the first valid YYYY-MM-DD date in s.
+func findDate(s string) (time.Time, bool) {
+	for _, m := range datePattern.FindAllString(s, -1) {
+		if t, err := time.ParseInLocation("2006-01-02", m, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// gitAddedDate returns the author date of the commit that added path, or
+// false when path is not in a git repository or not committed yet.
+func gitAddedDate(path string) (time.Time, bool) {
+	cmd := exec.Command("git", "log", "--follow", "--diff-filter=A", "--format=%aI", "--", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	out, err := cmd.Output()
+	if err != nil {
+		return time.Time{}, false
+	}
+	// 新しい順に出るので最後の行が最初に追加されたコミット
+	lines := strings.Fields(string(out))
+	if len(lines) == 0 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, lines[len(lines)-1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.Local(), true
+}
+
+// hasCreated reports whether fm has a created key, in any casing.
+func hasCreated(fm models.FrontMatter) bool {
+	return createdKey(fm) != ""
+}
+
+// createdKey returns the key of fm that holds created, preferring the
+// lower case one, or "".
+func createdKey(fm models.FrontMatter) string {
+	if _, ok := fm["created"]; ok {
+		return "created"
+	}
+	for key := range fm {
+		if strings.EqualFold(key, "created") {
+			return key
+		}
+	}
+	return ""
+}
+
 // AddCreatedFromNote parses the note's file name and content to
 // find the first date string (YYYY-MM-DD) and sets it as the
-// Created field in the frontmatter. If the field already exists,
+// created field in the frontmatter. If the field already exists,
 // the function does nothing. It returns an error when no date
 // string can be found.
 func AddCreatedFromNote(n *models.Note) error {
@@ -21,22 +137,89 @@ func AddCreatedFromNote(n *models.Note) error {
 	}
 	if n.FrontMatter == nil {
 		n.FrontMatter = models.FrontMatter{}
-	} else if _, ok := n.FrontMatter["Created"]; ok {
+	} else if hasCreated(n.FrontMatter) {
 		return nil
 	}
 
-	if n.FilePath != "" {
-		base := filepath.Base(n.FilePath)
-		if m := datePattern.FindString(base); m != "" {
-			n.FrontMatter["Created"] = m
-			return nil
-		}
+	t, _, err := InferCreated(n, []CreatedSource{CreatedFromFilename, CreatedFromBody})
+	if err != nil {
+		return err
 	}
+	return n.FrontMatter.SetCreated(t)
+}
 
-	if m := datePattern.FindString(n.Content); m != "" {
-		n.FrontMatter["Created"] = m
-		return nil
-	}
+// CreatedBackfill is the created date found for a note.
+type CreatedBackfill struct {
+	Path    string        `json:"path"`
+	Created string        `json:"created,omitempty"`
+	Source  CreatedSource `json:"source,omitempty"`  // 空なら日付が見つからなかった
+	Renamed string        `json:"renamed,omitempty"` // created に名前を直したキー（Created など）
+	Error   string        `json:"error,omitempty"`   // ノートを読めなかった
+}
 
-	return fmt.Errorf("date string not found")
+// PlanCreatedBackfill finds the created date of the notes at paths that do
+// not have one, trying sources in order. A created key in another casing,
+// such as "Created", is renamed to created instead. It returns what was
+// found for each note and the edits that write the dates. Notes whose
+// frontmatter cannot be parsed are reported with Error and left as they
+// are.
+func PlanCreatedBackfill(paths []string, sources []CreatedSource) ([]CreatedBackfill, []NoteEdit, error) {
+	results := []CreatedBackfill{}
+	edits := []NoteEdit{}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		note, err := models.ParseNote(string(raw))
+		if err != nil {
+			results = append(results, CreatedBackfill{Path: path, Error: err.Error()})
+			continue
+		}
+		note.FilePath = path
+		if note.FrontMatter == nil {
+			note.FrontMatter = models.FrontMatter{}
+		}
+
+		key := createdKey(note.FrontMatter)
+		if key == "created" {
+			continue
+		}
+		result := CreatedBackfill{Path: path}
+		if key != "" {
+			// 日付はあるのでキー名だけを直す
+			note.RenameFrontMatterKey(key, "created")
+			after, err := note.ToString()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to update %s: %w", path, err)
+			}
+			result.Renamed = key
+			if t, err := note.FrontMatter.Created(); err == nil {
+				result.Created = t.Format("2006-01-02")
+			} else {
+				result.Created = fmt.Sprint(note.FrontMatter["created"])
+			}
+			results = append(results, result)
+			edits = append(edits, NoteEdit{Path: path, Before: string(raw), After: after})
+			continue
+		}
+
+		t, source, err := InferCreated(note, sources)
+		if err != nil {
+			results = append(results, result)
+			continue
+		}
+		if err := note.FrontMatter.SetCreated(t); err != nil {
+			return nil, nil, fmt.Errorf("failed to set created of %s: %w", path, err)
+		}
+		after, err := note.ToString()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to update %s: %w", path, err)
+		}
+		result.Created = t.Format("2006-01-02")
+		result.Source = source
+		results = append(results, result)
+		edits = append(edits, NoteEdit{Path: path, Before: string(raw), After: after})
+	}
+	return results, edits, nil
 }
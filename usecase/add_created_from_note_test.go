@@ -1,7 +1,11 @@
 package usecase
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ishida722/krapp-go/models"
 )
@@ -15,8 +19,8 @@ func TestAddCreatedFromNote_FileName(t *testing.T) {
 	if err := AddCreatedFromNote(note); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if note.FrontMatter["Created"] != "2025-06-10" {
-		t.Errorf("expected Created 2025-06-10, got %v", note.FrontMatter["Created"])
+	if note.FrontMatter["created"] != "2025-06-10" {
+		t.Errorf("expected created 2025-06-10, got %v", note.FrontMatter["created"])
 	}
 }
 
@@ -29,8 +33,8 @@ func TestAddCreatedFromNote_Content(t *testing.T) {
 	if err := AddCreatedFromNote(note); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if note.FrontMatter["Created"] != "2025-06-11" {
-		t.Errorf("expected Created 2025-06-11, got %v", note.FrontMatter["Created"])
+	if note.FrontMatter["created"] != "2025-06-11" {
+		t.Errorf("expected created 2025-06-11, got %v", note.FrontMatter["created"])
 	}
 }
 
@@ -40,3 +44,112 @@ func TestAddCreatedFromNote_NoDate(t *testing.T) {
 		t.Error("expected error, got nil")
 	}
 }
+
+func TestAddCreatedFromNote_Existing(t *testing.T) {
+	note := &models.Note{
+		FilePath:    "/notes/2025-06-10-title.md",
+		FrontMatter: models.FrontMatter{"Created": "2024-01-01"},
+	}
+	if err := AddCreatedFromNote(note); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := note.FrontMatter["created"]; ok {
+		t.Error("created should not be added when Created exists")
+	}
+}
+
+func TestInferCreated_Order(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "2025-06-10-title.md")
+	writeTestNote(t, path, "body")
+	mtime := time.Date(2023, 3, 4, 12, 0, 0, 0, time.Local)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	note := &models.Note{Content: "written 2025-13-01 and 2025-06-11", FilePath: path}
+
+	tests := []struct {
+		sources    []CreatedSource
+		wantDate   string
+		wantSource CreatedSource
+	}{
+		{DefaultCreatedSources, "2025-06-10", CreatedFromFilename},
+		// 2025-13-01 は日付ではないので次の候補を使う
+		{[]CreatedSource{CreatedFromBody, CreatedFromFilename}, "2025-06-11", CreatedFromBody},
+		{[]CreatedSource{CreatedFromGit, CreatedFromMtime}, "2023-03-04", CreatedFromMtime},
+	}
+	for _, tt := range tests {
+		got, source, err := InferCreated(note, tt.sources)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.sources, err)
+		}
+		if got.Format("2006-01-02") != tt.wantDate || source != tt.wantSource {
+			t.Errorf("%v: got %s from %s, want %s from %s", tt.sources, got.Format("2006-01-02"), source, tt.wantDate, tt.wantSource)
+		}
+	}
+}
+
+func TestParseCreatedSources(t *testing.T) {
+	sources, err := ParseCreatedSources([]string{"git", " Mtime"})
+	if err != nil || !reflect.DeepEqual(sources, []CreatedSource{CreatedFromGit, CreatedFromMtime}) {
+		t.Errorf("got %v, %v", sources, err)
+	}
+	if _, err := ParseCreatedSources([]string{"ctime"}); err == nil {
+		t.Error("expected an error for an unknown source")
+	}
+}
+
+func TestPlanCreatedBackfill(t *testing.T) {
+	dir := t.TempDir()
+	dated := filepath.Join(dir, "2025-06-10.md")
+	has := filepath.Join(dir, "has.md")
+	none := filepath.Join(dir, "none.md")
+	writeTestNote(t, dated, "---\n# メモ\ntags: [a]\n---\nbody\n")
+	writeTestNote(t, has, "---\ncreated: 2024-01-01\n---\nbody\n")
+	writeTestNote(t, none, "body\n")
+
+	results, edits, err := PlanCreatedBackfill([]string{dated, has, none}, []CreatedSource{CreatedFromFilename, CreatedFromBody})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CreatedBackfill{
+		{Path: dated, Created: "2025-06-10", Source: CreatedFromFilename},
+		{Path: none},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("got %+v, want %+v", results, want)
+	}
+	if len(edits) != 1 || edits[0].After != "---\n# メモ\ntags: [a]\ncreated: \"2025-06-10\"\n---\nbody\n" {
+		t.Errorf("unexpected edits: %+v", edits)
+	}
+}
+
+func TestPlanCreatedBackfill_LegacyKeyAndBrokenNote(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "legacy.md")
+	broken := filepath.Join(dir, "broken.md")
+	dated := filepath.Join(dir, "2025-06-10.md")
+	writeTestNote(t, legacy, "---\nCreated: 2024-01-01\ntags: [a]\n---\nbody\n")
+	writeTestNote(t, broken, "---\ncreated: [\n---\nbody\n")
+	writeTestNote(t, dated, "body\n")
+
+	results, edits, err := PlanCreatedBackfill([]string{legacy, broken, dated}, []CreatedSource{CreatedFromFilename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %+v", results)
+	}
+	if results[0] != (CreatedBackfill{Path: legacy, Created: "2024-01-01", Renamed: "Created"}) {
+		t.Errorf("legacy key should be renamed: %+v", results[0])
+	}
+	if results[1].Path != broken || results[1].Error == "" {
+		t.Errorf("broken note should be reported: %+v", results[1])
+	}
+	if results[2].Source != CreatedFromFilename {
+		t.Errorf("notes after the broken one should be processed: %+v", results[2])
+	}
+	if len(edits) != 2 || edits[0].After != "---\ncreated: 2024-01-01\ntags: [a]\n---\nbody\n" {
+		t.Errorf("unexpected edits: %+v", edits)
+	}
+}
@@ -1,12 +1,7 @@
 package usecase
 
 import (
-	"fmt"
-	"os"
-	"path/filepath"
 	"time"
-
-	"github.com/ishida722/krapp-go/models"
 )
 
 type Config interface {
@@ -17,42 +12,28 @@ type Config interface {
 
 // CreateDailyNote creates today's daily note and returns its path.
 func CreateDailyNote(cfg Config, now time.Time) (string, error) {
-	year := now.Format("2006")
-	month := now.Format("01")
-	date := now.Format("2006-01-02")
-	dir := filepath.Join(cfg.GetBaseDir(), cfg.GetDailyNoteDir(), year, month)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("ディレクトリ作成に失敗: %w", err)
-	}
-
-	filePath := filepath.Join(dir, date+".md")
-	
-	// 既存のファイルがある場合は、そのパスを返す
-	if _, err := os.Stat(filePath); err == nil {
-		return filePath, nil
-	}
-
-	// テンプレートから初期frontmatterを作成
-	fm := models.FrontMatter{}
+	return CreateDailyNoteWithTemplate(cfg, now, "", nil)
+}
 
-	// 作成日時を設定
-	fm.SetCreated(now)
+// CreateDailyNoteWithTemplate creates today's daily note using the named
+// body template. An empty name uses the "daily" template when it exists.
+// The creation is recorded in tx, which may be nil.
+func CreateDailyNoteWithTemplate(cfg Config, now time.Time, templateName string, tx *Transaction) (string, error) {
+	return createPeriodicNote(cfg, cfg.GetBaseDir(), PeriodDaily, dailyNoteSettings(cfg), now, templateName, tx)
+}
 
-	// テンプレートの属性を追加
-	if cfg.GetDailyTemplate() != nil {
-		for key, value := range cfg.GetDailyTemplate() {
-			fm[key] = value
-		}
-	}
+// DailyNotePath returns the path of the daily note of date.
+func DailyNotePath(cfg Config, date time.Time) (string, error) {
+	return PeriodicNotePath(cfg.GetBaseDir(), PeriodDaily, dailyNoteSettings(cfg), date)
+}
 
-	note, err := models.CreateNewNoteWithFrontMatter(models.NewNoteWithFrontMatter{
-		Content:     "",
-		FilePath:    filePath,
-		WriteFile:   true,
-		FrontMatter: fm,
-	})
-	if err != nil {
-		return "", fmt.Errorf("日記の保存に失敗: %w", err)
+func dailyNoteSettings(cfg Config) PeriodicNoteSettings {
+	settings := DefaultPeriodicNoteSettings(PeriodDaily)
+	if periodicCfg, ok := cfg.(PeriodicConfig); ok {
+		settings = periodicCfg.GetPeriodicNoteSettings(PeriodDaily)
 	}
-	return note.FilePath, nil
+	// ディレクトリとfrontmatterはdaily_note_dir / daily_templateを使う
+	settings.Dir = cfg.GetDailyNoteDir()
+	settings.FrontMatter = cfg.GetDailyTemplate()
+	return settings
 }
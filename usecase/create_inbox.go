@@ -15,36 +15,65 @@ type InboxConfig interface {
 	GetInboxTemplate() map[string]any
 }
 
+// InboxFilenamePatternConfig is implemented by configs that customize the
+// file names of inbox notes.
+type InboxFilenamePatternConfig interface {
+	GetInboxFilenamePattern() string
+}
+
 // CreateInboxNote creates a new inbox note with the given title and returns its path.
 func CreateInboxNote(cfg InboxConfig, now time.Time, title string) (string, error) {
-	date := now.Format("2006-01-02")
-	filename := fmt.Sprintf("%s-%s.md", date, title)
-	dir := filepath.Join(cfg.GetBaseDir(), cfg.GetInboxDir())
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	return CreateInboxNoteWithTemplate(cfg, now, title, "", nil)
+}
+
+// CreateInboxNoteWithTemplate creates a new inbox note using the named body
+// template. An empty name uses the "inbox" template when it exists. The
+// creation is recorded in tx, which may be nil.
+func CreateInboxNoteWithTemplate(cfg InboxConfig, now time.Time, title, templateName string, tx *Transaction) (string, error) {
+	note, err := newInboxNote(cfg, now, title, templateName)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(note.FilePath), 0755); err != nil {
 		return "", fmt.Errorf("inboxディレクトリ作成に失敗: %w", err)
 	}
+	if err := note.SaveToFile(); err != nil {
+		return "", fmt.Errorf("日記の保存に失敗: %w", err)
+	}
+	if err := tx.RecordCreate(note.FilePath); err != nil {
+		return note.FilePath, fmt.Errorf("操作の記録に失敗: %w", err)
+	}
+	return note.FilePath, nil
+}
 
-	// テンプレートから初期frontmatterを作成
-	fm := models.FrontMatter{}
-
-	// 作成日時を設定
-	fm.SetCreated(now)
+// newInboxNote returns the inbox note created at now with title, without
+// writing it: its path from the filename pattern and its frontmatter and
+// body from the templates. Importers replace the body and add to the
+// frontmatter.
+func newInboxNote(cfg InboxConfig, now time.Time, title, templateName string) (*models.Note, error) {
+	pattern := DefaultInboxFilenamePattern
+	if patternCfg, ok := cfg.(InboxFilenamePatternConfig); ok && patternCfg.GetInboxFilenamePattern() != "" {
+		pattern = patternCfg.GetInboxFilenamePattern()
+	}
+	filename, err := RenderPathPattern(pattern, NewPathPatternData(now, title))
+	if err != nil {
+		return nil, fmt.Errorf("ファイル名の生成に失敗: %w", err)
+	}
+	filePath := filepath.Join(cfg.GetBaseDir(), cfg.GetInboxDir(), filename)
 
-	// テンプレートの属性を追加
-	if cfg.GetInboxTemplate() != nil {
-		for key, value := range cfg.GetInboxTemplate() {
-			fm[key] = value
-		}
+	// テンプレートから初期frontmatterと本文を作成
+	fm, body, err := buildNoteFromTemplate(cfg, cfg.GetInboxTemplate(), templateName, "inbox", NewNoteTemplateData(now, title))
+	if err != nil {
+		return nil, fmt.Errorf("テンプレートの適用に失敗: %w", err)
 	}
 
 	note, err := models.CreateNewNoteWithFrontMatter(models.NewNoteWithFrontMatter{
-		Content:     "",
-		FilePath:    filepath.Join(dir, filename),
-		WriteFile:   true,
+		Content:     body,
+		FilePath:    filePath,
 		FrontMatter: fm,
 	})
 	if err != nil {
-		return "", fmt.Errorf("日記の保存に失敗: %w", err)
+		return nil, fmt.Errorf("日記の保存に失敗: %w", err)
 	}
-	return note.FilePath, nil
+	return note, nil
 }
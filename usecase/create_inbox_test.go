@@ -83,3 +83,31 @@ func TestCreateInboxNoteWithTemplate(t *testing.T) {
 		t.Errorf("priority field not found in frontmatter")
 	}
 }
+
+type testInboxPatternConfig struct {
+	testInboxConfig
+	pattern string
+}
+
+func (c *testInboxPatternConfig) GetInboxFilenamePattern() string { return c.pattern }
+
+func TestCreateInboxNote_FilenamePattern(t *testing.T) {
+	cfg := &testInboxPatternConfig{
+		testInboxConfig: testInboxConfig{baseDir: t.TempDir(), inboxDir: "inbox"},
+		pattern:         "%Y/%m/{{.Slug}}",
+	}
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	path, err := CreateInboxNote(cfg, now, "Weekly Sync: 議事録")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := filepath.Join(cfg.baseDir, "inbox", "2025", "06", "weekly-sync-議事録.md")
+	if path != expected {
+		t.Errorf("expected path %s, got %s", expected, path)
+	}
+
+	cfg.pattern = "%Q"
+	if _, err := CreateInboxNote(cfg, now, "bad"); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
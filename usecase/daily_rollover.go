@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+const (
+	// DefaultRolloverSection は持ち越したタスクを入れる見出しのデフォルト
+	DefaultRolloverSection = "## 持ち越し"
+	// DefaultRolloverLookbackDays は前のデイリーノートを遡って探す日数のデフォルト
+	DefaultRolloverLookbackDays = 30
+)
+
+// DailyRolloverSettings configures carrying unfinished tasks over to a new
+// daily note.
+type DailyRolloverSettings struct {
+	Enabled      bool
+	Section      string // タスクを追加する見出し
+	MarkMoved    bool   // 元のノートのタスクを [>] にする
+	LookbackDays int    // 前のデイリーノートを探す日数
+}
+
+// DailyRolloverConfig is implemented by configs that support rollover.
+type DailyRolloverConfig interface {
+	GetDailyRollover() DailyRolloverSettings
+}
+
+// RolloverResult describes the tasks carried over by RolloverDailyTasks.
+type RolloverResult struct {
+	Source string        // 前のデイリーノート（見つからなければ空）
+	Target string        // 追加先のデイリーノート
+	Tasks  []models.Task // 追加したタスク
+}
+
+// RolloverDailyTasks copies the open tasks of the most recent daily note
+// before date into the daily note of date, which must already exist.
+// Tasks already present in the target are not added again, so running it
+// repeatedly is safe. Overwritten files are recorded in tx, which may be nil.
+func RolloverDailyTasks(cfg Config, date time.Time, tx *Transaction) (RolloverResult, error) {
+	var settings DailyRolloverSettings
+	if rolloverCfg, ok := cfg.(DailyRolloverConfig); ok {
+		settings = rolloverCfg.GetDailyRollover()
+	}
+	if !settings.Enabled {
+		return RolloverResult{}, nil
+	}
+	if settings.Section == "" {
+		settings.Section = DefaultRolloverSection
+	}
+	if settings.LookbackDays <= 0 {
+		settings.LookbackDays = DefaultRolloverLookbackDays
+	}
+
+	target, err := DailyNotePath(cfg, date)
+	if err != nil {
+		return RolloverResult{}, err
+	}
+	result := RolloverResult{Target: target}
+	source, err := findPreviousDailyNote(cfg, date, settings.LookbackDays)
+	if err != nil || source == "" {
+		return result, err
+	}
+	result.Source = source
+
+	sourceRaw, err := os.ReadFile(source)
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	targetRaw, err := os.ReadFile(target)
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+
+	// 追加先にすでにあるタスクは除く
+	existing := map[string]bool{}
+	for _, task := range models.ParseFileTasks(string(targetRaw)) {
+		existing[task.Text] = true
+	}
+	var carried []models.Task
+	for _, task := range models.ParseFileTasks(string(sourceRaw)) {
+		if task.IsOpen() && task.Text != "" && !existing[task.Text] {
+			carried = append(carried, task)
+			existing[task.Text] = true
+		}
+	}
+	if len(carried) == 0 {
+		return result, nil
+	}
+
+	lines := make([]string, len(carried))
+	for i, task := range carried {
+		lines[i] = task.Indent + "- [ ] " + task.Text
+	}
+	if err := tx.RecordOverwrite(target); err != nil {
+		return result, fmt.Errorf("failed to record journal: %w", err)
+	}
+	updated := insertIntoSection(string(targetRaw), settings.Section, lines)
+	if err := os.WriteFile(target, []byte(updated), 0644); err != nil {
+		return result, fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	result.Tasks = carried
+
+	if settings.MarkMoved {
+		if err := markTasksForwarded(source, string(sourceRaw), carried, tx); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// findPreviousDailyNote searches back day by day from the day before date
+// and returns the first daily note found, or "" when there is none.
+func findPreviousDailyNote(cfg Config, date time.Time, lookbackDays int) (string, error) {
+	for i := 1; i <= lookbackDays; i++ {
+		path, err := DailyNotePath(cfg, date.AddDate(0, 0, -i))
+		if err != nil {
+			return "", err
+		}
+		_, err = os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// insertIntoSection adds lines at the end of the section whose heading is
+// section, creating the section at the end of raw when it does not exist.
+func insertIntoSection(raw, section string, lines []string) string {
+	all := strings.Split(strings.TrimRight(raw, "\n"), "\n")
+	heading := -1
+	for i, line := range all {
+		if strings.TrimSpace(line) == strings.TrimSpace(section) {
+			heading = i
+			break
+		}
+	}
+	if heading < 0 {
+		all = append(all, "", section)
+		all = append(all, lines...)
+		return strings.Join(all, "\n") + "\n"
+	}
+
+	// 同じかより上位の見出しまでがセクション
+	level := headingLevel(all[heading])
+	end := len(all)
+	for i := heading + 1; i < len(all); i++ {
+		if l := headingLevel(all[i]); l > 0 && l <= level {
+			end = i
+			break
+		}
+	}
+	pos := end
+	for pos > heading+1 && strings.TrimSpace(all[pos-1]) == "" {
+		pos--
+	}
+	result := append([]string{}, all[:pos]...)
+	result = append(result, lines...)
+	result = append(result, all[pos:]...)
+	return strings.Join(result, "\n") + "\n"
+}
+
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+// markTasksForwarded marks the given tasks of the file at path as [>].
+func markTasksForwarded(path, raw string, tasks []models.Task, tx *Transaction) error {
+	lines := strings.Split(raw, "\n")
+	for _, task := range tasks {
+		if line, ok := models.SetTaskStatus(lines[task.Line-1], models.TaskForwarded); ok {
+			lines[task.Line-1] = line
+		}
+	}
+	if err := tx.RecordOverwrite(path); err != nil {
+		return fmt.Errorf("failed to record journal: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
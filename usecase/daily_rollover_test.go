@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testRolloverConfig struct {
+	testDailyConfig
+	rollover DailyRolloverSettings
+}
+
+func (c *testRolloverConfig) GetDailyRollover() DailyRolloverSettings { return c.rollover }
+
+func TestRolloverDailyTasks(t *testing.T) {
+	cfg := &testRolloverConfig{
+		testDailyConfig: testDailyConfig{baseDir: t.TempDir(), dailyNoteDir: "daily"},
+		rollover:        DailyRolloverSettings{Enabled: true, MarkMoved: true},
+	}
+	// 2日前のノート（前日のノートはない）
+	previous := filepath.Join(cfg.baseDir, "daily", "2025", "05", "2025-05-31.md")
+	writeTestNote(t, previous, "---\ncreated: 2025-05-31\n---\n- [ ] 資料を作る 📅 2025-06-10\n- [x] 済み\n  - [ ] 子タスク\n- [-] やめた\n")
+
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	path, err := CreateDailyNote(cfg, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	journal := NewJournal(t.TempDir())
+	tx := journal.Begin("create-daily")
+	result, err := RolloverDailyTasks(cfg, now, tx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Source != previous || len(result.Tasks) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(data), "\n## 持ち越し\n- [ ] 資料を作る 📅 2025-06-10\n  - [ ] 子タスク\n") {
+		t.Errorf("unexpected daily note:\n%s", data)
+	}
+	old, _ := os.ReadFile(previous)
+	if string(old) != "---\ncreated: 2025-05-31\n---\n- [>] 資料を作る 📅 2025-06-10\n- [x] 済み\n  - [>] 子タスク\n- [-] やめた\n" {
+		t.Errorf("tasks were not marked as moved:\n%s", old)
+	}
+
+	// 2回目は何も追加しない
+	again, err := RolloverDailyTasks(cfg, now, nil)
+	if err != nil || len(again.Tasks) != 0 {
+		t.Errorf("expected no tasks on second run, got %+v (err: %v)", again, err)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(data) {
+		t.Errorf("daily note changed on second run:\n%s", after)
+	}
+
+	if err := journal.Undo(tx, nil); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	if restored, _ := os.ReadFile(previous); !strings.Contains(string(restored), "- [ ] 資料を作る") {
+		t.Errorf("undo did not restore previous note:\n%s", restored)
+	}
+}
+
+func TestRolloverDailyTasks_WithoutMark(t *testing.T) {
+	cfg := &testRolloverConfig{
+		testDailyConfig: testDailyConfig{baseDir: t.TempDir(), dailyNoteDir: "daily"},
+		rollover:        DailyRolloverSettings{Enabled: true, Section: "## TODO"},
+	}
+	writeTestNote(t, filepath.Join(cfg.baseDir, "daily", "2025", "06", "2025-06-01.md"), "- [ ] a\n- [ ] b\n")
+	path := filepath.Join(cfg.baseDir, "daily", "2025", "06", "2025-06-02.md")
+	writeTestNote(t, path, "# 06-02\n\n## TODO\n- [ ] a\n\n## メモ\ntext\n")
+
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if _, err := RolloverDailyTasks(cfg, now, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "# 06-02\n\n## TODO\n- [ ] a\n- [ ] b\n\n## メモ\ntext\n" {
+		t.Errorf("unexpected daily note:\n%s", data)
+	}
+}
+
+func TestRolloverDailyTasks_Disabled(t *testing.T) {
+	cfg := &testDailyConfig{baseDir: t.TempDir(), dailyNoteDir: "daily"}
+	writeTestNote(t, filepath.Join(cfg.baseDir, "daily", "2025", "06", "2025-06-01.md"), "- [ ] a\n")
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	result, err := RolloverDailyTasks(cfg, now, nil)
+	if err != nil || result.Source != "" {
+		t.Errorf("expected no rollover, got %+v (err: %v)", result, err)
+	}
+}
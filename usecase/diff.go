@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around a change.
+const diffContextLines = 3
+
+// UnifiedDiff returns the changes from before to after as a unified diff
+// labelled with path, or "" when they are equal.
+func UnifiedDiff(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+
+	// 最長共通部分列で行の対応をとる
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type diffLine struct {
+		op   byte // ' ', '-', '+'
+		text string
+		a, b int // 行番号（0始まり）
+	}
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			lines = append(lines, diffLine{'+', b[j], i, j})
+			j++
+		default:
+			lines = append(lines, diffLine{'-', a[i], i, j})
+			i++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", path, path)
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			continue
+		}
+		// 変更の前後の文脈を含めてひとまとまりにする
+		from := max(start-diffContextLines, 0)
+		to := start
+		for k := start; k < len(lines); k++ {
+			if lines[k].op != ' ' {
+				to = k + 1
+			} else if k-to >= 2*diffContextLines {
+				break
+			}
+		}
+		to = min(to+diffContextLines, len(lines))
+
+		countA, countB := 0, 0
+		for _, line := range lines[from:to] {
+			if line.op != '+' {
+				countA++
+			}
+			if line.op != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", lines[from].a+1, countA, lines[from].b+1, countB)
+		for _, line := range lines[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", line.op, line.text)
+		}
+		start = to
+	}
+	return out.String()
+}
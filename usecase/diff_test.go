@@ -0,0 +1,15 @@
+package usecase
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk"
+	want := "--- x.md\n+++ x.md\n@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n@@ -8,3 +8,4 @@\n h\n i\n j\n+k\n"
+	if got := UnifiedDiff("x.md", before, after); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if UnifiedDiff("x.md", "same", "same") != "" {
+		t.Errorf("expected empty diff for equal text")
+	}
+}
@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of values given to krapp fm set.
+const (
+	ValueFormatYAML   = "yaml"
+	ValueFormatJSON   = "json"
+	ValueFormatString = "string"
+)
+
+// FrontMatterCondition is a --where filter such as "status=new",
+// "created<2024-01-01", "label" (the key exists) or "!label" (it does not).
+type FrontMatterCondition struct {
+	Key   string
+	Op    string // "", "!", "=", "!=", "<", "<=", ">", ">="
+	Value string
+}
+
+var conditionPattern = regexp.MustCompile(`^([^=!<>\s]+)\s*(!=|<=|>=|=|<|>)\s*(.*)$`)
+
+// ParseFrontMatterCondition parses a --where expression.
+func ParseFrontMatterCondition(expr string) (FrontMatterCondition, error) {
+	expr = strings.TrimSpace(expr)
+	if m := conditionPattern.FindStringSubmatch(expr); m != nil {
+		return FrontMatterCondition{Key: m[1], Op: m[2], Value: strings.TrimSpace(m[3])}, nil
+	}
+	if strings.HasPrefix(expr, "!") && len(expr) > 1 && !strings.ContainsAny(expr[1:], "=<> ") {
+		return FrontMatterCondition{Key: expr[1:], Op: "!"}, nil
+	}
+	if expr != "" && !strings.ContainsAny(expr, "=<>! ") {
+		return FrontMatterCondition{Key: expr}, nil
+	}
+	return FrontMatterCondition{}, fmt.Errorf("invalid condition %q", expr)
+}
+
+// Matches reports whether fm satisfies the condition. "=" and "!=" on a
+// list test whether it contains the value. Values are compared as dates
+// or numbers when both sides are, and as strings otherwise, so that
+// "created<2024" selects dates before 2024.
+func (c FrontMatterCondition) Matches(fm models.FrontMatter) bool {
+	value, ok := fm[c.Key]
+	switch c.Op {
+	case "":
+		return ok
+	case "!":
+		return !ok
+	case "!=":
+		return !ok || !c.matchesAny(value, "=")
+	}
+	return ok && c.matchesAny(value, c.Op)
+}
+
+func (c FrontMatterCondition) matchesAny(value any, op string) bool {
+	if items, ok := value.([]any); ok {
+		for _, item := range items {
+			if c.compare(item, op) {
+				return true
+			}
+		}
+		return false
+	}
+	return c.compare(value, op)
+}
+
+func (c FrontMatterCondition) compare(value any, op string) bool {
+	cmp := compareValues(FormatFrontMatterValue(value), c.Value)
+	switch op {
+	case "=":
+		return cmp == 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func compareValues(a, b string) int {
+	if ta, err := time.Parse("2006-01-02", a); err == nil {
+		if tb, err := time.Parse("2006-01-02", b); err == nil {
+			return ta.Compare(tb)
+		}
+	}
+	if fa, err := strconv.ParseFloat(a, 64); err == nil {
+		if fb, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// ParseFrontMatterValue parses a value given on the command line. YAML
+// values such as "[a, b]", "true" or "3" become lists, booleans and
+// numbers; dates are kept as strings, the form SetCreated writes.
+func ParseFrontMatterValue(s, format string) (any, error) {
+	switch format {
+	case ValueFormatString:
+		return s, nil
+	case ValueFormatJSON:
+		var value any
+		if err := json.Unmarshal([]byte(s), &value); err != nil {
+			return nil, fmt.Errorf("invalid JSON value: %w", err)
+		}
+		return value, nil
+	case "", ValueFormatYAML:
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(s), &node); err != nil {
+			return nil, fmt.Errorf("invalid YAML value: %w", err)
+		}
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		if scalar := node.Content[0]; scalar.Kind == yaml.ScalarNode && scalar.ShortTag() == "!!timestamp" {
+			return scalar.Value, nil
+		}
+		var value any
+		if err := node.Content[0].Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid YAML value: %w", err)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unknown value format %q (yaml, json, string)", format)
+	}
+}
+
+// FormatFrontMatterValue returns value as a single line: strings as they
+// are, dates as YYYY-MM-DD and everything else in YAML flow style.
+func FormatFrontMatterValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.Equal(v.Truncate(24 * time.Hour)) {
+			return v.Format("2006-01-02")
+		}
+		return v.Format(time.RFC3339)
+	}
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	setFlowStyle(&node)
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func setFlowStyle(node *yaml.Node) {
+	if node.Kind == yaml.SequenceNode || node.Kind == yaml.MappingNode {
+		node.Style = yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		setFlowStyle(child)
+	}
+}
+
+// ExpandNotePaths returns the notes named by args: files, directories
+// (searched recursively) and glob patterns, where "**" matches any number
+// of directories. Relative paths are tried from the current directory and
+// then from baseDir. With no args, every note under baseDir is returned.
+func ExpandNotePaths(baseDir string, args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = []string{baseDir}
+	}
+	seen := map[string]bool{}
+	var paths []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, arg := range args {
+		candidates := []string{arg}
+		if !filepath.IsAbs(arg) {
+			candidates = append(candidates, filepath.Join(baseDir, arg))
+		}
+		found := false
+		for _, candidate := range candidates {
+			matches, err := globNotes(candidate)
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range matches {
+				add(path)
+			}
+			if len(matches) > 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no notes match %s", arg)
+		}
+	}
+	return paths, nil
+}
+
+// globNotes returns the notes matching pattern.
+func globNotes(pattern string) ([]string, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		info, err := os.Stat(pattern)
+		if err != nil {
+			return nil, nil
+		}
+		if !info.IsDir() {
+			return []string{filepath.Clean(pattern)}, nil
+		}
+		var paths []string
+		err = walkNoteFiles(pattern, true, func(path string, d fs.DirEntry) error {
+			paths = append(paths, path)
+			return nil
+		})
+		return paths, err
+	}
+
+	if !strings.Contains(pattern, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		var paths []string
+		for _, path := range matches {
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				paths = append(paths, path)
+			}
+		}
+		return paths, nil
+	}
+
+	// "**" を含むパターンは固定部分のディレクトリから探す
+	pattern = filepath.Clean(pattern)
+	root := pattern[:strings.Index(pattern, "**")]
+	root = filepath.Dir(root + "x")
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	if _, err := os.Stat(root); err != nil {
+		return nil, nil
+	}
+	err = walkNoteFiles(root, true, func(path string, d fs.DirEntry) error {
+		if re.MatchString(filepath.ToSlash(path)) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// globRegexp converts a glob pattern with "**" to a regular expression.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(pattern)
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				b.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// SelectNotes loads the notes at paths and keeps those matching every
+// condition. Notes that cannot be read are returned in failures.
+func SelectNotes(paths []string, conditions []FrontMatterCondition) ([]*models.Note, map[string]error) {
+	notes := []*models.Note{}
+	failures := map[string]error{}
+	for _, path := range paths {
+		note, err := models.LoadNoteFromFile(path)
+		if err != nil {
+			failures[path] = err
+			continue
+		}
+		matched := true
+		for _, condition := range conditions {
+			if !condition.Matches(note.FrontMatter) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			notes = append(notes, note)
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool {
+		return notes[i].FilePath < notes[j].FilePath
+	})
+	return notes, failures
+}
+
+// NoteEdit is the new content of a note file.
+type NoteEdit struct {
+	Path   string
+	Before string
+	After  string
+}
+
+// Diff returns the change as a unified diff.
+func (edit NoteEdit) Diff() string {
+	return UnifiedDiff(edit.Path, edit.Before, edit.After)
+}
+
+// PlanFrontMatterSet plans setting key to value in the notes. Notes where
+// the key already has the value are left out.
+func PlanFrontMatterSet(notes []*models.Note, key string, value any) ([]NoteEdit, error) {
+	return planFrontMatterEdits(notes, func(fm models.FrontMatter) {
+		fm[key] = value
+	})
+}
+
+// PlanFrontMatterUnset plans removing key from the notes that have it.
+func PlanFrontMatterUnset(notes []*models.Note, key string) ([]NoteEdit, error) {
+	return planFrontMatterEdits(notes, func(fm models.FrontMatter) {
+		delete(fm, key)
+	})
+}
+
+func planFrontMatterEdits(notes []*models.Note, edit func(fm models.FrontMatter)) ([]NoteEdit, error) {
+	edits := []NoteEdit{}
+	for _, note := range notes {
+		before, err := os.ReadFile(note.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", note.FilePath, err)
+		}
+		if note.FrontMatter == nil {
+			note.FrontMatter = models.FrontMatter{}
+		}
+		edit(note.FrontMatter)
+		after, err := note.ToString()
+		if err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", note.FilePath, err)
+		}
+		if after != string(before) {
+			edits = append(edits, NoteEdit{Path: note.FilePath, Before: string(before), After: after})
+		}
+	}
+	return edits, nil
+}
+
+// ApplyNoteEdits writes the edits, recording them in tx, which may be nil.
+func ApplyNoteEdits(edits []NoteEdit, tx *Transaction) error {
+	for _, edit := range edits {
+		if err := tx.RecordOverwrite(edit.Path); err != nil {
+			return fmt.Errorf("failed to record journal: %w", err)
+		}
+		if err := os.WriteFile(edit.Path, []byte(edit.After), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", edit.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+func TestFrontMatterCondition_Matches(t *testing.T) {
+	fm := models.FrontMatter{
+		"status":  "new",
+		"created": time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+		"tags":    []any{"a", "b"},
+		"rating":  3,
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"status=new", true},
+		{"status != new", false},
+		{"label!=diary", true},
+		{"created<2024-01-01", true},
+		{"created<2024", true},
+		{"created>=2024-01-01", false},
+		{"tags=b", true},
+		{"tags=c", false},
+		{"rating>10", false},
+		{"rating<=3", true},
+		{"status", true},
+		{"!label", true},
+		{"!status", false},
+	}
+	for _, tt := range tests {
+		condition, err := ParseFrontMatterCondition(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseFrontMatterCondition(%q): %v", tt.expr, err)
+		}
+		if got := condition.Matches(fm); got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+	for _, expr := range []string{"", "=x", "a b"} {
+		if _, err := ParseFrontMatterCondition(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestParseFrontMatterValue(t *testing.T) {
+	tests := []struct {
+		value  string
+		format string
+		want   any
+	}{
+		{"archived", "", "archived"},
+		{"[a, b]", ValueFormatYAML, []any{"a", "b"}},
+		{"3", ValueFormatYAML, 3},
+		{"true", ValueFormatYAML, true},
+		{"2024-01-01", ValueFormatYAML, "2024-01-01"},
+		{`{"k": [1, 2]}`, ValueFormatJSON, map[string]any{"k": []any{1.0, 2.0}}},
+		{"[a]", ValueFormatString, "[a]"},
+	}
+	for _, tt := range tests {
+		got, err := ParseFrontMatterValue(tt.value, tt.format)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.value, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+	if _, err := ParseFrontMatterValue("{", ValueFormatJSON); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+	if got := FormatFrontMatterValue([]any{"a", map[string]any{"k": 1}}); got != "[a, {k: 1}]" {
+		t.Errorf("unexpected formatted value: %q", got)
+	}
+}
+
+func TestExpandNotePaths(t *testing.T) {
+	baseDir := setupTestVault(t)
+	rel := func(paths []string) string {
+		var out []string
+		for _, path := range paths {
+			r, _ := filepath.Rel(baseDir, path)
+			out = append(out, filepath.ToSlash(r))
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"inbox"}, "inbox/a.md,inbox/b.md"},
+		{[]string{"daily/*.md", "inbox/a.md"}, "daily/c.md,daily/d.md,inbox/a.md"},
+		{[]string{filepath.Join(baseDir, "**", "c.md")}, "daily/c.md"},
+		{[]string{"inbox/a.md", "inbox/*.md"}, "inbox/a.md,inbox/b.md"},
+	}
+	for _, tt := range tests {
+		paths, err := ExpandNotePaths(baseDir, tt.args)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+		if got := rel(paths); got != tt.want {
+			t.Errorf("%v: got %s, want %s", tt.args, got, tt.want)
+		}
+	}
+	if _, err := ExpandNotePaths(baseDir, []string{"nothing/*.md"}); err == nil {
+		t.Errorf("expected error when nothing matches")
+	}
+}
+
+func TestPlanFrontMatterSet(t *testing.T) {
+	baseDir := setupTestVault(t)
+	paths, err := ExpandNotePaths(baseDir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	condition, _ := ParseFrontMatterCondition("created<2025-06-01")
+	notes, failures := SelectNotes(paths, []FrontMatterCondition{condition})
+	if len(notes) != 1 || len(failures) != 1 {
+		t.Fatalf("unexpected selection: %d notes, %v", len(notes), failures)
+	}
+
+	edits, err := PlanFrontMatterSet(notes, "status", "archived")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "---\ncreated: \"2025-05-20\"\nstatus: archived\ntags: meeting\n---\nB"
+	if len(edits) != 1 || edits[0].After != want {
+		t.Fatalf("unexpected edits: %+v", edits)
+	}
+	if diff := edits[0].Diff(); !strings.Contains(diff, "-status: new\n+status: archived\n") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	tx := journal.Begin("fm set")
+	if err := ApplyNoteEdits(edits, tx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readTestNote(t, edits[0].Path); got != want {
+		t.Errorf("unexpected file: %q", got)
+	}
+
+	// 同じ値なら変更しない
+	notes, _ = SelectNotes([]string{edits[0].Path}, nil)
+	if again, _ := PlanFrontMatterSet(notes, "status", "archived"); len(again) != 0 {
+		t.Errorf("expected no edits, got %+v", again)
+	}
+
+	notes, _ = SelectNotes(paths, nil)
+	edits, err = PlanFrontMatterUnset(notes, "tags")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(edits) != 2 || strings.Contains(edits[0].After, "tags") {
+		t.Errorf("unexpected unset edits: %+v", edits)
+	}
+}
@@ -13,6 +13,7 @@ type GitHubClient interface {
 	ListOpenIssues(repo string) ([]Issue, error)
 	GetIssueComments(repo string, issueNumber int) ([]Comment, error)
 	CloseIssue(repo string, issueNumber int) error
+	ReopenIssue(repo string, issueNumber int) error
 	GetCurrentRepo(baseDir string) (string, error)
 }
 
@@ -59,6 +60,8 @@ type ImportOptions struct {
 	Repo    string
 	DryRun  bool
 	NoClose bool
+	Since   time.Time    // これ以降に更新されたissueだけを取り込む（ゼロ値ならすべて）
+	Journal *Transaction // 作成したノートとクローズしたissueの記録先（nil可）
 }
 
 // GHClient implements GitHubClient using gh command
@@ -114,6 +117,17 @@ func (c *GHClient) CloseIssue(repo string, issueNumber int) error {
 	return nil
 }
 
+func (c *GHClient) ReopenIssue(repo string, issueNumber int) error {
+	cmd := exec.Command("gh", "issue", "reopen", fmt.Sprintf("%d", issueNumber),
+		"--repo", repo)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to reopen issue: %w", err)
+	}
+
+	return nil
+}
+
 func (c *GHClient) GetCurrentRepo(baseDir string) (string, error) {
 	cmd := exec.Command("git", "remote", "get-url", "origin")
 	cmd.Dir = baseDir
@@ -143,13 +157,15 @@ func (c *GHClient) GetCurrentRepo(baseDir string) (string, error) {
 
 // MockGitHubClient is a mock implementation for testing
 type MockGitHubClient struct {
-	Issues       []Issue
-	Comments     map[int][]Comment
-	ClosedIssues []int
-	RepoURL      string
-	ErrorOnList  error
-	ErrorOnGet   error
-	ErrorOnClose error
+	Issues         []Issue
+	Comments       map[int][]Comment
+	ClosedIssues   []int
+	ReopenedIssues []int
+	RepoURL        string
+	ErrorOnList    error
+	ErrorOnGet     error
+	ErrorOnClose   error
+	ErrorOnReopen  error
 }
 
 func (m *MockGitHubClient) ListOpenIssues(repo string) ([]Issue, error) {
@@ -178,6 +194,14 @@ func (m *MockGitHubClient) CloseIssue(repo string, issueNumber int) error {
 	return nil
 }
 
+func (m *MockGitHubClient) ReopenIssue(repo string, issueNumber int) error {
+	if m.ErrorOnReopen != nil {
+		return m.ErrorOnReopen
+	}
+	m.ReopenedIssues = append(m.ReopenedIssues, issueNumber)
+	return nil
+}
+
 func (m *MockGitHubClient) GetCurrentRepo(baseDir string) (string, error) {
 	if m.RepoURL == "" {
 		return "owner/repo", nil
@@ -2,16 +2,21 @@ package usecase
 
 import (
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
-	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ishida722/krapp-go/models"
 )
 
-// ImportGitHubIssues imports GitHub issues as inbox notes
+// ImportGitHubIssues imports GitHub issues as inbox notes. Issues that
+// already have a note, found by its issue_url or issue_number, are skipped,
+// or updated in place with their new comments when they changed on GitHub
+// since the note was written.
 func ImportGitHubIssues(cfg InboxConfig, client GitHubClient, options ImportOptions) error {
 	// 1. リポジトリ情報取得
 	var repo string
@@ -34,6 +39,17 @@ func ImportGitHubIssues(cfg InboxConfig, client GitHubClient, options ImportOpti
 		return fmt.Errorf("failed to list issues: %w", err)
 	}
 
+	// --since より前に更新されたissueは対象にしない
+	if !options.Since.IsZero() {
+		recent := issues[:0]
+		for _, issue := range issues {
+			if !issue.UpdatedAt.Before(options.Since) {
+				recent = append(recent, issue)
+			}
+		}
+		issues = recent
+	}
+
 	if len(issues) == 0 {
 		log.Println("No open issues found")
 		return nil
@@ -41,9 +57,29 @@ func ImportGitHubIssues(cfg InboxConfig, client GitHubClient, options ImportOpti
 
 	log.Printf("Found %d open issues", len(issues))
 
-	// 3. 各issueを処理
-	successCount := 0
+	// 3. 取り込み済みのノートを探す
+	existing, err := findIssueNotes(cfg.GetBaseDir())
+	if err != nil {
+		return fmt.Errorf("failed to find imported issues: %w", err)
+	}
+
+	// 4. 各issueを処理
+	successCount, updatedCount, skippedCount := 0, 0, 0
 	for _, issue := range issues {
+		if note := existing.find(issue); note != nil {
+			updated, err := updateIssueNote(client, repo, issue, note, options)
+			if err != nil {
+				log.Printf("failed to update issue #%d: %v", issue.Number, err)
+				continue
+			}
+			if updated {
+				updatedCount++
+			} else {
+				skippedCount++
+			}
+			successCount++
+			continue
+		}
 		if err := processIssue(cfg, client, repo, issue, options); err != nil {
 			log.Printf("failed to process issue #%d: %v", issue.Number, err)
 			continue
@@ -51,7 +87,7 @@ func ImportGitHubIssues(cfg InboxConfig, client GitHubClient, options ImportOpti
 		successCount++
 	}
 
-	log.Printf("Successfully processed %d/%d issues", successCount, len(issues))
+	log.Printf("Successfully processed %d/%d issues (%d updated, %d unchanged)", successCount, len(issues), updatedCount, skippedCount)
 	return nil
 }
 
@@ -67,13 +103,26 @@ func processIssue(cfg InboxConfig, client GitHubClient, repo string, issue Issue
 	markdown := generateIssueMarkdown(issue, comments)
 
 	// 3. ファイル作成
-	filename := generateIssueFilename(issue)
+	filename, err := issueFilename(cfg, issue)
+	if err != nil {
+		return fmt.Errorf("failed to generate filename: %w", err)
+	}
 	filePath := filepath.Join(cfg.GetBaseDir(), cfg.GetInboxDir(), filename)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
 
 	// 4. frontmatter作成（issueの作成日時をcreatedに設定）
 	fm := createIssueFrontMatter(issue)
 
-	// 5. ノート保存
+	// 5. ノート保存（既存ファイルは上書き前に退避）
+	_, statErr := os.Stat(filePath)
+	overwrite := statErr == nil
+	if overwrite {
+		if err := options.Journal.RecordOverwrite(filePath); err != nil {
+			return fmt.Errorf("failed to record journal: %w", err)
+		}
+	}
 	_, err = models.CreateNewNoteWithFrontMatter(models.NewNoteWithFrontMatter{
 		Content:     markdown,
 		FilePath:    filePath,
@@ -83,50 +132,180 @@ func processIssue(cfg InboxConfig, client GitHubClient, repo string, issue Issue
 	if err != nil {
 		return fmt.Errorf("failed to create note: %w", err)
 	}
+	if !overwrite {
+		if err := options.Journal.RecordCreate(filePath); err != nil {
+			return fmt.Errorf("failed to record journal: %w", err)
+		}
+	}
 
 	log.Printf("Created note for issue #%d: %s", issue.Number, filename)
 
 	// 6. issue クローズ（オプション）
-	if !options.DryRun && !options.NoClose {
-		if err := client.CloseIssue(repo, issue.Number); err != nil {
-			return fmt.Errorf("failed to close issue: %w", err)
+	return closeImportedIssue(client, repo, issue, options)
+}
+
+// closeImportedIssue closes issue unless options say otherwise.
+func closeImportedIssue(client GitHubClient, repo string, issue Issue, options ImportOptions) error {
+	if options.DryRun || options.NoClose {
+		return nil
+	}
+	if err := client.CloseIssue(repo, issue.Number); err != nil {
+		return fmt.Errorf("failed to close issue: %w", err)
+	}
+	if err := options.Journal.RecordIssueClose(repo, issue.Number); err != nil {
+		return fmt.Errorf("failed to record journal: %w", err)
+	}
+	log.Printf("Closed issue #%d", issue.Number)
+	return nil
+}
+
+// issueNotes are the notes of imported issues in the vault.
+type issueNotes struct {
+	byURL    map[string]*models.Note
+	byNumber map[int]*models.Note // issue_urlのないノート
+}
+
+// findIssueNotes loads the notes under baseDir that have an issue_url or
+// issue_number in their frontmatter.
+func findIssueNotes(baseDir string) (*issueNotes, error) {
+	notes := &issueNotes{byURL: map[string]*models.Note{}, byNumber: map[int]*models.Note{}}
+	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
+		return notes, nil
+	}
+	err := walkNoteFiles(baseDir, true, func(path string, d fs.DirEntry) error {
+		note, err := models.LoadNoteFromFile(path)
+		if err != nil {
+			return nil
+		}
+		if url, ok := note.FrontMatter["issue_url"].(string); ok && url != "" {
+			notes.byURL[url] = note
+		} else if number, ok := frontMatterInt(note.FrontMatter["issue_number"]); ok {
+			notes.byNumber[number] = note
 		}
-		log.Printf("Closed issue #%d", issue.Number)
+		return nil
+	})
+	return notes, err
+}
+
+// find returns the note of issue, or nil.
+func (notes *issueNotes) find(issue Issue) *models.Note {
+	if note, ok := notes.byURL[issue.URL]; ok && issue.URL != "" {
+		return note
 	}
+	return notes.byNumber[issue.Number]
+}
 
-	return nil
+func frontMatterInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), v == float64(int(v))
+	case string:
+		n, err := strconv.Atoi(strings.TrimPrefix(v, "#"))
+		return n, err == nil
+	}
+	return 0, false
 }
 
-// generateIssueFilename generates a filename for the issue
-func generateIssueFilename(issue Issue) string {
-	// 日付をYYYY-MM-DD形式で取得
-	date := issue.CreatedAt.Format("2006-01-02")
+// frontMatterTime returns a time written in RFC 3339 in the frontmatter.
+func frontMatterTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
 
-	// タイトルをサニタイズ（ファイル名に使用できない文字を除去）
-	sanitizedTitle := sanitizeFilename(issue.Title)
+// updateIssueNote brings the note of an imported issue up to date when the
+// issue was updated after original_updated: the comments posted since are
+// appended to it and the metadata in its frontmatter is refreshed. The
+// rest of the note, which may have been edited, is kept. It reports whether
+// the note was changed.
+func updateIssueNote(client GitHubClient, repo string, issue Issue, note *models.Note, options ImportOptions) (bool, error) {
+	previous, known := frontMatterTime(note.FrontMatter["original_updated"])
+	if known && previous.Equal(issue.UpdatedAt) {
+		log.Printf("Skipped issue #%d: already imported in %s", issue.Number, note.FilePath)
+		return false, closeImportedIssue(client, repo, issue, options)
+	}
 
-	// 長すぎる場合は切り詰め
-	if len(sanitizedTitle) > 50 {
-		sanitizedTitle = sanitizedTitle[:50]
+	comments, err := client.GetIssueComments(repo, issue.Number)
+	if err != nil {
+		return false, fmt.Errorf("failed to get comments: %w", err)
+	}
+	var added []Comment
+	for _, comment := range comments {
+		// 前回の取り込みより後に書かれ、まだノートにないコメントだけを追加する
+		if known && !comment.CreatedAt.After(previous) {
+			continue
+		}
+		if body := strings.TrimSpace(comment.Body); body != "" && strings.Contains(note.Content, body) {
+			continue
+		}
+		added = append(added, comment)
+	}
+	if len(added) > 0 {
+		note.Content = appendIssueComments(note.Content, added)
 	}
+	setIssueMetadata(note.FrontMatter, issue)
 
-	return fmt.Sprintf("%s-issue-%d-%s.md", date, issue.Number, sanitizedTitle)
+	if err := options.Journal.RecordOverwrite(note.FilePath); err != nil {
+		return false, fmt.Errorf("failed to record journal: %w", err)
+	}
+	if err := note.SaveToFile(); err != nil {
+		return false, fmt.Errorf("failed to update note: %w", err)
+	}
+	log.Printf("Updated note for issue #%d: %d new comments", issue.Number, len(added))
+	return true, closeImportedIssue(client, repo, issue, options)
 }
 
-// sanitizeFilename removes characters that are not suitable for filenames
-func sanitizeFilename(filename string) string {
-	// ファイル名として使用できない文字のみを削除（日本語は保持）
-	// Windows/macOS/Linuxで使用できない文字: / \ : * ? " < > |
-	reg := regexp.MustCompile(`[/\\:*?"<>|]`)
-	filename = reg.ReplaceAllString(filename, "-")
+// issueFooter starts the footer generateIssueMarkdown writes.
+const issueFooter = "\n---\n*Issue automatically imported"
 
-	// 連続するハイフンを一つにまとめる
-	reg = regexp.MustCompile(`-+`)
-	filename = reg.ReplaceAllString(filename, "-")
+// appendIssueComments adds comments to the end of the comments of an
+// issue note, before its footer.
+func appendIssueComments(content string, comments []Comment) string {
+	var builder strings.Builder
+	if !strings.Contains(content, "## Comments\n") {
+		builder.WriteString("## Comments\n\n")
+	}
+	for _, comment := range comments {
+		writeIssueComment(&builder, comment)
+	}
+	footer := strings.LastIndex(content, issueFooter)
+	if footer < 0 {
+		return strings.TrimRight(content, "\n") + "\n\n" + builder.String()
+	}
+	return content[:footer+1] + builder.String() + content[footer+1:]
+}
 
-	// 先頭と末尾のハイフンを削除
-	filename = strings.Trim(filename, "-")
+// IssueFilenamePatternConfig is implemented by configs that customize the
+// file names of imported issues.
+type IssueFilenamePatternConfig interface {
+	GetIssueFilenamePattern() string
+}
 
+// issueFilename renders the file name of issue from the configured pattern.
+func issueFilename(cfg InboxConfig, issue Issue) (string, error) {
+	patternCfg, ok := cfg.(IssueFilenamePatternConfig)
+	if !ok || patternCfg.GetIssueFilenamePattern() == "" {
+		return generateIssueFilename(issue), nil
+	}
+	data := NewPathPatternData(issue.CreatedAt, issue.Title)
+	data.Number = issue.Number
+	return RenderPathPattern(patternCfg.GetIssueFilenamePattern(), data)
+}
+
+// generateIssueFilename generates a filename for the issue with the default pattern
+func generateIssueFilename(issue Issue) string {
+	data := NewPathPatternData(issue.CreatedAt, issue.Title)
+	data.Number = issue.Number
+	filename, _ := RenderPathPattern(DefaultIssueFilenamePattern, data)
 	return filename
 }
 
@@ -142,11 +321,20 @@ func createIssueFrontMatter(issue Issue) models.FrontMatter {
 	fm["status"] = "imported"
 	fm["issue_number"] = issue.Number
 	fm["issue_url"] = issue.URL
-	fm["state"] = issue.State
 	fm["imported_at"] = time.Now().Format(time.RFC3339)
+	setIssueMetadata(fm, issue)
+
+	return fm
+}
+
+// setIssueMetadata sets the frontmatter that follows the issue on GitHub,
+// removing the assignees, labels and milestone it no longer has.
+func setIssueMetadata(fm models.FrontMatter, issue Issue) {
+	fm["state"] = issue.State
 	fm["original_updated"] = issue.UpdatedAt.Format(time.RFC3339)
 
 	// 担当者
+	delete(fm, "assignees")
 	if len(issue.Assignees) > 0 {
 		assignees := make([]string, len(issue.Assignees))
 		for i, assignee := range issue.Assignees {
@@ -156,6 +344,7 @@ func createIssueFrontMatter(issue Issue) models.FrontMatter {
 	}
 
 	// ラベル
+	delete(fm, "labels")
 	if len(issue.Labels) > 0 {
 		labels := make([]string, len(issue.Labels))
 		for i, label := range issue.Labels {
@@ -165,11 +354,10 @@ func createIssueFrontMatter(issue Issue) models.FrontMatter {
 	}
 
 	// マイルストーン
+	delete(fm, "milestone")
 	if issue.Milestone.Title != "" {
 		fm["milestone"] = issue.Milestone.Title
 	}
-
-	return fm
 }
 
 // generateIssueMarkdown generates markdown content for the issue
@@ -216,10 +404,7 @@ func generateIssueMarkdown(issue Issue, comments []Comment) string {
 	if len(comments) > 0 {
 		builder.WriteString("## Comments\n\n")
 		for _, comment := range comments {
-			builder.WriteString(fmt.Sprintf("### Comment by @%s on %s\n\n",
-				comment.Author.Login, comment.CreatedAt.Format("2006-01-02")))
-			builder.WriteString(comment.Body)
-			builder.WriteString("\n\n")
+			writeIssueComment(&builder, comment)
 		}
 	}
 
@@ -230,3 +415,11 @@ func generateIssueMarkdown(issue Issue, comments []Comment) string {
 
 	return builder.String()
 }
+
+// writeIssueComment writes a comment as generateIssueMarkdown does.
+func writeIssueComment(builder *strings.Builder, comment Comment) {
+	builder.WriteString(fmt.Sprintf("### Comment by @%s on %s\n\n",
+		comment.Author.Login, comment.CreatedAt.Format("2006-01-02")))
+	builder.WriteString(comment.Body)
+	builder.WriteString("\n\n")
+}
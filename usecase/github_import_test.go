@@ -377,3 +377,140 @@ func TestGenerateIssueMarkdown(t *testing.T) {
 		t.Errorf("Markdown should contain footer")
 	}
 }
+
+type testIssuePatternConfig struct {
+	testInboxConfig
+	pattern string
+}
+
+func (c *testIssuePatternConfig) GetIssueFilenamePattern() string { return c.pattern }
+
+func TestImportGitHubIssues_FilenamePattern(t *testing.T) {
+	cfg := &testIssuePatternConfig{
+		testInboxConfig: testInboxConfig{baseDir: t.TempDir(), inboxDir: "inbox"},
+		pattern:         "issues/{{.Number}}-{{.Slug}}",
+	}
+	client := &MockGitHubClient{
+		Issues: []Issue{{
+			Number:    7,
+			Title:     "Broken Link Check",
+			CreatedAt: time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC),
+		}},
+		Comments: map[int][]Comment{},
+	}
+	if err := ImportGitHubIssues(cfg, client, ImportOptions{Repo: "owner/repo", NoClose: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := filepath.Join(cfg.baseDir, "inbox", "issues", "7-broken-link-check.md")
+	if _, err := os.Stat(expected); err != nil {
+		t.Errorf("expected %s to be created: %v", expected, err)
+	}
+}
+
+func TestImportGitHubIssues_Incremental(t *testing.T) {
+	tempDir := t.TempDir()
+	inboxDir := filepath.Join(tempDir, "inbox")
+	cfg := &testConfig{baseDir: tempDir}
+
+	issue := Issue{
+		Number:    7,
+		Title:     "Incremental import",
+		Body:      "Issue body",
+		State:     "open",
+		CreatedAt: time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 1, 12, 12, 0, 0, 0, time.UTC),
+		Author:    User{Login: "author"},
+		Labels:    []Label{{Name: "bug"}},
+		URL:       "https://github.com/owner/repo/issues/7",
+	}
+	first := Comment{Body: "First comment", CreatedAt: time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC), Author: User{Login: "a"}}
+	client := &MockGitHubClient{Issues: []Issue{issue}, Comments: map[int][]Comment{7: {first}}}
+
+	if err := ImportGitHubIssues(cfg, client, ImportOptions{NoClose: true}); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := os.ReadDir(inboxDir)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 note, got %d", len(files))
+	}
+	path := filepath.Join(inboxDir, files[0].Name())
+
+	// 手で書き足した内容は更新しても残る
+	edited := strings.Replace(readTestNote(t, path), "Issue body", "Issue body\n\nMy memo", 1)
+	writeTestNote(t, path, edited)
+
+	// 変更がなければノートはそのまま
+	if err := ImportGitHubIssues(cfg, client, ImportOptions{NoClose: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readTestNote(t, path); got != edited {
+		t.Errorf("unchanged issue should not rewrite the note:\n%s", got)
+	}
+
+	// 更新されたissueは新しいコメントだけを追記する
+	second := Comment{Body: "Second comment", CreatedAt: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC), Author: User{Login: "b"}}
+	issue.UpdatedAt = time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)
+	issue.Labels = []Label{{Name: "bug"}, {Name: "docs"}}
+	client.Issues = []Issue{issue}
+	client.Comments[7] = []Comment{first, second}
+	if err := ImportGitHubIssues(cfg, client, ImportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := os.ReadDir(inboxDir); len(files) != 1 {
+		t.Fatalf("the issue should not be imported twice, got %d notes", len(files))
+	}
+	note, err := models.LoadNoteFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(note.Content, "First comment") != 1 || !strings.Contains(note.Content, "My memo") {
+		t.Errorf("unexpected content:\n%s", note.Content)
+	}
+	if !strings.Contains(note.Content, "Second comment\n\n---\n*Issue automatically imported") {
+		t.Errorf("new comment should be appended before the footer:\n%s", note.Content)
+	}
+	if updated, _ := frontMatterTime(note.FrontMatter["original_updated"]); !updated.Equal(issue.UpdatedAt) {
+		t.Errorf("original_updated should be updated, got %v", note.FrontMatter["original_updated"])
+	}
+	if labels, ok := note.FrontMatter["labels"].([]any); !ok || len(labels) != 2 {
+		t.Errorf("labels should be updated, got %v", note.FrontMatter["labels"])
+	}
+	if len(client.ClosedIssues) != 1 {
+		t.Errorf("expected the issue to be closed, got %v", client.ClosedIssues)
+	}
+}
+
+func TestImportGitHubIssues_Since(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &testConfig{baseDir: tempDir}
+	client := &MockGitHubClient{Issues: []Issue{
+		{Number: 1, Title: "Old", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{Number: 2, Title: "New", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), UpdatedAt: time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	options := ImportOptions{NoClose: true, Since: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+	if err := ImportGitHubIssues(cfg, client, options); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := os.ReadDir(filepath.Join(tempDir, "inbox"))
+	if len(files) != 1 || !strings.Contains(files[0].Name(), "issue-2-") {
+		t.Errorf("only the recently updated issue should be imported, got %v", files)
+	}
+}
+
+func TestImportGitHubIssues_NoteWithoutURL(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &testConfig{baseDir: tempDir}
+	path := filepath.Join(tempDir, "notes", "issue.md")
+	writeTestNote(t, path, "---\nissue_number: 3\noriginal_updated: \"2024-01-02T00:00:00Z\"\n---\n# Issue #3\n")
+	client := &MockGitHubClient{Issues: []Issue{
+		{Number: 3, Title: "Moved note", UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	if err := ImportGitHubIssues(cfg, client, ImportOptions{NoClose: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "inbox")); !os.IsNotExist(err) {
+		t.Error("an issue with a note should not be imported again")
+	}
+}
@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// GraphFormat is an output format of krapp graph.
+type GraphFormat string
+
+const (
+	GraphFormatDOT     GraphFormat = "dot"
+	GraphFormatMermaid GraphFormat = "mermaid"
+	GraphFormatJSON    GraphFormat = "json"
+)
+
+// GraphFilter selects the notes included in an exported graph. Zero
+// values mean no filtering.
+type GraphFilter struct {
+	Tags        []string // すべてのタグ（子のタグを含む）を持つノートに限る
+	Folder      string   // BaseDirからのフォルダ（サブフォルダを含む）
+	Focus       string   // このノートの近傍に限る
+	Depth       int      // Focusからたどるリンクの数（0以下は1）
+	OrphansOnly bool
+}
+
+// GraphNode is a note in an exported graph.
+type GraphNode struct {
+	ID        string   `json:"id"` // BaseDirからのパス（スラッシュ区切り）
+	Path      string   `json:"path"`
+	Name      string   `json:"name"`
+	Folder    string   `json:"folder"`
+	Tags      []string `json:"tags"`
+	Links     int      `json:"links"`     // 他のノートへのリンク数
+	Backlinks int      `json:"backlinks"` // 他のノートからのリンク数
+	Orphan    bool     `json:"orphan"`
+}
+
+// GraphEdge is one or more links from a note to another.
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// NoteGraph is the part of the link graph selected for export. Only links
+// between notes are kept; links to attachments and self links are left out.
+type NoteGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// ParseGraphFormat returns the format named by s.
+func ParseGraphFormat(s string) (GraphFormat, error) {
+	switch format := GraphFormat(strings.ToLower(s)); format {
+	case GraphFormatDOT, GraphFormatMermaid, GraphFormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown graph format %q (dot, mermaid, json)", s)
+	}
+}
+
+// Export returns the notes matching filter and the links between them.
+// Orphans are notes that neither link to nor are linked from another note
+// anywhere in the vault.
+func (graph *LinkGraph) Export(filter GraphFilter) (*NoteGraph, error) {
+	// ノート間のリンク数を数える
+	counts := map[[2]string]int{}
+	neighbors := map[string]map[string]bool{}
+	for source, links := range graph.Links {
+		for _, link := range links {
+			if link.Path == source || graph.Notes[link.Path] == nil {
+				continue
+			}
+			counts[[2]string{source, link.Path}]++
+			for _, pair := range [][2]string{{source, link.Path}, {link.Path, source}} {
+				if neighbors[pair[0]] == nil {
+					neighbors[pair[0]] = map[string]bool{}
+				}
+				neighbors[pair[0]][pair[1]] = true
+			}
+		}
+	}
+
+	var focus map[string]bool
+	if filter.Focus != "" {
+		path, err := graph.FindNote(filter.Focus)
+		if err != nil {
+			return nil, err
+		}
+		focus = neighborhood(path, neighbors, filter.Depth)
+	}
+	folder := ""
+	if filter.Folder != "" {
+		folder = filepath.Clean(filepath.Join(graph.BaseDir, filter.Folder))
+	}
+
+	selected := map[string]*GraphNode{}
+	for path, note := range graph.Notes {
+		if focus != nil && !focus[path] {
+			continue
+		}
+		if folder != "" && path != folder && !strings.HasPrefix(path, folder+string(filepath.Separator)) {
+			continue
+		}
+		tags := note.AllTags()
+		if !hasAllTags(tags, filter.Tags) {
+			continue
+		}
+		orphan := len(neighbors[path]) == 0
+		if filter.OrphansOnly && !orphan {
+			continue
+		}
+		rel, _ := filepath.Rel(graph.BaseDir, path)
+		relDir := filepath.Dir(rel)
+		if relDir == "." {
+			relDir = ""
+		}
+		base := filepath.Base(path)
+		selected[path] = &GraphNode{
+			ID:     filepath.ToSlash(rel),
+			Path:   path,
+			Name:   strings.TrimSuffix(base, filepath.Ext(base)),
+			Folder: filepath.ToSlash(relDir),
+			Tags:   tags,
+			Orphan: orphan,
+		}
+	}
+
+	result := &NoteGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for pair, count := range counts {
+		from, to := selected[pair[0]], selected[pair[1]]
+		if from == nil || to == nil {
+			continue
+		}
+		from.Links++
+		to.Backlinks++
+		result.Edges = append(result.Edges, GraphEdge{From: from.ID, To: to.ID, Count: count})
+	}
+	for _, node := range selected {
+		result.Nodes = append(result.Nodes, *node)
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		return result.Nodes[i].ID < result.Nodes[j].ID
+	})
+	sort.Slice(result.Edges, func(i, j int) bool {
+		if result.Edges[i].From != result.Edges[j].From {
+			return result.Edges[i].From < result.Edges[j].From
+		}
+		return result.Edges[i].To < result.Edges[j].To
+	})
+	return result, nil
+}
+
+// neighborhood returns the notes reachable from start by following at most
+// depth links in either direction.
+func neighborhood(start string, neighbors map[string]map[string]bool, depth int) map[string]bool {
+	if depth <= 0 {
+		depth = 1
+	}
+	visited := map[string]bool{start: true}
+	current := []string{start}
+	for i := 0; i < depth && len(current) > 0; i++ {
+		var next []string
+		for _, path := range current {
+			for neighbor := range neighbors[path] {
+				if !visited[neighbor] {
+					visited[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		current = next
+	}
+	return visited
+}
+
+// hasAllTags reports whether every required tag, or a child of it, is in
+// tags.
+func hasAllTags(tags, required []string) bool {
+	for _, query := range required {
+		found := false
+		for _, tag := range tags {
+			if models.TagMatches(tag, query) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Write writes the graph to w in the given format.
+func (g *NoteGraph) Write(w io.Writer, format GraphFormat) error {
+	switch format {
+	case GraphFormatDOT:
+		return g.WriteDOT(w)
+	case GraphFormatMermaid:
+		return g.WriteMermaid(w)
+	case GraphFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(g)
+	default:
+		return fmt.Errorf("unknown graph format %q", format)
+	}
+}
+
+// WriteDOT writes the graph in Graphviz DOT. Notes are grouped into a
+// cluster per folder and orphans are drawn dashed.
+func (g *NoteGraph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph krapp {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for i, folder := range g.folders() {
+		indent := "  "
+		if folder != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, dotQuote(folder))
+			indent = "    "
+		}
+		for _, node := range g.Nodes {
+			if node.Folder != folder {
+				continue
+			}
+			attrs := "label=" + dotQuote(node.Name)
+			if node.Orphan {
+				attrs += `, style="rounded,dashed"`
+			}
+			fmt.Fprintf(&b, "%s%s [%s];\n", indent, dotQuote(node.ID), attrs)
+		}
+		if folder != "" {
+			b.WriteString("  }\n")
+		}
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s", dotQuote(edge.From), dotQuote(edge.To))
+		if edge.Count > 1 {
+			fmt.Fprintf(&b, " [penwidth=%d]", min(edge.Count, 5))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart. Node IDs are
+// numbered because Mermaid does not accept arbitrary file names.
+func (g *NoteGraph) WriteMermaid(w io.Writer) error {
+	ids := map[string]string{}
+	for i, node := range g.Nodes {
+		ids[node.ID] = fmt.Sprintf("n%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	var orphans []string
+	for i, folder := range g.folders() {
+		indent := "  "
+		if folder != "" {
+			fmt.Fprintf(&b, "  subgraph f%d[%s]\n", i, mermaidQuote(folder))
+			indent = "    "
+		}
+		for _, node := range g.Nodes {
+			if node.Folder != folder {
+				continue
+			}
+			fmt.Fprintf(&b, "%s%s[%s]\n", indent, ids[node.ID], mermaidQuote(node.Name))
+			if node.Orphan {
+				orphans = append(orphans, ids[node.ID])
+			}
+		}
+		if folder != "" {
+			b.WriteString("  end\n")
+		}
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+	}
+	if len(orphans) > 0 {
+		b.WriteString("  classDef orphan stroke-dasharray: 5 5\n")
+		fmt.Fprintf(&b, "  class %s orphan\n", strings.Join(orphans, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// folders returns the folders of the nodes, with the vault root ("") first.
+func (g *NoteGraph) folders() []string {
+	set := map[string]bool{"": true}
+	for _, node := range g.Nodes {
+		set[node.Folder] = true
+	}
+	folders := make([]string, 0, len(set))
+	for folder := range set {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
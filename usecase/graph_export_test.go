@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupGraphVault(t *testing.T) *LinkGraph {
+	t.Helper()
+	baseDir := setupLinkVault(t)
+	writeTestNote(t, filepath.Join(baseDir, "lonely.md"), "---\ntags: [draft]\n---\nどこにもリンクしない\n")
+	writeTestNote(t, filepath.Join(baseDir, "projects", "far.md"), "---\ntags: [draft, work]\n---\n[[ideas]]\n")
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return graph
+}
+
+func graphNodeIDs(g *NoteGraph) string {
+	ids := make([]string, 0, len(g.Nodes))
+	for _, node := range g.Nodes {
+		ids = append(ids, node.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestLinkGraph_Export(t *testing.T) {
+	graph := setupGraphVault(t)
+
+	all, err := graph.Export(GraphFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := graphNodeIDs(all); ids != "daily/2025-06-02.md,ideas.md,lonely.md,projects/far.md,projects/krapp.md" {
+		t.Errorf("unexpected nodes: %s", ids)
+	}
+	// ideas -> krapp は2本のリンク、添付ファイルと自分へのリンクは含まない
+	if len(all.Edges) != 5 {
+		t.Fatalf("expected 5 edges, got %+v", all.Edges)
+	}
+	for _, edge := range all.Edges {
+		if edge.From == "ideas.md" && edge.To == "projects/krapp.md" && edge.Count != 2 {
+			t.Errorf("expected 2 links from ideas to krapp, got %d", edge.Count)
+		}
+	}
+	if !all.Nodes[2].Orphan || all.Nodes[1].Orphan {
+		t.Errorf("unexpected orphans: %+v", all.Nodes)
+	}
+
+	tests := []struct {
+		name   string
+		filter GraphFilter
+		want   string
+	}{
+		{"tag", GraphFilter{Tags: []string{"#draft"}}, "lonely.md,projects/far.md"},
+		{"tags", GraphFilter{Tags: []string{"draft", "work"}}, "projects/far.md"},
+		{"folder", GraphFilter{Folder: "projects"}, "projects/far.md,projects/krapp.md"},
+		{"focus", GraphFilter{Focus: "far", Depth: 1}, "ideas.md,projects/far.md"},
+		{"depth", GraphFilter{Focus: "far", Depth: 2}, "ideas.md,projects/far.md,projects/krapp.md"},
+		{"orphans", GraphFilter{OrphansOnly: true}, "lonely.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := graph.Export(tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ids := graphNodeIDs(g); ids != tt.want {
+				t.Errorf("got %s, want %s", ids, tt.want)
+			}
+		})
+	}
+
+	if _, err := graph.Export(GraphFilter{Focus: "nothing"}); err == nil {
+		t.Errorf("expected error for unknown note")
+	}
+}
+
+func TestNoteGraph_Write(t *testing.T) {
+	graph := setupGraphVault(t)
+	g, err := graph.Export(GraphFilter{Folder: "projects"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var dot bytes.Buffer
+	if err := g.Write(&dot, GraphFormatDOT); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"digraph krapp {", `label="projects";`, `"projects/krapp.md" [label="krapp"];`} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	if err := g.Write(&mermaid, GraphFormatMermaid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"flowchart LR", `subgraph f1["projects"]`, `n0["far"]`} {
+		if !strings.Contains(mermaid.String(), want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, mermaid.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := g.Write(&out, GraphFormatJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded NoteGraph
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(decoded.Nodes) != 2 || len(decoded.Edges) != 0 {
+		t.Errorf("unexpected JSON graph: %+v", decoded)
+	}
+
+	if _, err := ParseGraphFormat("svg"); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// htmlConverter converts HTML, including Evernote's ENML, to Markdown.
+type htmlConverter struct {
+	// media returns the Markdown for an <en-media> or <img> element, or ""
+	// to drop it. nil writes images as they are.
+	media func(tag string, attrs map[string]string) string
+	// link rewrites the target of a link. nil keeps it.
+	link func(href string) string
+
+	pre int // <pre> の中
+}
+
+// HTMLToMarkdown converts an HTML document or fragment to Markdown.
+func HTMLToMarkdown(s string) (string, error) {
+	return (&htmlConverter{}).convert(s)
+}
+
+func (c *htmlConverter) convert(s string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	var out strings.Builder
+	c.children(doc, &out)
+	return cleanMarkdown(out.String()), nil
+}
+
+var (
+	whitespacePattern = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// cleanMarkdown removes trailing spaces and repeated blank lines.
+func cleanMarkdown(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLinesPattern.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+func (c *htmlConverter) children(n *html.Node, out *strings.Builder) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child, out)
+	}
+}
+
+// render returns the Markdown of the children of n.
+func (c *htmlConverter) render(n *html.Node) string {
+	var out strings.Builder
+	c.children(n, &out)
+	return out.String()
+}
+
+// atLineStart reports whether out is empty or ends with a newline.
+func atLineStart(out *strings.Builder) bool {
+	s := out.String()
+	return s == "" || strings.HasSuffix(s, "\n")
+}
+
+func attrs(n *html.Node) map[string]string {
+	m := make(map[string]string, len(n.Attr))
+	for _, attr := range n.Attr {
+		m[attr.Key] = attr.Val
+	}
+	return m
+}
+
+func (c *htmlConverter) node(n *html.Node, out *strings.Builder) {
+	switch n.Type {
+	case html.TextNode:
+		if c.pre > 0 {
+			out.WriteString(n.Data)
+			return
+		}
+		text := whitespacePattern.ReplaceAllString(n.Data, " ")
+		if atLineStart(out) {
+			text = strings.TrimLeft(text, " ")
+		}
+		out.WriteString(text)
+		return
+	case html.DocumentNode:
+		c.children(n, out)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch tag := strings.ToLower(n.Data); tag {
+	case "head", "script", "style", "title", "meta", "link":
+	case "br":
+		out.WriteString("\n")
+	case "p", "section", "article", "header", "footer", "main", "figure":
+		out.WriteString("\n\n")
+		c.children(n, out)
+		out.WriteString("\n\n")
+	case "div", "en-note", "body", "html", "figcaption", "dd", "dt", "address":
+		if !atLineStart(out) {
+			out.WriteString("\n")
+		}
+		c.children(n, out)
+		if !atLineStart(out) {
+			out.WriteString("\n")
+		}
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(tag[1] - '0')
+		text := strings.TrimSpace(strings.ReplaceAll(c.render(n), "\n", " "))
+		if text != "" {
+			fmt.Fprintf(out, "\n\n%s %s\n\n", strings.Repeat("#", level), text)
+		}
+	case "strong", "b":
+		c.wrap(n, out, "**")
+	case "em", "i", "cite":
+		c.wrap(n, out, "*")
+	case "s", "del", "strike":
+		c.wrap(n, out, "~~")
+	case "code", "kbd", "samp", "tt":
+		if c.pre > 0 {
+			c.children(n, out)
+		} else {
+			c.wrap(n, out, "`")
+		}
+	case "pre":
+		c.pre++
+		text := strings.Trim(c.render(n), "\n")
+		c.pre--
+		fmt.Fprintf(out, "\n\n```\n%s\n```\n\n", text)
+	case "blockquote":
+		text := cleanMarkdown(c.render(n))
+		out.WriteString("\n\n")
+		for _, line := range strings.Split(text, "\n") {
+			out.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+		}
+		out.WriteString("\n")
+	case "hr":
+		out.WriteString("\n\n---\n\n")
+	case "ul", "ol":
+		c.list(n, out, tag == "ol")
+	case "li":
+		// ul/olの外のli
+		c.listItem(n, out, "- ")
+	case "a":
+		c.anchor(n, out)
+	case "img", "en-media":
+		a := attrs(n)
+		if c.media != nil {
+			out.WriteString(c.media(tag, a))
+		} else if tag == "img" && a["src"] != "" {
+			fmt.Fprintf(out, "![%s](%s)", a["alt"], markdownTarget(a["src"]))
+		}
+	case "en-todo":
+		if strings.EqualFold(attrs(n)["checked"], "true") {
+			out.WriteString("- [x] ")
+		} else {
+			out.WriteString("- [ ] ")
+		}
+		// HTMLとして読むと後ろのテキストが子になる
+		c.children(n, out)
+	case "en-crypt":
+		out.WriteString("<!-- encrypted -->")
+	case "table":
+		c.table(n, out)
+	default:
+		c.children(n, out)
+	}
+}
+
+// wrap writes the children of n between marker, keeping the spaces around
+// them outside the markers.
+func (c *htmlConverter) wrap(n *html.Node, out *strings.Builder, marker string) {
+	text := c.render(n)
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		out.WriteString(text)
+		return
+	}
+	if strings.HasPrefix(text, " ") && !atLineStart(out) {
+		out.WriteString(" ")
+	}
+	out.WriteString(marker + trimmed + marker)
+	if strings.HasSuffix(text, " ") {
+		out.WriteString(" ")
+	}
+}
+
+func (c *htmlConverter) anchor(n *html.Node, out *strings.Builder) {
+	href := attrs(n)["href"]
+	text := strings.TrimSpace(c.render(n))
+	if href == "" || strings.HasPrefix(href, "#") {
+		out.WriteString(text)
+		return
+	}
+	if c.link != nil {
+		href = c.link(href)
+	}
+	switch {
+	case text == "":
+		fmt.Fprintf(out, "<%s>", href)
+	case text == href && strings.Contains(href, "://"):
+		fmt.Fprintf(out, "<%s>", href)
+	default:
+		fmt.Fprintf(out, "[%s](%s)", text, markdownTarget(href))
+	}
+}
+
+// markdownTarget returns target written so that it can be used in a
+// Markdown link.
+func markdownTarget(target string) string {
+	if strings.ContainsAny(target, " ()") {
+		return "<" + target + ">"
+	}
+	return target
+}
+
+func (c *htmlConverter) list(n *html.Node, out *strings.Builder, ordered bool) {
+	if !atLineStart(out) {
+		out.WriteString("\n")
+	}
+	number := 1
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		if !strings.EqualFold(child.Data, "li") {
+			// ネストしたリストがliの外にある場合
+			c.node(child, out)
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		c.listItem(child, out, marker)
+	}
+	out.WriteString("\n")
+}
+
+// listItem writes li with marker, indenting its other lines under the
+// marker.
+func (c *htmlConverter) listItem(n *html.Node, out *strings.Builder, marker string) {
+	text := cleanMarkdown(c.render(n))
+	// Evernoteのチェックボックスはリストの印を兼ねる
+	if strings.HasPrefix(text, "- [") && marker == "- " {
+		marker = ""
+	}
+	indent := strings.Repeat(" ", len(marker))
+	if !atLineStart(out) {
+		out.WriteString("\n")
+	}
+	for i, line := range strings.Split(text, "\n") {
+		switch {
+		case i == 0:
+			out.WriteString(marker + line)
+		case line == "":
+		default:
+			out.WriteString(indent + line)
+		}
+		out.WriteString("\n")
+	}
+}
+
+func (c *htmlConverter) table(n *html.Node, out *strings.Builder) {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if strings.EqualFold(child.Data, "tr") {
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (strings.EqualFold(cell.Data, "td") || strings.EqualFold(cell.Data, "th")) {
+						text := cleanMarkdown(c.render(cell))
+						text = strings.ReplaceAll(text, "\n", " ")
+						row = append(row, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				rows = append(rows, row)
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	out.WriteString("\n\n")
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		out.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			out.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	out.WriteString("\n")
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import "testing"
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"paragraphs", "<p>first  line</p>\n<p>second<br>line</p>", "first line\n\nsecond\nline"},
+		{"heading", "<h2>Title <b>bold</b></h2><p>text</p>", "## Title **bold**\n\ntext"},
+		{"inline", "<p>a <strong>b</strong> <em>c</em> <code>d</code> <s>e</s></p>", "a **b** *c* `d` ~~e~~"},
+		{"link", `<p><a href="https://example.com">site</a> <a href="https://example.com/x">https://example.com/x</a></p>`, "[site](https://example.com) <https://example.com/x>"},
+		{"list", "<ul><li>a<ul><li>b</li></ul></li><li>c</li></ul>", "- a\n  - b\n- c"},
+		{"ordered", "<ol><li>one</li><li>two</li></ol>", "1. one\n2. two"},
+		{"pre", "<pre><code>x := 1\n  y()\n</code></pre>", "```\nx := 1\n  y()\n```"},
+		{"quote", "<blockquote><p>a</p><p>b</p></blockquote>", "> a\n>\n> b"},
+		{"table", "<table><tr><th>k</th><th>v</th></tr><tr><td>a|b</td><td>1</td></tr></table>", "| k | v |\n| --- | --- |\n| a\\|b | 1 |"},
+		{"divs", "<en-note><div>line 1</div>\n<div>line 2</div><div><br/></div><div>line 3</div></en-note>", "line 1\nline 2\n\nline 3"},
+		{"todo", `<div><en-todo checked="true"/>done</div><div><en-todo/>todo</div>`, "- [x] done\n- [ ] todo"},
+		{"image", `<p><img src="a b.png" alt="pic"></p>`, "![pic](<a b.png>)"},
+		{"script", "<script>alert(1)</script><p>ok</p>", "ok"},
+	}
+	for _, tt := range tests {
+		got, err := HTMLToMarkdown(tt.html)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package usecase
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// enexNote is a <note> of an Evernote export.
+type enexNote struct {
+	Title      string         `xml:"title"`
+	Content    string         `xml:"content"`
+	Created    string         `xml:"created"`
+	Updated    string         `xml:"updated"`
+	Tags       []string       `xml:"tag"`
+	SourceURL  string         `xml:"note-attributes>source-url"`
+	Author     string         `xml:"note-attributes>author"`
+	Resources  []enexResource `xml:"resource"`
+	Notebook   string         `xml:"notebook"` // 新しい書き出し形式にだけある
+	sourceFile string
+}
+
+// enexResource is an attachment of an Evernote note.
+type enexResource struct {
+	Data     string `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// enexTimeLayout is the form of dates in ENEX files, always in UTC.
+const enexTimeLayout = "20060102T150405Z"
+
+// FindEnexFiles returns path when it is a file, or the .enex files under
+// it when it is a directory.
+func FindEnexFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".enex") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// ImportEnex imports the notes of the Evernote export files at paths into
+// the inbox, as CreateInboxNote would create them. Bodies are converted to
+// Markdown and attachments are saved in "attachments/<note name>" beside
+// the note. created, updated, tags and the notebook (the file name unless
+// the export records it) become frontmatter.
+func ImportEnex(cfg InboxConfig, paths []string, opts ImportNotesOptions, tx *Transaction) (*ImportSummary, error) {
+	summary := &ImportSummary{Notes: []ImportedNote{}}
+	writer := newImportWriter(opts, tx)
+	for _, path := range paths {
+		err := readEnexNotes(path, func(note enexNote) error {
+			results, err := importEnexNote(cfg, note, writer)
+			summary.Notes = append(summary.Notes, results...)
+			return err
+		})
+		if err != nil {
+			return summary, fmt.Errorf("failed to import %s: %w", path, err)
+		}
+	}
+	return summary, nil
+}
+
+// readEnexNotes calls fn for each note of the ENEX file at path, reading
+// the file as a stream since exports can be large.
+func readEnexNotes(path string, fn func(enexNote) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	notebook := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+		var note enexNote
+		if err := decoder.DecodeElement(&note, &start); err != nil {
+			return err
+		}
+		if note.Notebook == "" {
+			note.Notebook = notebook
+		}
+		note.sourceFile = path
+		if err := fn(note); err != nil {
+			return err
+		}
+	}
+}
+
+func parseEnexTime(s string) (time.Time, bool) {
+	t, err := time.Parse(enexTimeLayout, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.Local(), true
+}
+
+// importEnexNote writes note and its attachments.
+func importEnexNote(cfg InboxConfig, note enexNote, writer *importWriter) ([]ImportedNote, error) {
+	title := strings.TrimSpace(note.Title)
+	if title == "" {
+		title = "untitled"
+	}
+	source := fmt.Sprintf("%s: %s", note.sourceFile, title)
+	created, ok := parseEnexTime(note.Created)
+	if !ok {
+		created = time.Now()
+	}
+
+	inboxNote, err := newInboxNote(cfg, created, title, "")
+	if err != nil {
+		return nil, err
+	}
+	// 失敗するかもしれない添付ファイルのデコードは、ノートのパスを決める前に済ませる
+	resources := make([][]byte, len(note.Resources))
+	for i, resource := range note.Resources {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(resource.Data), ""))
+		if err != nil {
+			return []ImportedNote{{Source: source, Status: ImportFailed, Message: fmt.Sprintf("invalid attachment: %v", err)}}, nil
+		}
+		resources[i] = data
+	}
+
+	// 添付ファイルのフォルダは名前を変えた後のノート名で作る
+	result := ImportedNote{Source: source}
+	result.Dest, result.Status, err = writer.reserve(inboxNote.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == ImportSkipped {
+		return []ImportedNote{result}, nil
+	}
+	attachments := newNoteAttachments(result.Dest)
+	// 添付ファイルはen-mediaのhash（MD5）で本文から参照される
+	byHash := map[string]*noteAttachment{}
+	for i, resource := range note.Resources {
+		sum := md5.Sum(resources[i])
+		byHash[hex.EncodeToString(sum[:])] = attachments.add(resource.FileName, resource.Mime, resources[i])
+	}
+
+	converter := &htmlConverter{
+		media: func(tag string, attrs map[string]string) string {
+			if tag == "img" {
+				if attrs["src"] == "" {
+					return ""
+				}
+				return fmt.Sprintf("![%s](%s)", attrs["alt"], markdownTarget(attrs["src"]))
+			}
+			a, ok := byHash[strings.ToLower(attrs["hash"])]
+			if !ok {
+				return ""
+			}
+			return a.link()
+		},
+	}
+	body, err := converter.convert(note.Content)
+	if err != nil {
+		// 書かなかったノートのパスは後の同名のノートに使わせる
+		writer.release(result.Dest)
+		return []ImportedNote{{Source: source, Status: ImportFailed, Message: err.Error()}}, nil
+	}
+	body += attachments.unusedLinks()
+	inboxNote.Content = strings.TrimSpace("# " + title + "\n\n" + body)
+
+	fm := inboxNote.FrontMatter
+	tags := fm.Tags()
+	for _, tag := range note.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > 0 {
+		setFrontMatterList(fm, "tags", tags)
+	}
+	if updated, ok := parseEnexTime(note.Updated); ok {
+		fm["updated"] = updated.Format("2006-01-02")
+	}
+	if note.Notebook != "" {
+		fm["notebook"] = note.Notebook
+	}
+	if note.SourceURL != "" {
+		fm["source_url"] = note.SourceURL
+	}
+	if note.Author != "" {
+		fm["author"] = note.Author
+	}
+
+	content, err := inboxNote.ToString()
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.save(result.Dest, result.Status, content); err != nil {
+		return nil, err
+	}
+	attached, err := attachments.write(writer, result.Dest, source)
+	return append([]ImportedNote{result}, attached...), err
+}
+
+// noteAttachments are the attachments of an imported note, saved in
+// "attachments/<note name>" beside it and linked from the note.
+type noteAttachments struct {
+	dir   string
+	files []*noteAttachment
+	names map[string]bool
+}
+
+// noteAttachment is a file attached to an imported note.
+type noteAttachment struct {
+	rel  string // ノートからの相対パス
+	data []byte
+	mime string
+	used bool // 本文から参照されている
+}
+
+func newNoteAttachments(notePath string) *noteAttachments {
+	stem := strings.TrimSuffix(filepath.Base(notePath), filepath.Ext(notePath))
+	return &noteAttachments{dir: filepath.Join("attachments", stem), names: map[string]bool{}}
+}
+
+// add adds a file named name, or "attachment-N" with the extension of its
+// type when it has no name.
+func (a *noteAttachments) add(name, mimeType string, data []byte) *noteAttachment {
+	name = safeFilename(name)
+	if name == "" {
+		name = fmt.Sprintf("attachment-%d%s", len(a.files)+1, mimeExtension(mimeType))
+	}
+	for base, n := name, 1; a.names[name]; n++ {
+		ext := filepath.Ext(base)
+		name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(base, ext), n, ext)
+	}
+	a.names[name] = true
+	file := &noteAttachment{rel: filepath.ToSlash(filepath.Join(a.dir, name)), data: data, mime: mimeType}
+	a.files = append(a.files, file)
+	return file
+}
+
+// link returns the Markdown link to the file and marks it used.
+func (file *noteAttachment) link() string {
+	file.used = true
+	return attachmentLink(file.rel, file.mime)
+}
+
+// unusedLinks returns a list of links to the files the body does not refer
+// to, to append to it, or "".
+func (a *noteAttachments) unusedLinks() string {
+	var links []string
+	for _, file := range a.files {
+		if !file.used {
+			links = append(links, "- "+attachmentLink(file.rel, file.mime))
+		}
+	}
+	if len(links) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(links, "\n")
+}
+
+// write writes the files beside the note written at noteDest. A file that
+// cannot be written where the note links to, because another file is there
+// and is not to be overwritten, is reported as failed.
+func (a *noteAttachments) write(writer *importWriter, noteDest, source string) ([]ImportedNote, error) {
+	// リンクはノートからの相対パスなので、ノートと同じディレクトリに置く
+	noteDir := filepath.Dir(noteDest)
+	var results []ImportedNote
+	for _, file := range a.files {
+		path := filepath.Join(noteDir, filepath.FromSlash(file.rel))
+		result := ImportedNote{Source: source, Dest: path, Attachment: true}
+		if writer.exists(path) && writer.opts.Collision != CollisionOverwrite {
+			result.Status = ImportFailed
+			result.Message = "another file is already at the linked path"
+			results = append(results, result)
+			continue
+		}
+		var err error
+		result.Dest, result.Status, err = writer.write(path, string(file.data))
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// attachmentLink returns the Markdown link to an attachment, embedding
+// images.
+func attachmentLink(rel, mimeType string) string {
+	name := filepath.Base(rel)
+	if strings.HasPrefix(mimeType, "image/") {
+		return fmt.Sprintf("![%s](%s)", name, markdownTarget(rel))
+	}
+	return fmt.Sprintf("[%s](%s)", name, markdownTarget(rel))
+}
+
+// mimeExtension returns the usual extension of a MIME type, or "".
+func mimeExtension(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "application/pdf":
+		return ".pdf"
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestEnex(t *testing.T, dir string) string {
+	t.Helper()
+	image := []byte("\x89PNG fake image")
+	sum := md5.Sum(image)
+	enex := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20240201T000000Z" application="Evernote" version="10">
+  <note>
+    <title>会議 メモ</title>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div>議題は<b>予算</b>です。</div><div><en-todo checked="true"/>資料を送る</div><div><en-media hash="` + hex.EncodeToString(sum[:]) + `" type="image/png"/></div></en-note>]]></content>
+    <created>20240105T010203Z</created>
+    <updated>20240106T000000Z</updated>
+    <tag>meeting</tag>
+    <tag>work</tag>
+    <note-attributes><source-url>https://example.com/a</source-url></note-attributes>
+    <resource>
+      <data encoding="base64">
+` + base64.StdEncoding.EncodeToString(image) + `
+      </data>
+      <mime>image/png</mime>
+      <resource-attributes><file-name>board.png</file-name></resource-attributes>
+    </resource>
+    <resource>
+      <data encoding="base64">` + base64.StdEncoding.EncodeToString([]byte("%PDF")) + `</data>
+      <mime>application/pdf</mime>
+    </resource>
+  </note>
+  <note>
+    <title>Second</title>
+    <content><![CDATA[<en-note>plain</en-note>]]></content>
+    <created>20240107T000000Z</created>
+  </note>
+</en-export>
+`
+	path := filepath.Join(dir, "Work.enex")
+	writeTestNote(t, path, enex)
+	return path
+}
+
+func TestImportEnex(t *testing.T) {
+	baseDir := t.TempDir()
+	cfg := &testConfig{baseDir: baseDir}
+	path := writeTestEnex(t, t.TempDir())
+
+	summary, err := ImportEnex(cfg, []string{path}, ImportNotesOptions{}, nil)
+	if err != nil {
+		t.Fatalf("ImportEnex: %v", err)
+	}
+	if summary.Count(ImportCreated) != 4 {
+		t.Fatalf("expected 2 notes and 2 attachments, got %+v", summary.Notes)
+	}
+
+	note := readTestNote(t, summary.Notes[0].Dest)
+	if filepath.Base(summary.Notes[0].Dest) != "2024-01-05-会議 メモ.md" {
+		t.Errorf("unexpected file name %s", summary.Notes[0].Dest)
+	}
+	for _, want := range []string{
+		"created: \"2024-01-05\"",
+		"updated: \"2024-01-06\"",
+		"notebook: Work",
+		"source_url: https://example.com/a",
+		"- meeting\n",
+		"- work\n",
+		"status: new",
+		"# 会議 メモ\n\n議題は**予算**です。\n- [x] 資料を送る\n![board.png](<attachments/2024-01-05-会議 メモ/board.png>)",
+		"- [attachment-2.pdf](<attachments/2024-01-05-会議 メモ/attachment-2.pdf>)",
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("note should contain %q:\n%s", want, note)
+		}
+	}
+
+	image, err := os.ReadFile(filepath.Join(baseDir, "inbox", "attachments", "2024-01-05-会議 メモ", "board.png"))
+	if err != nil || string(image) != "\x89PNG fake image" {
+		t.Errorf("attachment was not decoded: %q, %v", image, err)
+	}
+
+	// 同じファイルをもう一度取り込んでも既定では上書きしない
+	summary, err = ImportEnex(cfg, []string{path}, ImportNotesOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count(ImportSkipped) != 2 || summary.Count(ImportCreated) != 0 {
+		t.Errorf("expected the notes to be skipped, got %+v", summary.Notes)
+	}
+}
+
+func TestFindEnexFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTestNote(t, filepath.Join(dir, "b.enex"), "")
+	writeTestNote(t, filepath.Join(dir, "sub", "a.ENEX"), "")
+	writeTestNote(t, filepath.Join(dir, "note.md"), "")
+
+	files, err := FindEnexFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || filepath.Base(files[0]) != "b.enex" {
+		t.Errorf("unexpected files: %v", files)
+	}
+}
+
+func TestImportEnex_RenamedNoteKeepsItsAttachments(t *testing.T) {
+	note := func(data string) string {
+		sum := md5.Sum([]byte(data))
+		return `<note><title>Same</title><content><![CDATA[<en-note><en-media hash="` + hex.EncodeToString(sum[:]) + `" type="image/png"/></en-note>]]></content>
+<created>20240105T000000Z</created>
+<resource><data encoding="base64">` + base64.StdEncoding.EncodeToString([]byte(data)) + `</data><mime>image/png</mime><resource-attributes><file-name>a.png</file-name></resource-attributes></resource></note>`
+	}
+	path := filepath.Join(t.TempDir(), "same.enex")
+	writeTestNote(t, path, "<en-export>"+note("first")+note("second")+"</en-export>")
+	baseDir := t.TempDir()
+
+	summary, err := ImportEnex(&testConfig{baseDir: baseDir}, []string{path}, ImportNotesOptions{Collision: CollisionRename}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count(ImportFailed) != 0 || len(summary.Notes) != 4 {
+		t.Fatalf("unexpected summary: %+v", summary.Notes)
+	}
+
+	inbox := filepath.Join(baseDir, "inbox")
+	for name, data := range map[string]string{"2024-01-05-Same": "first", "2024-01-05-Same-1": "second"} {
+		note := readTestNote(t, filepath.Join(inbox, name+".md"))
+		if !strings.Contains(note, "![a.png](attachments/"+name+"/a.png)") {
+			t.Errorf("%s should link to its own attachment:\n%s", name, note)
+		}
+		if got := readTestNote(t, filepath.Join(inbox, "attachments", name, "a.png")); got != data {
+			t.Errorf("attachment of %s = %q, want %q", name, got, data)
+		}
+	}
+}
+
+func TestImportEnex_FailedNoteKeepsItsName(t *testing.T) {
+	note := func(data string) string {
+		return `<note><title>Same</title><content><![CDATA[<en-note>text</en-note>]]></content><created>20240105T000000Z</created>
+<resource><data encoding="base64">` + data + `</data><mime>image/png</mime></resource></note>`
+	}
+	path := filepath.Join(t.TempDir(), "same.enex")
+	writeTestNote(t, path, "<en-export>"+note("!!!")+note(base64.StdEncoding.EncodeToString([]byte("png")))+"</en-export>")
+	baseDir := t.TempDir()
+
+	summary, err := ImportEnex(&testConfig{baseDir: baseDir}, []string{path}, ImportNotesOptions{Collision: CollisionRename}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Notes) != 3 || summary.Notes[0].Status != ImportFailed || summary.Notes[1].Status != ImportCreated {
+		t.Fatalf("unexpected summary: %+v", summary.Notes)
+	}
+	// 失敗したノートは書いていないので、次のノートが元の名前を使う
+	if want := filepath.Join(baseDir, "inbox", "2024-01-05-Same.md"); summary.Notes[1].Dest != want {
+		t.Errorf("got %s, want %s", summary.Notes[1].Dest, want)
+	}
+}
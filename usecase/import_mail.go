@@ -0,0 +1,408 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ishida722/krapp-go/models"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// mailMessage is an email read for import.
+type mailMessage struct {
+	subject   string
+	from      string
+	to        string
+	cc        string
+	date      time.Time
+	messageID string
+
+	plain       string
+	html        string
+	attachments []mailAttachment
+}
+
+// mailAttachment is a part of an email that is not its body.
+type mailAttachment struct {
+	name      string
+	contentID string
+	mimeType  string
+	data      []byte
+}
+
+// mimeHeader is the header of a message or of a part of it.
+type mimeHeader interface {
+	Get(key string) string
+}
+
+// FindMailFiles returns path when it is a file, or the .eml and .mbox files
+// under it when it is a directory.
+func FindMailFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".eml", ".mbox":
+			if !d.IsDir() {
+				files = append(files, p)
+			}
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// ImportMail imports the emails in the .eml and mbox files at paths into
+// the inbox, as CreateInboxNote would create them. The plain text body is
+// preferred to the HTML one, which is converted to Markdown. From, To,
+// Subject, Date and Message-ID become frontmatter and attachments are saved
+// in "attachments/<note name>" beside the note. Messages whose Message-ID
+// is already in a note of the vault are skipped, so importing the same
+// mailbox again only adds the new messages.
+func ImportMail(cfg InboxConfig, paths []string, opts ImportNotesOptions, tx *Transaction) (*ImportSummary, error) {
+	imported, err := importedMessageIDs(cfg.GetBaseDir())
+	if err != nil {
+		return nil, err
+	}
+	summary := &ImportSummary{Notes: []ImportedNote{}}
+	writer := newImportWriter(opts, tx)
+	for _, path := range paths {
+		err := readMailbox(path, func(source string, raw []byte) error {
+			msg, err := parseMail(raw)
+			if err != nil {
+				summary.Notes = append(summary.Notes, ImportedNote{Source: source, Status: ImportFailed, Message: err.Error()})
+				return nil
+			}
+			if msg.messageID != "" {
+				if dest, ok := imported[msg.messageID]; ok {
+					summary.Notes = append(summary.Notes, ImportedNote{Source: source, Dest: dest, Status: ImportSkipped, Message: "already imported"})
+					return nil
+				}
+			}
+			results, err := importMailMessage(cfg, msg, source, writer)
+			summary.Notes = append(summary.Notes, results...)
+			if err == nil && len(results) > 0 && msg.messageID != "" {
+				imported[msg.messageID] = results[0].Dest
+			}
+			return err
+		})
+		if err != nil {
+			return summary, fmt.Errorf("failed to import %s: %w", path, err)
+		}
+	}
+	return summary, nil
+}
+
+// importedMessageIDs returns the notes under baseDir by the message_id in
+// their frontmatter.
+func importedMessageIDs(baseDir string) (map[string]string, error) {
+	ids := map[string]string{}
+	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
+		return ids, nil
+	}
+	err := walkNoteFiles(baseDir, true, func(path string, d fs.DirEntry) error {
+		note, err := models.LoadNoteFromFile(path)
+		if err != nil {
+			return nil
+		}
+		if id, ok := note.FrontMatter["message_id"].(string); ok && id != "" {
+			ids[id] = path
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// readMailbox calls fn for each message of the file at path: the whole
+// file for .eml files, or each message of an mbox file.
+func readMailbox(path string, fn func(source string, raw []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	first, _ := r.Peek(5)
+	if string(first) != "From " {
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return fn(path, raw)
+	}
+
+	// mboxは空行の次の "From " の行で区切られ、本文中の "From " は ">From " になっている
+	var msg bytes.Buffer
+	count := 0
+	flush := func() error {
+		if msg.Len() == 0 {
+			return nil
+		}
+		count++
+		raw := append([]byte(nil), msg.Bytes()...)
+		msg.Reset()
+		return fn(fmt.Sprintf("%s#%d", path, count), raw)
+	}
+	blank := true
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			switch {
+			case blank && bytes.HasPrefix(line, []byte("From ")):
+				if err := flush(); err != nil {
+					return err
+				}
+			case bytes.HasPrefix(bytes.TrimLeft(line, ">"), []byte("From ")) && line[0] == '>':
+				msg.Write(line[1:])
+			default:
+				msg.Write(line)
+			}
+			blank = len(bytes.TrimRight(line, "\r\n")) == 0
+		}
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseMail parses a message in RFC 5322 format.
+func parseMail(raw []byte) (*mailMessage, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	msg := &mailMessage{
+		subject:   decodeMailHeader(m.Header.Get("Subject")),
+		from:      decodeMailHeader(m.Header.Get("From")),
+		to:        decodeMailHeader(m.Header.Get("To")),
+		cc:        decodeMailHeader(m.Header.Get("Cc")),
+		messageID: strings.Trim(strings.TrimSpace(m.Header.Get("Message-ID")), "<>"),
+	}
+	if date, err := m.Header.Date(); err == nil {
+		msg.date = date
+	}
+	if err := msg.readPart(m.Header, m.Body); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+var mailWordDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, fmt.Errorf("unknown charset %q", charset)
+		}
+		return enc.NewDecoder().Reader(input), nil
+	},
+}
+
+// decodeMailHeader decodes the encoded words (=?ISO-2022-JP?B?...?=) in a
+// header, and ISO-2022-JP written in it as it is.
+func decodeMailHeader(value string) string {
+	if decoded, err := mailWordDecoder.DecodeHeader(value); err == nil {
+		value = decoded
+	}
+	if strings.Contains(value, "\x1b$") {
+		if decoded, err := japanese.ISO2022JP.NewDecoder().String(value); err == nil {
+			value = decoded
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+// readPart reads a part of the message, and the parts in it. The first
+// text/plain and text/html parts are the body; other parts, and those
+// with a file name, are attachments.
+func (msg *mailMessage) readPart(header mimeHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		r := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := r.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", mediaType, err)
+			}
+			if err := msg.readPart(part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", mediaType, err)
+	}
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := dispositionParams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	name = decodeMailHeader(name)
+
+	if name == "" && disposition != "attachment" {
+		switch {
+		case mediaType == "text/plain" && msg.plain == "":
+			msg.plain = decodeMailText(data, params["charset"])
+			return nil
+		case mediaType == "text/html" && msg.html == "":
+			msg.html = decodeMailText(data, params["charset"])
+			return nil
+		}
+	}
+	if name == "" && mediaType == "message/rfc822" {
+		name = "message.eml"
+	}
+	msg.attachments = append(msg.attachments, mailAttachment{
+		name:      name,
+		contentID: strings.Trim(strings.TrimSpace(header.Get("Content-ID")), "<>"),
+		mimeType:  mediaType,
+		data:      data,
+	})
+	return nil
+}
+
+// decodeMailText converts a text part in charset to UTF-8, detecting the
+// encoding when the charset is missing or wrong.
+func decodeMailText(data []byte, charset string) string {
+	var text string
+	if enc, err := htmlindex.Get(charset); err == nil && charset != "" {
+		if out, err := enc.NewDecoder().Bytes(data); err == nil && utf8.Valid(out) {
+			text = string(out)
+		}
+	}
+	if text == "" {
+		if out, _, err := decodeText(data, nil); err == nil {
+			text = out
+		} else {
+			text = strings.ToValidUTF8(string(data), "?")
+		}
+	}
+	return strings.ReplaceAll(text, "\r\n", "\n")
+}
+
+// importMailMessage writes msg and its attachments.
+func importMailMessage(cfg InboxConfig, msg *mailMessage, source string, writer *importWriter) ([]ImportedNote, error) {
+	title := msg.subject
+	if title == "" {
+		title = "untitled"
+	}
+	created := msg.date
+	if created.IsZero() {
+		created = time.Now()
+	}
+	inboxNote, err := newInboxNote(cfg, created, title, "")
+	if err != nil {
+		return nil, err
+	}
+	// 件名と日付が同じメールは名前が変わるので、添付ファイルのフォルダもそれに合わせる
+	result := ImportedNote{Source: source}
+	result.Dest, result.Status, err = writer.reserve(inboxNote.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == ImportSkipped {
+		return []ImportedNote{result}, nil
+	}
+
+	attachments := newNoteAttachments(result.Dest)
+	byContentID := map[string]*noteAttachment{}
+	for _, a := range msg.attachments {
+		file := attachments.add(a.name, a.mimeType, a.data)
+		if a.contentID != "" {
+			byContentID[a.contentID] = file
+		}
+	}
+
+	body := strings.TrimSpace(msg.plain)
+	if body == "" && msg.html != "" {
+		// 本文中の画像は cid:Content-ID で添付ファイルを参照する
+		converter := &htmlConverter{
+			media: func(tag string, attrs map[string]string) string {
+				src := attrs["src"]
+				if file, ok := byContentID[strings.TrimPrefix(src, "cid:")]; ok && strings.HasPrefix(src, "cid:") {
+					return file.link()
+				}
+				if tag != "img" || src == "" {
+					return ""
+				}
+				return fmt.Sprintf("![%s](%s)", attrs["alt"], markdownTarget(src))
+			},
+		}
+		body, err = converter.convert(msg.html)
+		if err != nil {
+			writer.release(result.Dest)
+			return []ImportedNote{{Source: source, Status: ImportFailed, Message: err.Error()}}, nil
+		}
+	}
+	body += attachments.unusedLinks()
+	inboxNote.Content = strings.TrimSpace("# " + title + "\n\n" + body)
+
+	fm := inboxNote.FrontMatter
+	for key, value := range map[string]string{
+		"from":       msg.from,
+		"to":         msg.to,
+		"cc":         msg.cc,
+		"subject":    msg.subject,
+		"message_id": msg.messageID,
+	} {
+		if value != "" {
+			fm[key] = value
+		}
+	}
+	if !msg.date.IsZero() {
+		fm["date"] = msg.date.Local().Format(time.RFC3339)
+	}
+
+	content, err := inboxNote.ToString()
+	if err != nil {
+		return nil, err
+	}
+	if err := writer.save(result.Dest, result.Status, content); err != nil {
+		return nil, err
+	}
+	attached, err := attachments.write(writer, result.Dest, source)
+	return append([]ImportedNote{result}, attached...), err
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func writeTestMbox(t *testing.T, dir string) string {
+	t.Helper()
+	subject, err := japanese.ISO2022JP.NewEncoder().String("会議の件")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := japanese.ISO2022JP.NewEncoder().String("資料を送ります。\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mbox := "From alice@example.com Fri Jan  5 10:30:00 2024\n" +
+		"From: =?UTF-8?B?" + base64.StdEncoding.EncodeToString([]byte("山田 花子")) + "?= <alice@example.com>\n" +
+		"To: bob@example.com\n" +
+		"Subject: =?ISO-2022-JP?B?" + base64.StdEncoding.EncodeToString([]byte(subject)) + "?=\n" +
+		"Date: Fri, 05 Jan 2024 10:30:00 +0900\n" +
+		"Message-ID: <m1@example.com>\n" +
+		"MIME-Version: 1.0\n" +
+		"Content-Type: multipart/mixed; boundary=\"b1\"\n" +
+		"\n" +
+		"--b1\n" +
+		"Content-Type: text/plain; charset=UTF-8\n" +
+		"Content-Transfer-Encoding: quoted-printable\n" +
+		"\n" +
+		"=E6=9C=AC=E6=96=87=E3=81=A7=E3=81=99=E3=80=82 long =\nline\n" +
+		">From the archive\n" +
+		"--b1\n" +
+		"Content-Type: application/pdf; name=\"agenda.pdf\"\n" +
+		"Content-Disposition: attachment; filename=\"agenda.pdf\"\n" +
+		"Content-Transfer-Encoding: base64\n" +
+		"\n" +
+		base64.StdEncoding.EncodeToString([]byte("%PDF-1.4")) + "\n" +
+		"--b1--\n" +
+		"\n" +
+		"From bob@example.com Sat Jan  6 09:00:00 2024\n" +
+		"From: bob@example.com\n" +
+		"To: alice@example.com\n" +
+		"Subject: Re: plain\n" +
+		"Date: Sat, 06 Jan 2024 09:00:00 +0900\n" +
+		"Message-ID: <m2@example.com>\n" +
+		"Content-Type: text/plain; charset=ISO-2022-JP\n" +
+		"\n" +
+		body
+	path := filepath.Join(dir, "inbox.mbox")
+	writeTestNote(t, path, mbox)
+	return path
+}
+
+func TestImportMail(t *testing.T) {
+	baseDir := t.TempDir()
+	cfg := &testConfig{baseDir: baseDir}
+	path := writeTestMbox(t, t.TempDir())
+
+	summary, err := ImportMail(cfg, []string{path}, ImportNotesOptions{Collision: CollisionRename}, nil)
+	if err != nil {
+		t.Fatalf("ImportMail: %v", err)
+	}
+	if summary.Count(ImportCreated) != 3 {
+		t.Fatalf("expected 2 notes and an attachment, got %+v", summary.Notes)
+	}
+
+	note := readTestNote(t, filepath.Join(baseDir, "inbox", "2024-01-05-会議の件.md"))
+	for _, want := range []string{
+		"from: 山田 花子 <alice@example.com>",
+		"to: bob@example.com",
+		"subject: 会議の件",
+		"message_id: m1@example.com",
+		"created: \"2024-01-05\"",
+		"# 会議の件\n\n本文です。 long line\nFrom the archive",
+		"- [agenda.pdf](attachments/2024-01-05-会議の件/agenda.pdf)",
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("note should contain %q:\n%s", want, note)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(baseDir, "inbox", "attachments", "2024-01-05-会議の件", "agenda.pdf")); err != nil || string(data) != "%PDF-1.4" {
+		t.Errorf("attachment was not decoded: %q, %v", data, err)
+	}
+
+	reply := readTestNote(t, filepath.Join(baseDir, "inbox", "2024-01-06-Re- plain.md"))
+	if !strings.Contains(reply, "資料を送ります。") {
+		t.Errorf("ISO-2022-JP body was not decoded:\n%s", reply)
+	}
+
+	// 同じメールはMessage-IDで取り込み済みとわかる
+	summary, err = ImportMail(cfg, []string{path}, ImportNotesOptions{Collision: CollisionRename}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count(ImportSkipped) != 2 || len(summary.Notes) != 2 {
+		t.Errorf("expected the messages to be skipped, got %+v", summary.Notes)
+	}
+}
+
+func TestImportMail_HTMLWithInlineImage(t *testing.T) {
+	eml := "From: a@example.com\r\n" +
+		"Subject: Photo\r\n" +
+		"Date: Sun, 07 Jan 2024 12:00:00 +0000\r\n" +
+		"Content-Type: multipart/related; boundary=r\r\n" +
+		"\r\n" +
+		"--r\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<p>Look <b>here</b>:</p><img src=\"cid:img1@x\">\r\n" +
+		"--r\r\n" +
+		"Content-Type: image/png\r\n" +
+		"Content-ID: <img1@x>\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		base64.StdEncoding.EncodeToString([]byte("\x89PNG")) + "\r\n" +
+		"--r--\r\n"
+	dir := t.TempDir()
+	writeTestNote(t, filepath.Join(dir, "photo.eml"), eml)
+	baseDir := t.TempDir()
+
+	files, err := FindMailFiles(dir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("FindMailFiles: %v, %v", files, err)
+	}
+	summary, err := ImportMail(&testConfig{baseDir: baseDir}, files, ImportNotesOptions{DryRun: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count(ImportCreated) != 2 || !summary.Notes[1].Attachment {
+		t.Errorf("unexpected summary: %+v", summary.Notes)
+	}
+	if entries, _ := os.ReadDir(baseDir); len(entries) != 0 {
+		t.Errorf("dry run should not write files, found %d", len(entries))
+	}
+
+	msg, err := parseMail([]byte(eml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.plain != "" || !strings.Contains(msg.html, "<b>here</b>") || len(msg.attachments) != 1 || msg.attachments[0].contentID != "img1@x" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+}
+
+func TestImportMail_SameSubjectKeepsAttachments(t *testing.T) {
+	message := func(id, data string) string {
+		return "From a@example.com Mon Jan  8 09:00:00 2024\n" +
+			"From: a@example.com\n" +
+			"Subject: Report\n" +
+			"Date: Mon, 08 Jan 2024 09:00:00 +0900\n" +
+			"Message-ID: <" + id + "@example.com>\n" +
+			"Content-Type: multipart/mixed; boundary=\"b\"\n" +
+			"\n" +
+			"--b\n" +
+			"Content-Type: text/plain\n" +
+			"\n" +
+			"see attached\n" +
+			"--b\n" +
+			"Content-Type: text/plain; name=\"r.txt\"\n" +
+			"Content-Disposition: attachment; filename=\"r.txt\"\n" +
+			"\n" +
+			data + "\n" +
+			"--b--\n" +
+			"\n"
+	}
+	path := filepath.Join(t.TempDir(), "reports.mbox")
+	writeTestNote(t, path, message("r1", "first")+message("r2", "second"))
+	baseDir := t.TempDir()
+
+	summary, err := ImportMail(&testConfig{baseDir: baseDir}, []string{path}, ImportNotesOptions{Collision: CollisionRename}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count(ImportFailed) != 0 || len(summary.Notes) != 4 {
+		t.Fatalf("unexpected summary: %+v", summary.Notes)
+	}
+
+	inbox := filepath.Join(baseDir, "inbox")
+	for name, data := range map[string]string{"2024-01-08-Report": "first", "2024-01-08-Report-1": "second"} {
+		note := readTestNote(t, filepath.Join(inbox, name+".md"))
+		if !strings.Contains(note, "[r.txt](attachments/"+name+"/r.txt)") {
+			t.Errorf("%s should link to its own attachment:\n%s", name, note)
+		}
+		if got := readTestNote(t, filepath.Join(inbox, "attachments", name, "r.txt")); strings.TrimSpace(got) != data {
+			t.Errorf("attachment of %s = %q, want %q", name, got, data)
+		}
+	}
+}
@@ -1,55 +1,350 @@
 package usecase
 
 import (
+	"bytes"
 	"fmt"
-	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/ishida722/krapp-go/models"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Ways to handle an imported note whose destination already exists.
+const (
+	CollisionSkip      = "skip"
+	CollisionRename    = "rename"
+	CollisionOverwrite = "overwrite"
+)
+
+// Results of importing a note.
+const (
+	ImportCreated     = "created"
+	ImportRenamed     = "renamed"
+	ImportOverwritten = "overwritten"
+	ImportSkipped     = "skipped"
+	ImportFailed      = "failed"
 )
 
+// ImportNotesOptions controls ImportNotes.
+type ImportNotesOptions struct {
+	Collision string          // 既存のファイルの扱い（既定はskip）
+	Encoding  string          // 元のファイルの文字コード（空なら推定する）
+	Created   []CreatedSource // createdのないノートに日付を設定する推定元（空なら設定しない）
+	DryRun    bool
+}
+
+// ImportedNote is the result of importing a file.
+type ImportedNote struct {
+	Source   string        `json:"source"`
+	Dest     string        `json:"dest,omitempty"`
+	Status   string        `json:"status"`
+	Encoding string        `json:"encoding,omitempty"`
+	Created  CreatedSource `json:"created,omitempty"` // createdを設定した推定元
+	Message  string        `json:"message,omitempty"`
+
+	Attachment bool `json:"attachment,omitempty"` // ノート以外のファイル
+}
+
+// ImportSummary is the outcome of an import.
+type ImportSummary struct {
+	Notes   []ImportedNote
+	Ignored int // 対象外の拡張子のファイル
+}
+
+// Count returns the number of notes with status.
+func (s *ImportSummary) Count(status string) int {
+	count := 0
+	for _, note := range s.Notes {
+		if note.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
 // ImportNotes copies .txt and .md files from src to dst recursively.
-// .txt files are converted to .md and all files are saved as UTF-8.
-func ImportNotes(src, dst string) error {
-	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+// .txt files are converted to .md and all files are converted to UTF-8
+// from the encoding detected, or opts.Encoding. Created and overwritten
+// files are recorded in tx, which may be nil.
+func ImportNotes(src, dst string, opts ImportNotesOptions, tx *Transaction) (*ImportSummary, error) {
+	forced, err := importEncoding(opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ImportSummary{Notes: []ImportedNote{}}
+	writer := newImportWriter(opts, tx)
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		if d.IsDir() {
 			return nil
 		}
-		fmt.Println("Processing:", path)
 		ext := strings.ToLower(filepath.Ext(d.Name()))
 		if ext != ".txt" && ext != ".md" {
+			summary.Ignored++
 			return nil
 		}
 		rel, err := filepath.Rel(src, path)
 		if err != nil {
 			return err
 		}
-		newName := d.Name()
-		if ext == ".txt" {
-			newName = strings.TrimSuffix(d.Name(), ".txt") + ".md"
-		}
-		dstPath := filepath.Join(dst, filepath.Dir(rel), newName)
-		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
-			return err
+		newName := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name())) + ".md"
+		result := ImportedNote{Source: path}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			result.Status = ImportFailed
+			result.Message = err.Error()
+			summary.Notes = append(summary.Notes, result)
+			return nil
 		}
-		in, err := os.Open(path)
+		text, encName, err := decodeText(raw, forced)
 		if err != nil {
-			return err
+			result.Status = ImportFailed
+			result.Message = err.Error()
+			summary.Notes = append(summary.Notes, result)
+			return nil
+		}
+		result.Encoding = encName
+
+		if len(opts.Created) > 0 {
+			var source CreatedSource
+			text, source, err = injectCreated(text, path, opts.Created)
+			if err != nil {
+				// frontmatterが読めなくても本文は失わないようにそのまま取り込む
+				result.Message = err.Error()
+			}
+			result.Created = source
 		}
-		defer in.Close()
-		out, err := os.Create(dstPath)
+
+		result.Dest, result.Status, err = writer.write(filepath.Join(dst, filepath.Dir(rel), newName), text)
 		if err != nil {
 			return err
 		}
-		defer out.Close()
-		// 読み込み→UTF-8で書き込み（GoのstringはUTF-8なのでそのままコピーでOK）
-		if _, err := io.Copy(out, in); err != nil {
-			return err
+		if result.Status == ImportSkipped {
+			result.Created = ""
 		}
+		summary.Notes = append(summary.Notes, result)
 		return nil
 	})
+	return summary, err
+}
+
+// importEncoding returns the encoding named name, or nil to detect it
+// when name is empty.
+func importEncoding(name string) (encoding.Encoding, error) {
+	if name == "" {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return enc, nil
+}
+
+// importWriter writes imported notes, resolving collisions with existing
+// files and with notes written earlier in the same import.
+type importWriter struct {
+	opts    ImportNotesOptions
+	tx      *Transaction
+	written map[string]bool
+}
+
+func newImportWriter(opts ImportNotesOptions, tx *Transaction) *importWriter {
+	return &importWriter{opts: opts, tx: tx, written: map[string]bool{}}
+}
+
+func (w *importWriter) exists(path string) bool {
+	if w.written[path] {
+		return true
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// write writes content to path, or plans to with DryRun, and returns the
+// path written and the status.
+func (w *importWriter) write(path, content string) (string, string, error) {
+	path, status, err := w.reserve(path)
+	if err != nil || status == ImportSkipped {
+		return path, status, err
+	}
+	return path, status, w.save(path, status, content)
+}
+
+// reserve decides where a file meant for path is written, applying the
+// collision handling, without writing it. Files whose content depends on
+// their final path are reserved first and then written with save.
+func (w *importWriter) reserve(path string) (string, string, error) {
+	status := ImportCreated
+	if w.exists(path) {
+		switch w.opts.Collision {
+		case CollisionOverwrite:
+			status = ImportOverwritten
+		case CollisionRename:
+			path = w.freePath(path)
+			status = ImportRenamed
+		case "", CollisionSkip:
+			return path, ImportSkipped, nil
+		default:
+			return "", "", fmt.Errorf("unknown collision handling %q (skip, rename, overwrite)", w.opts.Collision)
+		}
+	}
+	w.written[path] = true
+	return path, status, nil
+}
+
+// release gives back a path returned by reserve whose file is not going to
+// be written after all.
+func (w *importWriter) release(path string) {
+	delete(w.written, path)
+}
+
+// save writes content to a path returned by reserve, or does nothing with
+// DryRun.
+func (w *importWriter) save(path, status, content string) error {
+	if w.opts.DryRun {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if status == ImportOverwritten {
+		if err := w.tx.RecordOverwrite(path); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	if status != ImportOverwritten {
+		return w.tx.RecordCreate(path)
+	}
+	return nil
+}
+
+// freePath returns path with "-1", "-2", ... added before the extension
+// until it does not exist.
+func (w *importWriter) freePath(path string) string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", stem, i, ext)
+		if !w.exists(candidate) {
+			return candidate
+		}
+	}
+}
+
+// injectCreated sets created in the frontmatter of text when it does not
+// have one, inferring the date from the source file at path.
+func injectCreated(text, path string, sources []CreatedSource) (string, CreatedSource, error) {
+	note, err := models.ParseNote(text)
+	if err != nil {
+		return text, "", err
+	}
+	if note.FrontMatter == nil {
+		note.FrontMatter = models.FrontMatter{}
+	}
+	if hasCreated(note.FrontMatter) {
+		return text, "", nil
+	}
+	note.FilePath = path
+	t, source, err := InferCreated(note, sources)
+	if err != nil {
+		return text, "", nil
+	}
+	if err := note.FrontMatter.SetCreated(t); err != nil {
+		return text, "", err
+	}
+	out, err := note.ToString()
+	if err != nil {
+		return text, "", err
+	}
+	return out, source, nil
+}
+
+// textEncodings are the encodings tried, in order, for text that is not
+// UTF-8.
+var textEncodings = []struct {
+	name string
+	enc  encoding.Encoding
+}{
+	{"shift_jis", japanese.ShiftJIS},
+	{"euc-jp", japanese.EUCJP},
+	{"iso-2022-jp", japanese.ISO2022JP},
+}
+
+// decodeText converts raw to UTF-8 and returns the name of the encoding it
+// was in. With enc nil the encoding is detected: UTF-8 and UTF-16 by their
+// BOM or validity, and otherwise the Japanese encoding that decodes raw
+// without errors into the most Japanese-looking text.
+func decodeText(raw []byte, enc encoding.Encoding) (string, string, error) {
+	if enc != nil {
+		out, err := enc.NewDecoder().Bytes(raw)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decode: %w", err)
+		}
+		name, _ := htmlindex.Name(enc)
+		return string(out), name, nil
+	}
+
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xEF, 0xBB, 0xBF}):
+		// BOMはノートに残さない
+		return string(raw[3:]), "utf-8", nil
+	case bytes.HasPrefix(raw, []byte{0xFF, 0xFE}), bytes.HasPrefix(raw, []byte{0xFE, 0xFF}):
+		out, err := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder().Bytes(raw)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decode UTF-16: %w", err)
+		}
+		return string(out), "utf-16", nil
+	case utf8.Valid(raw) && !bytes.Contains(raw, []byte{0x1B, '$'}):
+		return string(raw), "utf-8", nil
+	}
+
+	best, bestName, bestScore := "", "", -1
+	for _, candidate := range textEncodings {
+		out, err := candidate.enc.NewDecoder().Bytes(raw)
+		if err != nil || bytes.ContainsRune(out, utf8.RuneError) {
+			continue
+		}
+		if score := japaneseScore(string(out)); score > bestScore {
+			best, bestName, bestScore = string(out), candidate.name, score
+		}
+	}
+	if bestScore < 0 {
+		return "", "", fmt.Errorf("unknown encoding (not UTF-8, Shift_JIS, EUC-JP or ISO-2022-JP)")
+	}
+	return best, bestName, nil
+}
+
+// japaneseScore rates how natural text looks as Japanese: kana and kanji
+// count for it, half-width katakana and symbols that are rare in notes,
+// which appear when EUC-JP is read as Shift_JIS, count against it.
+func japaneseScore(text string) int {
+	score := 0
+	for _, r := range text {
+		switch {
+		case r >= 0x3040 && r <= 0x30FF: // ひらがな・カタカナ
+			score += 2
+		case r >= 0x4E00 && r <= 0x9FFF: // 漢字
+			score++
+		case r >= 0xFF61 && r <= 0xFF9F: // 半角カタカナ
+			score -= 2
+		case r >= 0xE000 && r <= 0xF8FF: // 私用領域
+			score -= 4
+		}
+	}
+	return max(score, 0)
 }
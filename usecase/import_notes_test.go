@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encodeTestText(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	out, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	return out
+}
+
+func TestDecodeText(t *testing.T) {
+	const memo = "会議のメモ\r\n明日までに資料を用意する。\r\n"
+	tests := []struct {
+		name string
+		raw  []byte
+		want string
+	}{
+		{"utf-8", []byte(memo), "utf-8"},
+		{"utf-8 bom", append([]byte{0xEF, 0xBB, 0xBF}, memo...), "utf-8"},
+		{"utf-16", encodeTestText(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), memo), "utf-16"},
+		{"shift_jis", encodeTestText(t, japanese.ShiftJIS, memo), "shift_jis"},
+		{"euc-jp", encodeTestText(t, japanese.EUCJP, memo), "euc-jp"},
+		{"iso-2022-jp", encodeTestText(t, japanese.ISO2022JP, memo), "iso-2022-jp"},
+	}
+	for _, tt := range tests {
+		text, name, err := decodeText(tt.raw, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if name != tt.want || text != memo {
+			t.Errorf("%s: got %q as %s", tt.name, text, name)
+		}
+	}
+}
+
+func TestDecodeText_Forced(t *testing.T) {
+	raw := encodeTestText(t, japanese.ShiftJIS, "メモ")
+	text, name, err := decodeText(raw, japanese.ShiftJIS)
+	if err != nil || text != "メモ" || name != "shift_jis" {
+		t.Errorf("got %q, %q, %v", text, name, err)
+	}
+}
+
+func TestImportNotes(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "old.txt"), encodeTestText(t, japanese.ShiftJIS, "古いメモです"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2019, 4, 1, 9, 0, 0, 0, time.Local)
+	if err := os.Chtimes(filepath.Join(src, "old.txt"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	writeTestNote(t, filepath.Join(src, "sub", "2021-05-06-idea.md"), "---\ntags: [idea]\n---\nアイデア")
+	writeTestNote(t, filepath.Join(src, "dated.md"), "---\ncreated: 2020-01-01\n---\n本文")
+	writeTestNote(t, filepath.Join(src, "image.png"), "png")
+
+	summary, err := ImportNotes(src, dst, ImportNotesOptions{Created: DefaultCreatedSources}, nil)
+	if err != nil {
+		t.Fatalf("ImportNotes: %v", err)
+	}
+	if summary.Count(ImportCreated) != 3 || summary.Ignored != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	old := readTestNote(t, filepath.Join(dst, "old.md"))
+	if !strings.Contains(old, "created: \"2019-04-01\"") || !strings.Contains(old, "古いメモです") {
+		t.Errorf("unexpected old.md:\n%s", old)
+	}
+	idea := readTestNote(t, filepath.Join(dst, "sub", "2021-05-06-idea.md"))
+	if !strings.Contains(idea, "created: \"2021-05-06\"") || !strings.Contains(idea, "tags: [idea]") {
+		t.Errorf("unexpected idea note:\n%s", idea)
+	}
+	if dated := readTestNote(t, filepath.Join(dst, "dated.md")); dated != "---\ncreated: 2020-01-01\n---\n本文" {
+		t.Errorf("note with created should be copied as is:\n%s", dated)
+	}
+}
+
+func TestImportNotes_Collision(t *testing.T) {
+	tests := []struct {
+		collision string
+		status    string
+		dest      string
+		content   string
+	}{
+		{CollisionSkip, ImportSkipped, "a.md", "original"},
+		{CollisionRename, ImportRenamed, "a-1.md", "original"},
+		{CollisionOverwrite, ImportOverwritten, "a.md", "imported"},
+	}
+	for _, tt := range tests {
+		src := t.TempDir()
+		dst := t.TempDir()
+		writeTestNote(t, filepath.Join(src, "a.txt"), "imported")
+		writeTestNote(t, filepath.Join(dst, "a.md"), "original")
+
+		summary, err := ImportNotes(src, dst, ImportNotesOptions{Collision: tt.collision}, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.collision, err)
+		}
+		note := summary.Notes[0]
+		if note.Status != tt.status || note.Dest != filepath.Join(dst, tt.dest) {
+			t.Errorf("%s: unexpected result %+v", tt.collision, note)
+		}
+		if got := readTestNote(t, filepath.Join(dst, "a.md")); got != tt.content {
+			t.Errorf("%s: a.md is %q, want %q", tt.collision, got, tt.content)
+		}
+	}
+}
+
+func TestImportNotes_SameDestination(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeTestNote(t, filepath.Join(src, "a.md"), "md")
+	writeTestNote(t, filepath.Join(src, "a.txt"), "txt")
+
+	// a.md と a.txt はどちらも a.md になる
+	summary, err := ImportNotes(src, dst, ImportNotesOptions{Collision: CollisionRename, DryRun: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Notes[0].Dest != filepath.Join(dst, "a.md") || summary.Notes[1].Dest != filepath.Join(dst, "a-1.md") {
+		t.Errorf("unexpected destinations: %+v", summary.Notes)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Errorf("dry run should not write files, found %d", len(entries))
+	}
+}
@@ -0,0 +1,605 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+	"golang.org/x/text/encoding"
+)
+
+// notionIDPattern matches the ID Notion adds to the names of exported
+// pages and databases ("Page 0123...cdef.md"), with the "_all" of the CSV
+// holding every column of a database.
+var notionIDPattern = regexp.MustCompile(`\s*\b([0-9a-f]{32})(_all)?((?:\.[0-9A-Za-z]+)?)$`)
+
+// stripNotionID removes the Notion ID from a file or folder name.
+func stripNotionID(name string) string {
+	stripped := notionIDPattern.ReplaceAllString(name, "$3")
+	if stripped != name && (stripped == "" || strings.HasPrefix(stripped, ".")) {
+		return "Untitled" + stripped
+	}
+	return stripped
+}
+
+// stripNotionIDs removes the Notion IDs from every element of a slash
+// separated path.
+func stripNotionIDs(p string) string {
+	elems := strings.Split(p, "/")
+	for i, elem := range elems {
+		elems[i] = stripNotionID(elem)
+	}
+	return strings.Join(elems, "/")
+}
+
+// notionLinkTarget returns the path a link to target of the export points
+// to once imported: without IDs, and with pages and databases as notes.
+func notionLinkTarget(target string) string {
+	return notionNotePath(stripNotionIDs(target))
+}
+
+// notionNotePath returns p with the extension of pages and databases
+// replaced by ".md".
+func notionNotePath(p string) string {
+	switch ext := path.Ext(p); strings.ToLower(ext) {
+	case ".html", ".htm", ".csv":
+		p = strings.TrimSuffix(p, ext) + ".md"
+	}
+	return p
+}
+
+// notionPaths maps the files and folders of an export to the paths they
+// are imported to, without their Notion IDs. Pages and databases of the
+// same folder that would get the same name keep the start of their IDs.
+type notionPaths map[string]string
+
+func newNotionPaths(files []exportFile) notionPaths {
+	children := map[string]map[string]bool{}
+	for _, file := range files {
+		elems := strings.Split(file.name, "/")
+		for i := range elems {
+			parent := strings.Join(elems[:i], "/")
+			if children[parent] == nil {
+				children[parent] = map[string]bool{}
+			}
+			children[parent][elems[i]] = true
+		}
+	}
+
+	paths := notionPaths{}
+	var walk func(parent, imported string)
+	walk = func(parent, imported string) {
+		// ページとその子ページのフォルダは同じIDなので、IDが違うものだけを区別する
+		ids := map[string]map[string]bool{}
+		for elem := range children[parent] {
+			if m := notionIDPattern.FindStringSubmatch(elem); m != nil {
+				stem := strings.ToLower(strings.TrimSuffix(stripNotionID(elem), m[3]))
+				if ids[stem] == nil {
+					ids[stem] = map[string]bool{}
+				}
+				ids[stem][m[1]] = true
+			}
+		}
+		for elem := range children[parent] {
+			name := stripNotionID(elem)
+			if m := notionIDPattern.FindStringSubmatch(elem); m != nil {
+				if stem := strings.TrimSuffix(name, m[3]); len(ids[strings.ToLower(stem)]) > 1 {
+					name = stem + " " + m[1][:8] + m[3]
+				}
+			}
+			p := path.Join(parent, elem)
+			paths[p] = path.Join(imported, name)
+			walk(p, paths[p])
+		}
+	}
+	walk("", "")
+	return paths
+}
+
+// rel returns the path the file name of the export is imported to.
+func (paths notionPaths) rel(name string) string {
+	if rel, ok := paths[name]; ok {
+		return rel
+	}
+	return stripNotionIDs(name)
+}
+
+// linkTarget returns the path a link to target in the page name points to
+// once imported, relative to the imported page.
+func (paths notionPaths) linkTarget(name, target string) string {
+	imported, ok := paths[path.Join(path.Dir(name), target)]
+	if !ok {
+		return notionLinkTarget(target)
+	}
+	page := filepath.FromSlash(paths.rel(name))
+	return relativeLinkPath(page, filepath.FromSlash(notionNotePath(imported)))
+}
+
+// exportFile is a file of an export, which may be inside a zip file.
+type exportFile struct {
+	name string // エクスポート内のスラッシュ区切りのパス
+	read func() ([]byte, error)
+}
+
+// listExportFiles returns the files of fsys, skipping hidden files. Zip
+// files in it are read as part of the export, as Notion splits large
+// exports into several.
+func listExportFiles(fsys fs.FS) ([]exportFile, error) {
+	var files []exportFile
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		base := path.Base(name)
+		if name != "." && (strings.HasPrefix(base, ".") || base == "__MACOSX") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		read := func() ([]byte, error) { return fs.ReadFile(fsys, name) }
+		if !strings.EqualFold(path.Ext(name), ".zip") {
+			files = append(files, exportFile{name: name, read: read})
+			return nil
+		}
+		data, err := read()
+		if err != nil {
+			return err
+		}
+		r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		inner, err := listExportFiles(r)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		files = append(files, inner...)
+		return nil
+	})
+	return files, err
+}
+
+// notionDatabase is a database of a Notion export, read from its CSV.
+type notionDatabase struct {
+	source  string
+	dir     string   // 行のノートを置くディレクトリ（IDなし）
+	columns []string // 最初の列がタイトル
+	rows    []*notionRow
+}
+
+// notionRow is a row of a database, imported as a note.
+type notionRow struct {
+	db          *notionDatabase
+	title       string
+	key         string // IDを除いた行のページのパス（同じタイトルの行で共通）
+	rel         string
+	values      []string
+	frontMatter models.FrontMatter
+	imported    bool // 行のページがエクスポートにあり、それと一緒に取り込んだ
+}
+
+// ImportNotionExport imports a Notion export, or any folder of Markdown
+// and HTML pages, into dst. src is the exported zip file or the folder it
+// was extracted to. Notion IDs are removed from file names and links, HTML
+// pages are converted to Markdown and each row of a database becomes a
+// note with the columns in its frontmatter. Other files are copied as
+// attachments. Collisions and created are handled as in ImportNotes.
+func ImportNotionExport(src, dst string, opts ImportNotesOptions, tx *Transaction) (*ImportSummary, error) {
+	forced, err := importEncoding(opts.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	var fsys fs.FS
+	if info.IsDir() {
+		fsys = os.DirFS(src)
+	} else {
+		r, err := zip.OpenReader(src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", src, err)
+		}
+		defer r.Close()
+		fsys = r
+	}
+	files, err := listExportFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+	paths := newNotionPaths(files)
+
+	summary := &ImportSummary{Notes: []ImportedNote{}}
+	fail := func(source string, err error) {
+		summary.Notes = append(summary.Notes, ImportedNote{Source: source, Status: ImportFailed, Message: err.Error()})
+	}
+
+	// データベースの行を先に読み、同じ名前のページにfrontmatterとして付ける
+	allColumns := map[string]bool{}
+	for _, file := range files {
+		if m := notionIDPattern.FindStringSubmatch(file.name); m != nil && m[2] == "_all" {
+			allColumns[paths.rel(file.name)] = true
+		}
+	}
+	var databases []*notionDatabase
+	rows := map[string][]*notionRow{}
+	pages := map[string]bool{}
+	for _, file := range files {
+		rel := paths.rel(file.name)
+		switch strings.ToLower(path.Ext(rel)) {
+		case ".md", ".html", ".htm":
+			pages[strings.TrimSuffix(rel, path.Ext(rel))+".md"] = true
+			continue
+		case ".csv":
+		default:
+			continue
+		}
+		// _all.csv があればそちらにすべての列がある
+		if m := notionIDPattern.FindStringSubmatch(file.name); allColumns[rel] && (m == nil || m[2] != "_all") {
+			continue
+		}
+		source := filepath.Join(src, filepath.FromSlash(file.name))
+		db, err := readNotionDatabase(file, rel, forced)
+		if err != nil {
+			fail(source, err)
+			continue
+		}
+		db.source = source
+		databases = append(databases, db)
+		for _, row := range db.rows {
+			rows[row.key] = append(rows[row.key], row)
+		}
+	}
+
+	writer := newImportWriter(opts, tx)
+	write := func(result ImportedNote, rel, text string) error {
+		// 添付ファイルにはfrontmatterを付けない
+		if len(opts.Created) > 0 && !result.Attachment {
+			var err error
+			text, result.Created, err = injectCreated(text, result.Source, opts.Created)
+			if err != nil {
+				result.Message = err.Error()
+			}
+		}
+		var err error
+		result.Dest, result.Status, err = writer.write(filepath.Join(dst, filepath.FromSlash(rel)), text)
+		if err != nil {
+			return err
+		}
+		if result.Status == ImportSkipped {
+			result.Created = ""
+		}
+		summary.Notes = append(summary.Notes, result)
+		return nil
+	}
+
+	for _, file := range files {
+		source := filepath.Join(src, filepath.FromSlash(file.name))
+		rel := paths.rel(file.name)
+		ext := strings.ToLower(path.Ext(rel))
+		if ext == ".csv" {
+			continue
+		}
+		raw, err := file.read()
+		if err != nil {
+			fail(source, err)
+			continue
+		}
+		if ext != ".md" && ext != ".html" && ext != ".htm" {
+			if err := write(ImportedNote{Source: source, Attachment: true}, rel, string(raw)); err != nil {
+				return summary, err
+			}
+			continue
+		}
+
+		text, encName, err := decodeText(raw, forced)
+		if err != nil {
+			fail(source, err)
+			continue
+		}
+		rel = strings.TrimSuffix(rel, path.Ext(rel)) + ".md"
+		linkTarget := func(target string) string { return paths.linkTarget(file.name, target) }
+		if ext == ".md" {
+			text = rewriteNotionLinks(text, linkTarget)
+		} else if text, err = convertHTMLPage(text, linkTarget); err != nil {
+			fail(source, err)
+			continue
+		}
+		result := ImportedNote{Source: source, Encoding: encName}
+		key := path.Join(path.Dir(rel), notionNotePath(stripNotionID(path.Base(file.name))))
+		if row := matchNotionRow(rows[key], text); row != nil {
+			row.imported = true
+			row.rel = rel
+			if text, err = mergeNotionRow(text, row); err != nil {
+				result.Message = err.Error()
+			}
+		}
+		if err := write(result, rel, text); err != nil {
+			return summary, err
+		}
+	}
+
+	for _, db := range databases {
+		var links []string
+		for _, row := range db.rows {
+			links = append(links, fmt.Sprintf("- [%s](%s)", row.title, markdownPathEscaper.Replace(path.Base(row.db.dir)+"/"+path.Base(row.rel))))
+			if row.imported {
+				continue
+			}
+			note := &models.Note{FrontMatter: row.frontMatter, Content: "# " + row.title}
+			text, err := note.ToString()
+			if err != nil {
+				return summary, err
+			}
+			if err := write(ImportedNote{Source: fmt.Sprintf("%s: %s", db.source, row.title)}, row.rel, text); err != nil {
+				return summary, err
+			}
+		}
+		// データベースへのリンクの行き先として行の一覧を作る
+		index := db.dir + ".md"
+		if pages[index] {
+			continue
+		}
+		content := "# " + path.Base(db.dir) + "\n\n" + strings.Join(links, "\n") + "\n"
+		if err := write(ImportedNote{Source: db.source}, index, content); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
+
+// readNotionDatabase reads the CSV of a database, whose rows become notes
+// in a folder named after it.
+func readNotionDatabase(file exportFile, rel string, forced encoding.Encoding) (*notionDatabase, error) {
+	raw, err := file.read()
+	if err != nil {
+		return nil, err
+	}
+	text, _, err := decodeText(raw, forced)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	db := &notionDatabase{dir: strings.TrimSuffix(rel, path.Ext(rel))}
+	if len(records) == 0 {
+		return db, nil
+	}
+	db.columns = records[0]
+	used := map[string]int{}
+	for _, record := range records[1:] {
+		if len(record) == 0 {
+			continue
+		}
+		title := strings.TrimSpace(record[0])
+		if title == "" {
+			title = "Untitled"
+		}
+		name := safeFilename(title)
+		if name == "" {
+			name = "Untitled"
+		}
+		row := &notionRow{
+			db:          db,
+			title:       title,
+			key:         path.Join(db.dir, name+".md"),
+			rel:         path.Join(db.dir, name+".md"),
+			values:      record,
+			frontMatter: notionFrontMatter(db.columns, record),
+		}
+		// 同じタイトルの行も別のノートにする
+		if n := used[name]; n > 0 {
+			row.rel = path.Join(db.dir, fmt.Sprintf("%s-%d.md", name, n))
+		}
+		used[name]++
+		db.rows = append(db.rows, row)
+	}
+	return db, nil
+}
+
+// notionDateLayouts are the forms of dates in Notion's CSV files.
+var notionDateLayouts = []string{
+	"January 2, 2006 3:04 PM",
+	"January 2, 2006",
+	"2006/01/02 15:04",
+}
+
+// notionFrontMatter returns the frontmatter of a database row: a key for
+// each non-empty column other than the title. Tags become a list and a
+// created column the created date.
+func notionFrontMatter(columns, values []string) models.FrontMatter {
+	fm := models.FrontMatter{}
+	for i := 1; i < len(columns) && i < len(values); i++ {
+		key := strings.TrimSpace(columns[i])
+		value := strings.TrimSpace(values[i])
+		if key == "" || value == "" {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "tags", "tag", "タグ":
+			// マルチセレクトは ", " 区切り（値に空白を含むことがある）
+			var tags []string
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimPrefix(strings.TrimSpace(tag), "#"); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+			setFrontMatterList(fm, "tags", tags)
+			continue
+		case "created", "created time", "作成日時":
+			if t, ok := parseNotionDate(value); ok && fm.SetCreated(t) == nil {
+				continue
+			}
+		}
+		fm[key] = value
+	}
+	return fm
+}
+
+func parseNotionDate(s string) (time.Time, bool) {
+	for _, layout := range notionDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return parseAnyDate(s)
+}
+
+// matchNotionRow returns the row of a page among the rows with its title
+// that are not imported yet: the one with the most values listed under the
+// title of the page text, or the first. It returns nil when there is none.
+func matchNotionRow(candidates []*notionRow, text string) *notionRow {
+	var best *notionRow
+	bestScore := -1
+	for _, row := range candidates {
+		if row.imported {
+			continue
+		}
+		score := 0
+		for i := 1; i < len(row.db.columns) && i < len(row.values); i++ {
+			value := strings.TrimSpace(row.values[i])
+			if value != "" && strings.Contains(text, strings.TrimSpace(row.db.columns[i])+": "+value) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = row, score
+		}
+	}
+	return best
+}
+
+// mergeNotionRow adds the columns of row to the frontmatter of the page
+// text, and removes the "Column: value" lines Notion writes under its
+// title.
+func mergeNotionRow(text string, row *notionRow) (string, error) {
+	note, err := models.ParseNote(text)
+	if err != nil {
+		return text, err
+	}
+	if note.FrontMatter == nil {
+		note.FrontMatter = models.FrontMatter{}
+	}
+	for key, value := range row.frontMatter {
+		if _, ok := note.FrontMatter[key]; !ok {
+			note.FrontMatter[key] = value
+		}
+	}
+	note.Content = stripNotionProperties(note.Content, row.db.columns[1:])
+	return note.ToString()
+}
+
+// stripNotionProperties removes the lines of properties that follow the
+// title of a page.
+func stripNotionProperties(content string, columns []string) string {
+	lines := strings.Split(content, "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	if i == len(lines) || !strings.HasPrefix(lines[i], "# ") {
+		return content
+	}
+	i++
+	title := i
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	end := i
+	for end < len(lines) && isNotionProperty(lines[end], columns) {
+		end++
+	}
+	if end == i {
+		return content
+	}
+	return strings.Join(append(lines[:title:title], lines[end:]...), "\n")
+}
+
+func isNotionProperty(line string, columns []string) bool {
+	for _, column := range columns {
+		if column = strings.TrimSpace(column); column != "" && (strings.HasPrefix(line, column+": ") || line == column+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteNotionLinks rewrites the Markdown links in raw to the imported
+// paths of their targets, given by linkTarget.
+func rewriteNotionLinks(raw string, linkTarget func(string) string) string {
+	var rewrites []linkRewrite
+	for _, link := range models.ParseFileLinks(raw) {
+		if link.Kind != models.LinkMarkdown {
+			continue
+		}
+		target := linkTarget(link.Target)
+		if target == link.Target {
+			continue
+		}
+		resolved := ResolvedLink{Link: link}
+		rewrites = append(rewrites, linkRewrite{link: resolved, raw: rewriteMarkdownTarget(resolved, target)})
+	}
+	if len(rewrites) == 0 {
+		return raw
+	}
+	return applyLinkRewrites(raw, rewrites)
+}
+
+var htmlTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// convertHTMLPage converts an exported HTML page to Markdown, with the
+// links to other pages of the export rewritten by linkTarget. The page
+// title becomes the heading when the page has none.
+func convertHTMLPage(text string, linkTarget func(string) string) (string, error) {
+	local := func(href string) string {
+		u, err := url.Parse(href)
+		if err != nil || u.Scheme != "" || u.Host != "" {
+			return href
+		}
+		target, fragment, _ := strings.Cut(href, "#")
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		return linkTarget(target) + headingSuffix(fragment)
+	}
+	converter := &htmlConverter{
+		link: local,
+		media: func(tag string, attrs map[string]string) string {
+			if tag != "img" || attrs["src"] == "" {
+				return ""
+			}
+			return fmt.Sprintf("![%s](%s)", attrs["alt"], markdownTarget(local(attrs["src"])))
+		},
+	}
+	body, err := converter.convert(text)
+	if err != nil {
+		return "", err
+	}
+	if m := htmlTitlePattern.FindStringSubmatch(text); m != nil && !strings.HasPrefix(body, "# ") {
+		if title := strings.TrimSpace(html.UnescapeString(m[1])); title != "" {
+			body = "# " + title + "\n\n" + body
+		}
+	}
+	return body + "\n", nil
+}
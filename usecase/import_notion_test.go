@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testWikiID  = "0123456789abcdef0123456789abcdef"
+	testTasksID = "fedcba9876543210fedcba9876543210"
+	testRowID   = "00112233445566778899aabbccddeeff"
+)
+
+func writeTestNotionZip(t *testing.T, dir string) string {
+	t.Helper()
+	files := map[string]string{
+		"Wiki " + testWikiID + ".md":                                                        "# Wiki\n\n[Tasks](Wiki%20" + testWikiID + "/Tasks%20" + testTasksID + ".csv) と [手順](Wiki%20" + testWikiID + "/Guide%20" + testRowID + ".html#setup)\n\n![](Wiki%20" + testWikiID + "/diagram.png)\n",
+		"Wiki " + testWikiID + "/diagram.png":                                               "\x89PNG",
+		"Wiki " + testWikiID + "/Guide " + testRowID + ".html":                              `<html><head><title>Guide</title></head><body><h1 class="page-title">Guide</h1><p>See <a href="../Wiki%20` + testWikiID + `.md">the wiki</a>.</p></body></html>`,
+		"Wiki " + testWikiID + "/Tasks " + testTasksID + ".csv":                             "\ufeffName,Status\nWrite docs,Done\n",
+		"Wiki " + testWikiID + "/Tasks " + testTasksID + "_all.csv":                         "\ufeffName,Status,Tags,Created\nWrite docs,Done,\"docs, team wiki\",\"January 5, 2024 10:30 AM\"\nReview,,,\n",
+		"Wiki " + testWikiID + "/Tasks " + testTasksID + "/Write docs " + testRowID + ".md": "# Write docs\n\nStatus: Done\nTags: docs, team wiki\n\nDraft the guide.\n",
+	}
+	path := filepath.Join(dir, "Export.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStripNotionIDs(t *testing.T) {
+	tests := map[string]string{
+		"Wiki " + testWikiID + "/Page " + testRowID + ".md": "Wiki/Page.md",
+		"Tasks " + testTasksID + "_all.csv":                 "Tasks.csv",
+		testRowID + ".md":                                   "Untitled.md",
+		"image.png":                                         "image.png",
+		"v1.2 notes.md":                                     "v1.2 notes.md",
+	}
+	for in, want := range tests {
+		if got := stripNotionIDs(in); got != want {
+			t.Errorf("stripNotionIDs(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestImportNotionExport(t *testing.T) {
+	src := writeTestNotionZip(t, t.TempDir())
+	dst := filepath.Join(t.TempDir(), "inbox")
+
+	summary, err := ImportNotionExport(src, dst, ImportNotesOptions{}, nil)
+	if err != nil {
+		t.Fatalf("ImportNotionExport: %v", err)
+	}
+	if summary.Count(ImportCreated) != 6 || len(summary.Notes) != 6 {
+		t.Fatalf("unexpected summary: %+v", summary.Notes)
+	}
+
+	wiki := readTestNote(t, filepath.Join(dst, "Wiki.md"))
+	for _, want := range []string{"[Tasks](Wiki/Tasks.md)", "[手順](Wiki/Guide.md#setup)", "![](Wiki/diagram.png)"} {
+		if !strings.Contains(wiki, want) {
+			t.Errorf("Wiki.md should contain %q:\n%s", want, wiki)
+		}
+	}
+
+	guide := readTestNote(t, filepath.Join(dst, "Wiki", "Guide.md"))
+	if guide != "# Guide\n\nSee [the wiki](../Wiki.md).\n" {
+		t.Errorf("unexpected Guide.md:\n%s", guide)
+	}
+
+	row := readTestNote(t, filepath.Join(dst, "Wiki", "Tasks", "Write docs.md"))
+	for _, want := range []string{"Status: Done", "created: \"2024-01-05\"", "tags:\n    - docs\n    - team wiki\n", "# Write docs\n\nDraft the guide."} {
+		if !strings.Contains(row, want) {
+			t.Errorf("Write docs.md should contain %q:\n%s", want, row)
+		}
+	}
+
+	review := readTestNote(t, filepath.Join(dst, "Wiki", "Tasks", "Review.md"))
+	if strings.Contains(review, "Status") || !strings.Contains(review, "# Review") {
+		t.Errorf("unexpected Review.md:\n%s", review)
+	}
+
+	index := readTestNote(t, filepath.Join(dst, "Wiki", "Tasks.md"))
+	if index != "# Tasks\n\n- [Write docs](Tasks/Write%20docs.md)\n- [Review](Tasks/Review.md)\n" {
+		t.Errorf("unexpected Tasks.md:\n%s", index)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dst, "Wiki", "diagram.png")); err != nil || string(data) != "\x89PNG" {
+		t.Errorf("attachment should be copied: %q, %v", data, err)
+	}
+}
+
+func TestImportNotionExport_Directory(t *testing.T) {
+	src := t.TempDir()
+	writeTestNote(t, filepath.Join(src, "page.html"), "<html><head><title>Memo &amp; plan</title></head><body><p>text</p></body></html>")
+	dst := filepath.Join(t.TempDir(), "inbox")
+
+	summary, err := ImportNotionExport(src, dst, ImportNotesOptions{DryRun: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count(ImportCreated) != 1 || filepath.Base(summary.Notes[0].Dest) != "page.md" {
+		t.Errorf("unexpected summary: %+v", summary.Notes)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Error("dry run should not write files")
+	}
+}
+
+func TestImportNotionExport_SameTitles(t *testing.T) {
+	first := strings.Repeat("a", 32)
+	second := strings.Repeat("b", 32)
+	db := strings.Repeat("c", 32)
+	src := t.TempDir()
+	for name, content := range map[string]string{
+		"Meeting " + first + ".md":                                  "# Meeting\n\nfirst\n",
+		"Meeting " + second + ".md":                                 "# Meeting\n\nsecond\n",
+		"Index.md":                                                  "[1](Meeting%20" + first + ".md) [2](Meeting%20" + second + ".md) [db](Notes%20" + db + ".csv)\n",
+		"Notes " + db + ".csv":                                      "Name,Status\nSame,Open\nSame,Done\n",
+		"Notes " + db + "/Same " + first + ".md":                    "# Same\n\nStatus: Done\n\ndone page\n",
+		"Notes " + db + "/Same " + second + ".md":                   "# Same\n\nStatus: Open\n\nopen page\n",
+		"Notes " + db + "/Other " + strings.Repeat("d", 32) + ".md": "# Other\n",
+	} {
+		writeTestNote(t, filepath.Join(src, filepath.FromSlash(name)), content)
+	}
+	dst := t.TempDir()
+
+	summary, err := ImportNotionExport(src, dst, ImportNotesOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count(ImportCreated) != len(summary.Notes) {
+		t.Fatalf("every page should be created: %+v", summary.Notes)
+	}
+
+	if got := readTestNote(t, filepath.Join(dst, "Meeting aaaaaaaa.md")); !strings.Contains(got, "first") {
+		t.Errorf("unexpected Meeting aaaaaaaa.md:\n%s", got)
+	}
+	if got := readTestNote(t, filepath.Join(dst, "Meeting bbbbbbbb.md")); !strings.Contains(got, "second") {
+		t.Errorf("unexpected Meeting bbbbbbbb.md:\n%s", got)
+	}
+	index := readTestNote(t, filepath.Join(dst, "Index.md"))
+	if index != "[1](Meeting%20aaaaaaaa.md) [2](Meeting%20bbbbbbbb.md) [db](Notes.md)\n" {
+		t.Errorf("unexpected Index.md:\n%s", index)
+	}
+
+	// 同じタイトルの行は、ページのプロパティで対応するページを見分ける
+	for name, want := range map[string]string{"Same aaaaaaaa.md": "Status: Done", "Same bbbbbbbb.md": "Status: Open"} {
+		if got := readTestNote(t, filepath.Join(dst, "Notes", name)); !strings.Contains(got, want) {
+			t.Errorf("%s should contain %q:\n%s", name, want, got)
+		}
+	}
+	if got := readTestNote(t, filepath.Join(dst, "Notes", "Other.md")); got != "# Other\n" {
+		t.Errorf("unique names should lose their IDs:\n%s", got)
+	}
+	list := readTestNote(t, filepath.Join(dst, "Notes.md"))
+	if list != "# Notes\n\n- [Same](Notes/Same%20bbbbbbbb.md)\n- [Same](Notes/Same%20aaaaaaaa.md)\n" {
+		t.Errorf("unexpected Notes.md:\n%s", list)
+	}
+}
+
+func TestImportNotionExport_CreatedSkipsAttachments(t *testing.T) {
+	src := t.TempDir()
+	writeTestNote(t, filepath.Join(src, "Page "+testWikiID+".md"), "# Page\n\n![](Page%20"+testWikiID+"/img.png)\n")
+	writeTestNote(t, filepath.Join(src, "Page "+testWikiID, "img.png"), "\x89PNG")
+	dst := filepath.Join(t.TempDir(), "inbox")
+
+	summary, err := ImportNotionExport(src, dst, ImportNotesOptions{Created: []CreatedSource{CreatedFromFilename, CreatedFromMtime}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, note := range summary.Notes {
+		if note.Attachment == (note.Created != "") {
+			t.Errorf("only the page should get created: %+v", note)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dst, "Page", "img.png")); err != nil || string(data) != "\x89PNG" {
+		t.Errorf("attachment should be copied as it is: %q, %v", data, err)
+	}
+	if page := readTestNote(t, filepath.Join(dst, "Page.md")); !strings.HasPrefix(page, "---\ncreated:") {
+		t.Errorf("the page should get created:\n%s", page)
+	}
+}
@@ -0,0 +1,322 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// ObsidianDailyNotes is the daily notes setting of an Obsidian vault, read
+// from .obsidian/daily-notes.json.
+type ObsidianDailyNotes struct {
+	Folder string `json:"folder"` // vaultからのディレクトリ
+	Format string `json:"format"` // moment.jsの書式（既定は YYYY-MM-DD）
+}
+
+// LoadObsidianDailyNotes reads the daily notes setting of vault. A vault
+// without the setting uses Obsidian's defaults.
+func LoadObsidianDailyNotes(vault string) (ObsidianDailyNotes, error) {
+	settings := ObsidianDailyNotes{}
+	data, err := os.ReadFile(filepath.Join(vault, ".obsidian", "daily-notes.json"))
+	if err == nil {
+		if err := json.Unmarshal(data, &settings); err != nil {
+			return settings, fmt.Errorf("failed to read daily-notes.json: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return settings, err
+	}
+	settings.Folder = strings.Trim(filepath.ToSlash(settings.Folder), "/")
+	if settings.Format == "" {
+		settings.Format = "YYYY-MM-DD"
+	}
+	return settings, nil
+}
+
+// Date returns the date of the note at rel, a path relative to the vault,
+// when it is a daily note.
+func (settings ObsidianDailyNotes) Date(rel string) (time.Time, bool) {
+	rel = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+	if settings.Folder != "" {
+		if !strings.HasPrefix(rel, settings.Folder+"/") {
+			return time.Time{}, false
+		}
+		rel = strings.TrimPrefix(rel, settings.Folder+"/")
+	}
+	layout, err := momentLayout(settings.Format)
+	if err != nil {
+		return time.Time{}, false
+	}
+	// 書式に / が含まれていればサブディレクトリごと、なければファイル名で比べる
+	name := rel
+	if !strings.Contains(layout, "/") {
+		name = filepath.Base(rel)
+	}
+	t, err := time.ParseInLocation(layout, name, time.Local)
+	if err != nil || t.Format(layout) != name {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// momentTokens are the moment.js tokens supported in daily note formats,
+// longest first.
+var momentTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"}, {"YY", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"DD", "02"}, {"D", "2"},
+	{"dddd", "Monday"}, {"ddd", "Mon"},
+}
+
+// momentLayout converts a moment.js date format to a Go time layout.
+// Text in [brackets] is kept as it is.
+func momentLayout(format string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] == '[' {
+			end := strings.IndexByte(format[i:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unclosed [ in date format %q", format)
+			}
+			b.WriteString(format[i+1 : i+end])
+			i += end + 1
+			continue
+		}
+		matched := false
+		for _, token := range momentTokens {
+			if strings.HasPrefix(format[i:], token.token) {
+				b.WriteString(token.layout)
+				i += len(token.token)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if strings.ContainsRune("HhmsAaWwgGEeQXxZz", rune(format[i])) {
+			return "", fmt.Errorf("unsupported token %q in date format %q", format[i], format)
+		}
+		b.WriteByte(format[i])
+		i++
+	}
+	return b.String(), nil
+}
+
+// obsidianFile is a file of the vault to import.
+type obsidianFile struct {
+	path  string
+	rel   string
+	dest  string
+	note  bool
+	daily time.Time
+}
+
+// ImportObsidianVault imports the notes and attachments of an Obsidian
+// vault into dst, keeping the folders. Daily notes are moved to the daily
+// note of their date in krapp and wikilinks to them are rewritten; other
+// wikilinks are left as they are. Aliases and tags are converted to lists
+// in the frontmatter.
+func ImportObsidianVault(cfg Config, vault, dst string, opts ImportNotesOptions, tx *Transaction) (*ImportSummary, error) {
+	daily, err := LoadObsidianDailyNotes(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []obsidianFile
+	err = filepath.WalkDir(vault, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// .obsidian や .trash は取り込まない
+		if strings.HasPrefix(d.Name(), ".") && path != vault {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(vault, path)
+		if err != nil {
+			return err
+		}
+		file := obsidianFile{path: path, rel: rel, dest: filepath.Join(dst, rel)}
+		file.note = strings.EqualFold(filepath.Ext(path), ".md")
+		if file.note {
+			if date, ok := daily.Date(rel); ok {
+				dest, err := DailyNotePath(cfg, date)
+				if err != nil {
+					return err
+				}
+				file.dest = dest
+				file.daily = date
+			}
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// デイリーノートへのリンクの書き換え先（ファイル名とvaultからのパスの両方で引く）
+	renamed := map[string]string{}
+	for _, file := range files {
+		if file.daily.IsZero() {
+			continue
+		}
+		oldName := strings.TrimSuffix(filepath.Base(file.rel), filepath.Ext(file.rel))
+		newName := strings.TrimSuffix(filepath.Base(file.dest), filepath.Ext(file.dest))
+		if oldName != newName {
+			renamed[strings.ToLower(oldName)] = newName
+		}
+		// パスで書かれたリンクは新しいパスにする
+		if rel, err := filepath.Rel(cfg.GetBaseDir(), file.dest); err == nil {
+			oldPath := filepath.ToSlash(strings.TrimSuffix(file.rel, filepath.Ext(file.rel)))
+			if strings.Contains(oldPath, "/") {
+				renamed[strings.ToLower(oldPath)] = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+			}
+		}
+	}
+
+	summary := &ImportSummary{Notes: []ImportedNote{}}
+	writer := newImportWriter(opts, tx)
+	for _, file := range files {
+		result := ImportedNote{Source: file.path, Attachment: !file.note}
+		raw, err := os.ReadFile(file.path)
+		if err != nil {
+			result.Status = ImportFailed
+			result.Message = err.Error()
+			summary.Notes = append(summary.Notes, result)
+			continue
+		}
+		content := string(raw)
+		if file.note {
+			text, encName, err := decodeText(raw, nil)
+			if err != nil {
+				result.Status = ImportFailed
+				result.Message = err.Error()
+				summary.Notes = append(summary.Notes, result)
+				continue
+			}
+			result.Encoding = encName
+			content, result.Created, err = convertObsidianNote(text, file, renamed, opts.Created)
+			if err != nil {
+				result.Message = err.Error()
+			}
+		}
+
+		result.Dest, result.Status, err = writer.write(file.dest, content)
+		if err != nil {
+			return summary, err
+		}
+		if result.Status == ImportSkipped {
+			result.Created = ""
+		}
+		summary.Notes = append(summary.Notes, result)
+	}
+	return summary, nil
+}
+
+// convertObsidianNote converts the frontmatter of an Obsidian note to
+// krapp's and rewrites the wikilinks to renamed daily notes. Notes whose
+// frontmatter cannot be read are returned as they are.
+func convertObsidianNote(text string, file obsidianFile, renamed map[string]string, sources []CreatedSource) (string, CreatedSource, error) {
+	text = rewriteRenamedWikiLinks(text, renamed)
+	note, err := models.ParseNote(text)
+	if err != nil {
+		return text, "", err
+	}
+	if note.FrontMatter == nil {
+		note.FrontMatter = models.FrontMatter{}
+	}
+	fm := note.FrontMatter
+
+	// tags: "a, #b" や tag: a を tags: [a, b] にする
+	if _, ok := fm["tags"]; ok || fm["tag"] != nil {
+		tags := fm.Tags()
+		if tag, ok := fm["tag"]; ok {
+			tags = append(tags, models.FrontMatter{"tags": tag}.Tags()...)
+			delete(fm, "tag")
+		}
+		setFrontMatterList(fm, "tags", tags)
+	}
+	if _, ok := fm["aliases"]; ok || fm["alias"] != nil {
+		aliases := models.FrontMatter{"aliases": fm["aliases"]}.Aliases()
+		if alias, ok := fm["alias"]; ok {
+			aliases = append(aliases, models.FrontMatter{"aliases": alias}.Aliases()...)
+			delete(fm, "alias")
+		}
+		setFrontMatterList(fm, "aliases", aliases)
+	}
+
+	var source CreatedSource
+	if !hasCreated(fm) {
+		if !file.daily.IsZero() {
+			if err := fm.SetCreated(file.daily); err != nil {
+				return text, "", err
+			}
+			source = CreatedFromFilename
+		} else if len(sources) > 0 {
+			note.FilePath = file.path
+			if t, from, err := InferCreated(note, sources); err == nil {
+				if err := fm.SetCreated(t); err != nil {
+					return text, "", err
+				}
+				source = from
+			}
+		}
+	}
+
+	out, err := note.ToString()
+	if err != nil {
+		return text, "", err
+	}
+	return out, source, nil
+}
+
+// setFrontMatterList sets key to items unless it already has them.
+func setFrontMatterList(fm models.FrontMatter, key string, items []string) {
+	list := make([]any, len(items))
+	for i, item := range items {
+		list[i] = item
+	}
+	if current, ok := fm[key].([]any); ok && reflect.DeepEqual(current, list) {
+		return
+	}
+	fm[key] = list
+}
+
+// rewriteRenamedWikiLinks rewrites the wikilinks in raw whose target is a
+// key of renamed, keeping their headings and aliases.
+func rewriteRenamedWikiLinks(raw string, renamed map[string]string) string {
+	if len(renamed) == 0 {
+		return raw
+	}
+	var rewrites []linkRewrite
+	for _, link := range models.ParseFileLinks(raw) {
+		if link.Kind != models.LinkWiki {
+			continue
+		}
+		target := strings.TrimSuffix(filepath.ToSlash(link.Target), ".md")
+		newTarget, ok := renamed[strings.ToLower(target)]
+		if !ok {
+			continue
+		}
+		if newRaw := rewriteWikiTarget(link.Raw, newTarget); newRaw != link.Raw {
+			rewrites = append(rewrites, linkRewrite{link: ResolvedLink{Link: link}, raw: newRaw})
+		}
+	}
+	if len(rewrites) == 0 {
+		return raw
+	}
+	return applyLinkRewrites(raw, rewrites)
+}
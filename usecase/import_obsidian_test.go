@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMomentLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"YYYY-MM-DD", "2006-01-02"},
+		{"YYYY/MM/YYYY-MM-DD", "2006/01/2006-01-02"},
+		{"YYYY年M月D日 dddd", "2006年1月2日 Monday"},
+		{"[Daily] YYYY.MM.DD", "Daily 2006.01.02"},
+	}
+	for _, tt := range tests {
+		got, err := momentLayout(tt.format)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.format, got, err, tt.want)
+		}
+	}
+	if _, err := momentLayout("YYYY-MM-DD HH:mm"); err == nil {
+		t.Error("expected an error for time tokens")
+	}
+}
+
+func TestObsidianDailyNotes_Date(t *testing.T) {
+	settings := ObsidianDailyNotes{Folder: "Daily", Format: "YYYY年MM月DD日"}
+	if date, ok := settings.Date("Daily/2024年01月05日.md"); !ok || !date.Equal(time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("got %v, %v", date, ok)
+	}
+	for _, rel := range []string{"2024年01月05日.md", "Daily/memo.md", "Daily/2024年13月05日.md"} {
+		if _, ok := settings.Date(rel); ok {
+			t.Errorf("%s should not be a daily note", rel)
+		}
+	}
+
+	nested := ObsidianDailyNotes{Format: "YYYY/MM/YYYY-MM-DD"}
+	if _, ok := nested.Date("2024/01/2024-01-05.md"); !ok {
+		t.Error("format with folders should match the path")
+	}
+}
+
+func setupObsidianVault(t *testing.T) string {
+	t.Helper()
+	vault := t.TempDir()
+	writeTestNote(t, filepath.Join(vault, ".obsidian", "daily-notes.json"), `{"folder": "Daily", "format": "YYYY年MM月DD日"}`)
+	writeTestNote(t, filepath.Join(vault, ".obsidian", "app.json"), `{}`)
+	writeTestNote(t, filepath.Join(vault, ".trash", "old.md"), "deleted")
+	writeTestNote(t, filepath.Join(vault, "Daily", "2024年01月05日.md"), "今日のメモ ![[photo.png]]\n")
+	writeTestNote(t, filepath.Join(vault, "Projects", "krapp.md"),
+		"---\naliases: クラップ, krapp-go\ntags: \"#project, go\"\n---\n[[2024年01月05日|金曜]]と[[Daily/2024年01月05日#午後]]、[[other]]\n")
+	writeTestNote(t, filepath.Join(vault, "attachments", "photo.png"), "\x89PNG")
+	return vault
+}
+
+func TestImportObsidianVault(t *testing.T) {
+	vault := setupObsidianVault(t)
+	baseDir := t.TempDir()
+	cfg := &testDailyConfig{baseDir: baseDir, dailyNoteDir: "daily"}
+	dst := filepath.Join(baseDir, "inbox")
+
+	summary, err := ImportObsidianVault(cfg, vault, dst, ImportNotesOptions{}, nil)
+	if err != nil {
+		t.Fatalf("ImportObsidianVault: %v", err)
+	}
+	if len(summary.Notes) != 3 || summary.Count(ImportCreated) != 3 {
+		t.Fatalf("unexpected summary: %+v", summary.Notes)
+	}
+
+	daily := readTestNote(t, filepath.Join(baseDir, "daily", "2024", "01", "2024-01-05.md"))
+	if !strings.Contains(daily, "created: \"2024-01-05\"") || !strings.Contains(daily, "![[photo.png]]") {
+		t.Errorf("unexpected daily note:\n%s", daily)
+	}
+
+	note := readTestNote(t, filepath.Join(dst, "Projects", "krapp.md"))
+	for _, want := range []string{
+		"aliases:\n  - クラップ\n  - krapp-go\n",
+		"tags:\n  - project\n  - go\n",
+		"[[2024-01-05|金曜]]",
+		"[[daily/2024/01/2024-01-05#午後]]",
+		"[[other]]",
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("note should contain %q:\n%s", want, note)
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dst, "attachments", "photo.png")); err != nil || string(data) != "\x89PNG" {
+		t.Errorf("attachment should be copied: %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, ".obsidian")); !os.IsNotExist(err) {
+		t.Error(".obsidian should not be imported")
+	}
+}
+
+func TestImportObsidianVault_DryRun(t *testing.T) {
+	vault := setupObsidianVault(t)
+	baseDir := t.TempDir()
+	cfg := &testDailyConfig{baseDir: baseDir, dailyNoteDir: "daily"}
+
+	summary, err := ImportObsidianVault(cfg, vault, filepath.Join(baseDir, "inbox"), ImportNotesOptions{DryRun: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Count(ImportCreated) != 3 {
+		t.Errorf("unexpected summary: %+v", summary.Notes)
+	}
+	if entries, _ := os.ReadDir(baseDir); len(entries) != 0 {
+		t.Errorf("dry run should not write files, found %d", len(entries))
+	}
+}
@@ -0,0 +1,326 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 操作ジャーナルに記録する操作の種類
+const (
+	JournalCreate     = "create"
+	JournalMove       = "move"
+	JournalOverwrite  = "overwrite"
+	JournalCloseIssue = "close_issue"
+)
+
+// JournalOp is a single recorded operation.
+type JournalOp struct {
+	Type   string `json:"type"`
+	Path   string `json:"path,omitempty"`   // 作成・上書きしたファイル、または移動先
+	From   string `json:"from,omitempty"`   // 移動元
+	Hash   string `json:"hash,omitempty"`   // 作成直後のファイルのSHA-256
+	Backup string `json:"backup,omitempty"` // 上書き前の内容を保存したファイル
+	Repo   string `json:"repo,omitempty"`
+	Issue  int    `json:"issue,omitempty"`
+}
+
+// Transaction groups the operations done by one krapp command so that they
+// can be reverted together. A nil *Transaction records nothing, so callers
+// that do not need undo support can pass nil.
+type Transaction struct {
+	ID        string      `json:"id"`
+	Command   string      `json:"command"`
+	CreatedAt time.Time   `json:"created_at"`
+	Ops       []JournalOp `json:"ops"`
+	Undone    bool        `json:"undone"`
+
+	journal *Journal
+}
+
+// Journal stores transactions as JSON files in Dir.
+type Journal struct {
+	Dir string
+}
+
+// NewJournal returns a journal stored in dir.
+func NewJournal(dir string) *Journal {
+	return &Journal{Dir: dir}
+}
+
+// Begin starts a new transaction for the named command. Nothing is written
+// until the first operation is recorded.
+func (j *Journal) Begin(command string) *Transaction {
+	now := time.Now()
+	suffix := make([]byte, 2)
+	rand.Read(suffix)
+	return &Transaction{
+		ID:        now.Format("20060102-150405.000") + "-" + hex.EncodeToString(suffix),
+		Command:   command,
+		CreatedAt: now,
+		Ops:       []JournalOp{},
+		journal:   j,
+	}
+}
+
+// RecordCreate records that path was created. Call it after writing the file.
+func (tx *Transaction) RecordCreate(path string) error {
+	if tx == nil {
+		return nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	hash, err := fileHash(abs)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return tx.record(JournalOp{Type: JournalCreate, Path: abs, Hash: hash})
+}
+
+// RecordMove records that a file was moved from one path to another.
+func (tx *Transaction) RecordMove(from, to string) error {
+	if tx == nil {
+		return nil
+	}
+	absFrom, err := filepath.Abs(from)
+	if err != nil {
+		return err
+	}
+	absTo, err := filepath.Abs(to)
+	if err != nil {
+		return err
+	}
+	return tx.record(JournalOp{Type: JournalMove, From: absFrom, Path: absTo})
+}
+
+// RecordOverwrite saves the current content of path so that it can be
+// restored later. Call it before overwriting the file.
+func (tx *Transaction) RecordOverwrite(path string) error {
+	if tx == nil {
+		return nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	backupDir := filepath.Join(tx.journal.Dir, tx.ID)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	backup := filepath.Join(backupDir, fmt.Sprintf("%d.bak", len(tx.Ops)))
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return tx.record(JournalOp{Type: JournalOverwrite, Path: abs, Backup: backup})
+}
+
+// RecordIssueClose records that a GitHub issue was closed.
+func (tx *Transaction) RecordIssueClose(repo string, issueNumber int) error {
+	if tx == nil {
+		return nil
+	}
+	return tx.record(JournalOp{Type: JournalCloseIssue, Repo: repo, Issue: issueNumber})
+}
+
+// record appends op and persists the transaction immediately so that a
+// crash halfway through a command still leaves an undoable journal.
+func (tx *Transaction) record(op JournalOp) error {
+	tx.Ops = append(tx.Ops, op)
+	return tx.save()
+}
+
+func (tx *Transaction) save() error {
+	if err := os.MkdirAll(tx.journal.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	data, err := json.MarshalIndent(tx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction: %w", err)
+	}
+	path := tx.journal.transactionPath(tx.ID)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) transactionPath(id string) string {
+	return filepath.Join(j.Dir, id+".json")
+}
+
+// List returns every recorded transaction, oldest first.
+func (j *Journal) List() ([]*Transaction, error) {
+	entries, err := os.ReadDir(j.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []*Transaction{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	transactions := []*Transaction{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		tx, err := j.Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, tx)
+	}
+	sort.Slice(transactions, func(a, b int) bool {
+		return transactions[a].ID < transactions[b].ID
+	})
+	return transactions, nil
+}
+
+// Load reads the transaction with the given ID.
+func (j *Journal) Load(id string) (*Transaction, error) {
+	data, err := os.ReadFile(j.transactionPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s not found: %w", id, err)
+	}
+	var tx Transaction
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction %s: %w", id, err)
+	}
+	tx.journal = j
+	return &tx, nil
+}
+
+// Last returns the most recent transaction that has not been undone.
+func (j *Journal) Last() (*Transaction, error) {
+	transactions, err := j.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(transactions) - 1; i >= 0; i-- {
+		if !transactions[i].Undone {
+			return transactions[i], nil
+		}
+	}
+	return nil, errors.New("no transaction to undo")
+}
+
+// Undo reverts the operations of tx in reverse order. Every file operation
+// is checked first so that a conflict (e.g. a created file edited since)
+// aborts before anything is changed. client is only needed for issue closes.
+func (j *Journal) Undo(tx *Transaction, client GitHubClient) error {
+	if tx.Undone {
+		return fmt.Errorf("transaction %s is already undone", tx.ID)
+	}
+	// 逆順に適用したときのファイルの有無を追跡しながら事前チェックする
+	exists := map[string]bool{}
+	for i := len(tx.Ops) - 1; i >= 0; i-- {
+		if err := checkUndo(tx.Ops[i], client, exists); err != nil {
+			return err
+		}
+	}
+	for i := len(tx.Ops) - 1; i >= 0; i-- {
+		if err := undoOp(tx.Ops[i], client); err != nil {
+			return fmt.Errorf("failed to undo %s: %w", tx.Ops[i].Type, err)
+		}
+	}
+	tx.Undone = true
+	tx.journal = j
+	return tx.save()
+}
+
+// checkUndo reports conflicts that would make undoing op unsafe. exists
+// holds the simulated state of paths touched by operations undone before op.
+func checkUndo(op JournalOp, client GitHubClient, exists map[string]bool) error {
+	pathExists := func(path string) bool {
+		if v, ok := exists[path]; ok {
+			return v
+		}
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	switch op.Type {
+	case JournalCreate:
+		if _, simulated := exists[op.Path]; !simulated && pathExists(op.Path) {
+			hash, err := fileHash(op.Path)
+			if err != nil {
+				return err
+			}
+			if hash != op.Hash {
+				return fmt.Errorf("%s was modified after it was created", op.Path)
+			}
+		}
+		exists[op.Path] = false
+	case JournalMove:
+		if !pathExists(op.Path) {
+			return fmt.Errorf("moved file %s no longer exists", op.Path)
+		}
+		if pathExists(op.From) {
+			return fmt.Errorf("original location %s is already taken", op.From)
+		}
+		exists[op.Path] = false
+		exists[op.From] = true
+	case JournalOverwrite:
+		if _, err := os.Stat(op.Backup); err != nil {
+			return fmt.Errorf("backup of %s is missing", op.Path)
+		}
+		exists[op.Path] = true
+	case JournalCloseIssue:
+		if client == nil {
+			return fmt.Errorf("GitHub client is required to reopen issue #%d", op.Issue)
+		}
+	default:
+		return fmt.Errorf("unknown operation: %s", op.Type)
+	}
+	return nil
+}
+
+func undoOp(op JournalOp, client GitHubClient) error {
+	switch op.Type {
+	case JournalCreate:
+		if err := os.Remove(op.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	case JournalMove:
+		if err := os.MkdirAll(filepath.Dir(op.From), 0755); err != nil {
+			return err
+		}
+		return os.Rename(op.Path, op.From)
+	case JournalOverwrite:
+		data, err := os.ReadFile(op.Backup)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(op.Path, data, 0644)
+	case JournalCloseIssue:
+		return client.ReopenIssue(op.Repo, op.Issue)
+	}
+	return nil
+}
+
+func fileHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournal_UndoFileOperations(t *testing.T) {
+	dir := t.TempDir()
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	tx := journal.Begin("test")
+
+	created := filepath.Join(dir, "created.md")
+	writeTestNote(t, created, "new")
+	if err := tx.RecordCreate(created); err != nil {
+		t.Fatalf("RecordCreate: %v", err)
+	}
+
+	overwritten := filepath.Join(dir, "overwritten.md")
+	writeTestNote(t, overwritten, "before")
+	if err := tx.RecordOverwrite(overwritten); err != nil {
+		t.Fatalf("RecordOverwrite: %v", err)
+	}
+	writeTestNote(t, overwritten, "after")
+
+	from := filepath.Join(dir, "a.md")
+	to := filepath.Join(dir, "sub", "b.md")
+	writeTestNote(t, from, "moved")
+	os.MkdirAll(filepath.Dir(to), 0755)
+	os.Rename(from, to)
+	if err := tx.RecordMove(from, to); err != nil {
+		t.Fatalf("RecordMove: %v", err)
+	}
+
+	last, err := journal.Last()
+	if err != nil {
+		t.Fatalf("Last: %v", err)
+	}
+	if last.ID != tx.ID || len(last.Ops) != 3 {
+		t.Fatalf("unexpected last transaction: %+v", last)
+	}
+
+	if err := journal.Undo(last, nil); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("created file should be removed")
+	}
+	if data, _ := os.ReadFile(overwritten); string(data) != "before" {
+		t.Errorf("overwritten file should be restored, got %q", data)
+	}
+	if _, err := os.Stat(from); err != nil {
+		t.Errorf("moved file should be back at %s", from)
+	}
+
+	if _, err := journal.Last(); err == nil {
+		t.Errorf("undone transaction should not be returned by Last")
+	}
+	reloaded, err := journal.Load(tx.ID)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := journal.Undo(reloaded, nil); err == nil {
+		t.Errorf("undoing twice should fail")
+	}
+}
+
+func TestJournal_UndoChainedMoves(t *testing.T) {
+	dir := t.TempDir()
+	journal := NewJournal(t.TempDir())
+	tx := journal.Begin("test")
+
+	a, b, c := filepath.Join(dir, "a.md"), filepath.Join(dir, "b.md"), filepath.Join(dir, "c.md")
+	writeTestNote(t, a, "x")
+	os.Rename(a, b)
+	tx.RecordMove(a, b)
+	os.Rename(b, c)
+	tx.RecordMove(b, c)
+
+	if err := journal.Undo(tx, nil); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if _, err := os.Stat(a); err != nil {
+		t.Errorf("file should be back at %s", a)
+	}
+}
+
+func TestJournal_UndoConflict(t *testing.T) {
+	dir := t.TempDir()
+	journal := NewJournal(t.TempDir())
+	tx := journal.Begin("test")
+
+	created := filepath.Join(dir, "created.md")
+	writeTestNote(t, created, "new")
+	tx.RecordCreate(created)
+	writeTestNote(t, created, "edited by user")
+
+	if err := journal.Undo(tx, nil); err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if data, _ := os.ReadFile(created); string(data) != "edited by user" {
+		t.Errorf("conflicting undo must not touch files")
+	}
+}
+
+func TestJournal_UndoIssueClose(t *testing.T) {
+	journal := NewJournal(t.TempDir())
+	tx := journal.Begin("import-issues")
+	tx.RecordIssueClose("owner/repo", 42)
+
+	if err := journal.Undo(tx, nil); err == nil {
+		t.Error("expected error without GitHub client")
+	}
+	client := &MockGitHubClient{}
+	if err := journal.Undo(tx, client); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if len(client.ReopenedIssues) != 1 || client.ReopenedIssues[0] != 42 {
+		t.Errorf("expected issue 42 to be reopened, got %v", client.ReopenedIssues)
+	}
+}
+
+func TestTransaction_Nil(t *testing.T) {
+	var tx *Transaction
+	if err := tx.RecordCreate("missing.md"); err != nil {
+		t.Errorf("nil transaction should record nothing: %v", err)
+	}
+	if err := tx.RecordIssueClose("owner/repo", 1); err != nil {
+		t.Errorf("nil transaction should record nothing: %v", err)
+	}
+}
+
+func TestImportNotes_RecordsJournal(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	writeTestNote(t, filepath.Join(src, "new.txt"), "new")
+	writeTestNote(t, filepath.Join(src, "existing.md"), "imported")
+	writeTestNote(t, filepath.Join(dst, "existing.md"), "original")
+
+	journal := NewJournal(t.TempDir())
+	tx := journal.Begin("import-notes")
+	if _, err := ImportNotes(src, dst, ImportNotesOptions{Collision: CollisionOverwrite}, tx); err != nil {
+		t.Fatalf("ImportNotes: %v", err)
+	}
+	if len(tx.Ops) != 2 {
+		t.Fatalf("expected 2 ops, got %d", len(tx.Ops))
+	}
+	if err := journal.Undo(tx, nil); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "new.md")); !os.IsNotExist(err) {
+		t.Errorf("imported file should be removed")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "existing.md")); string(data) != "original" {
+		t.Errorf("overwritten file should be restored, got %q", data)
+	}
+}
+
+func TestImportGitHubIssues_RecordsJournal(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &testConfig{baseDir: tempDir}
+	os.MkdirAll(filepath.Join(tempDir, "inbox"), 0755)
+	client := &MockGitHubClient{
+		Issues: []Issue{{Number: 7, Title: "Journal", State: "open"}},
+	}
+
+	journal := NewJournal(t.TempDir())
+	tx := journal.Begin("import-issues")
+	if err := ImportGitHubIssues(cfg, client, ImportOptions{Repo: "owner/repo", Journal: tx}); err != nil {
+		t.Fatalf("ImportGitHubIssues: %v", err)
+	}
+	if len(tx.Ops) != 2 || tx.Ops[0].Type != JournalCreate || tx.Ops[1].Type != JournalCloseIssue {
+		t.Fatalf("unexpected ops: %+v", tx.Ops)
+	}
+
+	if err := journal.Undo(tx, client); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	files, _ := os.ReadDir(filepath.Join(tempDir, "inbox"))
+	if len(files) != 0 {
+		t.Errorf("imported note should be removed, found %d files", len(files))
+	}
+	if len(client.ReopenedIssues) != 1 {
+		t.Errorf("closed issue should be reopened")
+	}
+}
+
+func TestCreateNotes_RecordJournal(t *testing.T) {
+	baseDir := t.TempDir()
+	now := time.Date(2025, 6, 1, 9, 0, 0, 0, time.Local)
+	journal := NewJournal(t.TempDir())
+	tx := journal.Begin("create")
+
+	inbox, err := CreateInboxNoteWithTemplate(&testConfig{baseDir: baseDir}, now, "memo", "", tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily, err := CreateDailyNoteWithTemplate(&testDailyConfig{baseDir: baseDir, dailyNoteDir: "daily"}, now, "", tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// すでにあるノートは作っていないので記録しない
+	if _, err := CreateDailyNoteWithTemplate(&testDailyConfig{baseDir: baseDir, dailyNoteDir: "daily"}, now, "", tx); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Ops) != 2 {
+		t.Fatalf("expected 2 ops, got %+v", tx.Ops)
+	}
+
+	if err := journal.Undo(tx, nil); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	for _, path := range []string{inbox, daily} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", path)
+		}
+	}
+}
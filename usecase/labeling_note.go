@@ -1,16 +1,201 @@
 package usecase
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/ishida722/krapp-go/models"
 )
 
+const (
+	// MaxPreviewLines はプレビューに表示する本文の最大行数
+	MaxPreviewLines = 20
+	// MaxPreviewLineLength はプレビューの1行あたりの最大文字数
+	MaxPreviewLineLength = 100
+)
+
+// ErrLabelingDone is returned by NoteLabeler.Next when every note has been visited.
+var ErrLabelingDone = errors.New("no more notes to label")
+
+// ラベリング時の各ノートに対する操作
+const (
+	LabelingActionLabeled = "labeled"
+	LabelingActionSkipped = "skipped"
+)
+
+// LabelOption maps an input key to a label name.
+type LabelOption struct {
+	Key  string
+	Name string
+}
+
+// LabelingStats summarizes a labeling session.
+type LabelingStats struct {
+	Total     int
+	Labeled   int
+	Skipped   int
+	Remaining int
+	Failed    int            // 読み込みに失敗したファイル
+	ByLabel   map[string]int // ラベルごとの件数
+}
+
+// NoteLabeler walks through unlabeled notes one at a time and writes the
+// chosen label into their frontmatter.
 type NoteLabeler struct {
 	Notes        []*models.Note
 	Current      *models.Note
 	CurrentIndex int
+	Labels       []LabelOption
+	Failures     map[string]error
+	Journal      *Transaction // 上書きしたノートの記録先（nil可）
+
+	actions map[string]string // ファイルパス → 操作
+	labels  map[string]string // ファイルパス → 付けたラベル
+}
+
+// NewNoteLabeler collects the notes in dir that have no label yet.
+// Subdirectories are included when recursive is true.
+func NewNoteLabeler(dir string, recursive bool, labels []LabelOption) (*NoteLabeler, error) {
+	if len(labels) == 0 {
+		return nil, errors.New("no labels configured")
+	}
+	index, err := LoadNotesInDir(dir, recursive)
+	if err != nil {
+		return nil, err
+	}
+	labeler := &NoteLabeler{
+		Labels:   labels,
+		Failures: index.Failures,
+	}
+	for _, note := range index.Notes {
+		if label, err := note.FrontMatter.Label(); err == nil && label != "" {
+			continue
+		}
+		labeler.Notes = append(labeler.Notes, note)
+	}
+	return labeler, nil
+}
+
+// Next advances to the next note. It returns ErrLabelingDone after the last one.
+func (labeler *NoteLabeler) Next() error {
+	if labeler.Current != nil {
+		labeler.CurrentIndex++
+	}
+	if labeler.CurrentIndex >= len(labeler.Notes) {
+		labeler.Current = nil
+		labeler.CurrentIndex = len(labeler.Notes)
+		return ErrLabelingDone
+	}
+	labeler.Current = labeler.Notes[labeler.CurrentIndex]
+	return nil
 }
 
-func (self *NoteLabeler) Next() error {
-	self.Current = self.Notes[0]
+// Back returns to the previous note so that its label can be changed.
+func (labeler *NoteLabeler) Back() error {
+	if labeler.CurrentIndex == 0 {
+		return errors.New("already at the first note")
+	}
+	labeler.CurrentIndex--
+	labeler.Current = labeler.Notes[labeler.CurrentIndex]
 	return nil
 }
+
+// LabelFor returns the label name bound to key.
+func (labeler *NoteLabeler) LabelFor(key string) (string, bool) {
+	for _, option := range labeler.Labels {
+		if option.Key == key {
+			return option.Name, true
+		}
+	}
+	return "", false
+}
+
+// Apply writes the label bound to key into the current note.
+func (labeler *NoteLabeler) Apply(key string) error {
+	if labeler.Current == nil {
+		return errors.New("no current note")
+	}
+	label, ok := labeler.LabelFor(key)
+	if !ok {
+		return fmt.Errorf("unknown label key: %s", key)
+	}
+	note := labeler.Current
+	if err := labeler.Journal.RecordOverwrite(note.FilePath); err != nil {
+		return fmt.Errorf("failed to record journal: %w", err)
+	}
+	if note.FrontMatter == nil {
+		note.FrontMatter = models.FrontMatter{}
+	}
+	note.FrontMatter["label"] = label
+	if err := note.SaveToFile(); err != nil {
+		return err
+	}
+	labeler.record(note.FilePath, LabelingActionLabeled, label)
+	return nil
+}
+
+// Skip leaves the current note unlabeled.
+func (labeler *NoteLabeler) Skip() {
+	if labeler.Current == nil {
+		return
+	}
+	labeler.record(labeler.Current.FilePath, LabelingActionSkipped, "")
+}
+
+func (labeler *NoteLabeler) record(path, action, label string) {
+	if labeler.actions == nil {
+		labeler.actions = map[string]string{}
+		labeler.labels = map[string]string{}
+	}
+	labeler.actions[path] = action
+	labeler.labels[path] = label
+}
+
+// Progress returns the 1-based position of the current note and the total.
+func (labeler *NoteLabeler) Progress() (int, int) {
+	return labeler.CurrentIndex + 1, len(labeler.Notes)
+}
+
+// Stats summarizes what has been done so far. A note that was labeled and
+// later revisited counts only with its last action.
+func (labeler *NoteLabeler) Stats() LabelingStats {
+	stats := LabelingStats{
+		Total:   len(labeler.Notes),
+		Failed:  len(labeler.Failures),
+		ByLabel: map[string]int{},
+	}
+	for path, action := range labeler.actions {
+		switch action {
+		case LabelingActionLabeled:
+			stats.Labeled++
+			stats.ByLabel[labeler.labels[path]]++
+		case LabelingActionSkipped:
+			stats.Skipped++
+		}
+	}
+	stats.Remaining = stats.Total - stats.Labeled - stats.Skipped
+	return stats
+}
+
+// GeneratePreview returns the first maxLines lines of content, with long
+// lines shortened and a marker when the content was truncated.
+func GeneratePreview(content string, maxLines int) string {
+	lines := strings.Split(content, "\n")
+	truncated := false
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		truncated = true
+	}
+	for i, line := range lines {
+		if utf8.RuneCountInString(line) > MaxPreviewLineLength {
+			lines[i] = string([]rune(line)[:MaxPreviewLineLength-3]) + "..."
+		}
+	}
+	preview := strings.Join(lines, "\n")
+	if truncated {
+		preview += "\n" + fmt.Sprintf("[Preview truncated - showing first %d lines]", maxLines)
+	}
+	return preview
+}
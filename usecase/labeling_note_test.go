@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+var testLabelOptions = []LabelOption{{Key: "1", Name: "diary"}, {Key: "2", Name: "idea"}}
+
+func TestNewNoteLabeler_SkipsLabeledNotes(t *testing.T) {
+	dir := t.TempDir()
+	writeTestNote(t, filepath.Join(dir, "a.md"), "---\nlabel: diary\n---\nA")
+	writeTestNote(t, filepath.Join(dir, "b.md"), "B")
+	writeTestNote(t, filepath.Join(dir, "sub", "c.md"), "C")
+
+	labeler, err := NewNoteLabeler(dir, false, testLabelOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(labeler.Notes) != 1 {
+		t.Errorf("expected 1 unlabeled note, got %d", len(labeler.Notes))
+	}
+
+	labeler, err = NewNoteLabeler(dir, true, testLabelOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(labeler.Notes) != 2 {
+		t.Errorf("expected 2 unlabeled notes recursively, got %d", len(labeler.Notes))
+	}
+
+	if _, err := NewNoteLabeler(dir, false, nil); err == nil {
+		t.Error("expected error without labels")
+	}
+}
+
+func TestNoteLabeler_Flow(t *testing.T) {
+	dir := t.TempDir()
+	writeTestNote(t, filepath.Join(dir, "a.md"), "A")
+	writeTestNote(t, filepath.Join(dir, "b.md"), "---\nstatus: new\n---\nB")
+	writeTestNote(t, filepath.Join(dir, "c.md"), "C")
+
+	labeler, err := NewNoteLabeler(dir, false, testLabelOptions)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := labeler.Next(); err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if err := labeler.Apply("1"); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	labeler.Next()
+	labeler.Skip()
+	labeler.Next()
+
+	// 戻ってスキップしたノートにラベルを付ける
+	if err := labeler.Back(); err != nil {
+		t.Fatalf("Back: %v", err)
+	}
+	if current, total := labeler.Progress(); current != 2 || total != 3 {
+		t.Errorf("Progress() = %d/%d, want 2/3", current, total)
+	}
+	if err := labeler.Apply("9"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+	if err := labeler.Apply("2"); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	labeler.Next()
+	if err := labeler.Next(); err != ErrLabelingDone {
+		t.Errorf("expected ErrLabelingDone, got %v", err)
+	}
+
+	stats := labeler.Stats()
+	if stats.Labeled != 2 || stats.Skipped != 0 || stats.Remaining != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.ByLabel["diary"] != 1 || stats.ByLabel["idea"] != 1 {
+		t.Errorf("unexpected per-label stats: %v", stats.ByLabel)
+	}
+
+	note, err := models.LoadNoteFromFile(filepath.Join(dir, "b.md"))
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if label, _ := note.FrontMatter.Label(); label != "idea" {
+		t.Errorf("expected label idea, got %q", label)
+	}
+	if status, _ := note.FrontMatter.Status(); status != "new" {
+		t.Errorf("existing frontmatter should be kept")
+	}
+}
+
+func TestGeneratePreview(t *testing.T) {
+	content := strings.Repeat("line\n", 5) + strings.Repeat("あ", 120)
+	preview := GeneratePreview(content, 3)
+	if !strings.HasSuffix(preview, "[Preview truncated - showing first 3 lines]") {
+		t.Errorf("expected truncate marker, got %q", preview)
+	}
+
+	preview = GeneratePreview(strings.Repeat("あ", 120), 3)
+	if preview != strings.Repeat("あ", MaxPreviewLineLength-3)+"..." {
+		t.Errorf("long line should be shortened, got %q", preview)
+	}
+}
@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// LinkGraph holds the links between the notes of a vault. Paths are the
+// file paths found under BaseDir.
+type LinkGraph struct {
+	BaseDir  string
+	Notes    map[string]*models.Note   // ファイルパス → ノート
+	Links    map[string][]ResolvedLink // ファイルパス → そのノートから出ているリンク
+	Failures map[string]error
+
+	names map[string][]string // 小文字のノート名・別名 → ファイルパス
+	files map[string][]string // 小文字のファイル名 → ファイルパス（添付ファイルを含む）
+	paths map[string]bool     // ボルト内のすべてのファイル
+	raws  map[string]string   // ファイルパス → ノートの内容
+}
+
+// ResolvedLink is a link together with the note it was found in and the
+// file it points to.
+type ResolvedLink struct {
+	models.Link
+	Source  string // リンク元のノート
+	Path    string // リンク先のファイル（解決できなければ空）
+	Context string // リンクを含む行
+}
+
+// Resolved reports whether the link points to an existing file.
+func (link ResolvedLink) Resolved() bool {
+	return link.Path != ""
+}
+
+// BuildLinkGraph reads every note under baseDir and resolves their links.
+func BuildLinkGraph(baseDir string) (*LinkGraph, error) {
+	graph := &LinkGraph{
+		BaseDir:  baseDir,
+		Notes:    map[string]*models.Note{},
+		Links:    map[string][]ResolvedLink{},
+		Failures: map[string]error{},
+		paths:    map[string]bool{},
+		raws:     map[string]string{},
+	}
+
+	// 名前の解決にはすべてのノートが必要なので、先に読み込んでから解決する
+	err := walkVaultFiles(baseDir, true, func(path string, d fs.DirEntry) error {
+		graph.paths[path] = true
+		if isNoteFile(d.Name()) {
+			graph.loadNote(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", baseDir, err)
+	}
+	graph.reindex()
+	return graph, nil
+}
+
+// loadNote reads the note at path into the graph. Read errors are
+// recorded in Failures.
+func (graph *LinkGraph) loadNote(path string) {
+	delete(graph.Failures, path)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		graph.Failures[path] = err
+		delete(graph.Notes, path)
+		delete(graph.raws, path)
+		return
+	}
+	note, err := models.ParseNote(string(raw))
+	if err != nil {
+		graph.Failures[path] = err
+		note = &models.Note{FrontMatter: models.FrontMatter{}}
+	}
+	note.FilePath = path
+	graph.Notes[path] = note
+	graph.raws[path] = string(raw)
+}
+
+// reindex rebuilds the name tables and resolves every link again.
+func (graph *LinkGraph) reindex() {
+	graph.names = map[string][]string{}
+	graph.files = map[string][]string{}
+	for path := range graph.paths {
+		name := strings.ToLower(filepath.Base(path))
+		graph.files[name] = append(graph.files[name], path)
+	}
+	for path, note := range graph.Notes {
+		base := filepath.Base(path)
+		graph.addName(strings.TrimSuffix(base, filepath.Ext(base)), path)
+		for _, alias := range note.FrontMatter.Aliases() {
+			graph.addName(alias, path)
+		}
+	}
+
+	graph.Links = map[string][]ResolvedLink{}
+	for path, raw := range graph.raws {
+		lines := strings.Split(raw, "\n")
+		for _, link := range models.ParseFileLinks(raw) {
+			graph.Links[path] = append(graph.Links[path], ResolvedLink{
+				Link:    link,
+				Source:  path,
+				Path:    graph.Resolve(path, link),
+				Context: strings.TrimSpace(lines[link.Line-1]),
+			})
+		}
+	}
+}
+
+func (graph *LinkGraph) addName(name, path string) {
+	key := strings.ToLower(name)
+	for _, existing := range graph.names[key] {
+		if existing == path {
+			return
+		}
+	}
+	graph.names[key] = append(graph.names[key], path)
+}
+
+// Resolve returns the file that link, found in the note at source, points
+// to, or "" when it cannot be resolved. Wikilinks are resolved by path,
+// file name or alias; markdown links relative to the source note.
+func (graph *LinkGraph) Resolve(source string, link models.Link) string {
+	target := filepath.FromSlash(link.Target)
+	if target == "" {
+		// [[#見出し]] は同じノートへのリンク
+		return source
+	}
+
+	if link.Kind == models.LinkMarkdown {
+		var candidate string
+		if strings.HasPrefix(link.Target, "/") {
+			candidate = filepath.Join(graph.BaseDir, target)
+		} else {
+			candidate = filepath.Join(filepath.Dir(source), target)
+		}
+		return graph.existingPath(candidate)
+	}
+
+	// パスを含むwikilinkはボルトのルートから、次にリンク元からの相対で探す
+	if strings.Contains(link.Target, "/") {
+		for _, candidate := range []string{
+			filepath.Join(graph.BaseDir, target),
+			filepath.Join(filepath.Dir(source), target),
+		} {
+			if path := graph.existingPath(candidate); path != "" {
+				return path
+			}
+		}
+	}
+
+	base := filepath.Base(target)
+	ext := strings.ToLower(filepath.Ext(base))
+	var candidates []string
+	switch {
+	case ext == ".md":
+		candidates = graph.names[strings.ToLower(strings.TrimSuffix(base, filepath.Ext(base)))]
+	case ext != "" && len(graph.files[strings.ToLower(base)]) > 0:
+		candidates = graph.files[strings.ToLower(base)]
+	default:
+		candidates = graph.names[strings.ToLower(link.Target)]
+		if len(candidates) == 0 {
+			candidates = graph.names[strings.ToLower(base)]
+		}
+	}
+	return closestPath(source, candidates)
+}
+
+// existingPath returns the vault path for candidate, trying ".md" when it
+// has no extension, or "" when no such file exists.
+func (graph *LinkGraph) existingPath(candidate string) string {
+	candidate = filepath.Clean(candidate)
+	if graph.paths[candidate] {
+		return candidate
+	}
+	if filepath.Ext(candidate) == "" && graph.paths[candidate+".md"] {
+		return candidate + ".md"
+	}
+	return ""
+}
+
+// closestPath picks the candidate in the same directory as source, or else
+// the one with the shortest path.
+func closestPath(source string, candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	sorted := append([]string(nil), candidates...)
+	sort.Slice(sorted, func(i, j int) bool {
+		sameI := filepath.Dir(sorted[i]) == filepath.Dir(source)
+		sameJ := filepath.Dir(sorted[j]) == filepath.Dir(source)
+		if sameI != sameJ {
+			return sameI
+		}
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) < len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted[0]
+}
+
+// Outgoing returns the links found in the note at path, in file order.
+func (graph *LinkGraph) Outgoing(path string) []ResolvedLink {
+	return append([]ResolvedLink{}, graph.Links[path]...)
+}
+
+// Backlinks returns the links from other notes (and the note itself) that
+// point to path, ordered by source and line.
+func (graph *LinkGraph) Backlinks(path string) []ResolvedLink {
+	backlinks := []ResolvedLink{}
+	for _, links := range graph.Links {
+		for _, link := range links {
+			if link.Path == path {
+				backlinks = append(backlinks, link)
+			}
+		}
+	}
+	sortLinks(backlinks)
+	return backlinks
+}
+
+// FindNote returns the note identified by arg, which is either a file path
+// (relative to the current directory or to BaseDir) or a note name or alias
+// as written in a wikilink.
+func (graph *LinkGraph) FindNote(arg string) (string, error) {
+	for _, candidate := range []string{arg, filepath.Join(graph.BaseDir, arg)} {
+		if path, ok := graph.lookupPath(candidate); ok {
+			return path, nil
+		}
+	}
+
+	name := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(arg, "[["), "]]"), ".md")
+	candidates := graph.names[strings.ToLower(name)]
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("note %s not found", arg)
+	case 1:
+		return candidates[0], nil
+	default:
+		sorted := append([]string(nil), candidates...)
+		sort.Strings(sorted)
+		return "", fmt.Errorf("note name %s is ambiguous: %s", arg, strings.Join(sorted, ", "))
+	}
+}
+
+// lookupPath returns the note path in the graph that refers to the same
+// file as path, which may be written relative to another directory.
+func (graph *LinkGraph) lookupPath(path string) (string, bool) {
+	if _, ok := graph.Notes[filepath.Clean(path)]; ok {
+		return filepath.Clean(path), true
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	for notePath := range graph.Notes {
+		if noteAbs, err := filepath.Abs(notePath); err == nil && noteAbs == abs {
+			return notePath, true
+		}
+	}
+	return "", false
+}
+
+// BrokenLinks returns every link that cannot be resolved, ordered by
+// source and line.
+func (graph *LinkGraph) BrokenLinks() []ResolvedLink {
+	broken := []ResolvedLink{}
+	for _, links := range graph.Links {
+		for _, link := range links {
+			if !link.Resolved() {
+				broken = append(broken, link)
+			}
+		}
+	}
+	sortLinks(broken)
+	return broken
+}
+
+func sortLinks(links []ResolvedLink) {
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Source != links[j].Source {
+			return links[i].Source < links[j].Source
+		}
+		if links[i].Line != links[j].Line {
+			return links[i].Line < links[j].Line
+		}
+		return links[i].Offset < links[j].Offset
+	})
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func setupLinkVault(t *testing.T) string {
+	t.Helper()
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "projects", "krapp.md"), "---\naliases: [Krapp CLI]\n---\n# krapp\n[[ideas#UI]] と [[daily/2025-06-02]]\n![[diagram.png]]\n")
+	writeTestNote(t, filepath.Join(baseDir, "ideas.md"), "[[Krapp CLI|ツール]] を作る\n[related](projects/krapp.md)\n[[missing]]\n")
+	writeTestNote(t, filepath.Join(baseDir, "daily", "2025-06-02.md"), "今日は [[krapp]] の作業\n[[#見出し]]\n")
+	writeTestNote(t, filepath.Join(baseDir, "assets", "diagram.png"), "png")
+	return baseDir
+}
+
+func TestBuildLinkGraph(t *testing.T) {
+	baseDir := setupLinkVault(t)
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	krapp := filepath.Join(baseDir, "projects", "krapp.md")
+	ideas := filepath.Join(baseDir, "ideas.md")
+	daily := filepath.Join(baseDir, "daily", "2025-06-02.md")
+
+	outgoing := graph.Outgoing(krapp)
+	if len(outgoing) != 3 {
+		t.Fatalf("expected 3 outgoing links, got %d", len(outgoing))
+	}
+	expected := []string{ideas, daily, filepath.Join(baseDir, "assets", "diagram.png")}
+	for i, link := range outgoing {
+		if link.Path != expected[i] {
+			t.Errorf("link %s resolved to %q, want %q", link.Raw, link.Path, expected[i])
+		}
+	}
+
+	backlinks := graph.Backlinks(krapp)
+	if len(backlinks) != 3 {
+		t.Fatalf("expected 3 backlinks, got %d: %+v", len(backlinks), backlinks)
+	}
+	if backlinks[0].Source != daily || backlinks[1].Source != ideas || backlinks[1].Alias != "ツール" {
+		t.Errorf("unexpected backlinks: %+v", backlinks)
+	}
+	if backlinks[2].Kind != "markdown" || backlinks[2].Line != 2 {
+		t.Errorf("unexpected markdown backlink: %+v", backlinks[2])
+	}
+
+	unresolved := graph.Outgoing(ideas)[2]
+	if unresolved.Resolved() || unresolved.Target != "missing" {
+		t.Errorf("expected unresolved link, got %+v", unresolved)
+	}
+	if self := graph.Outgoing(daily)[1]; self.Path != daily {
+		t.Errorf("heading-only link should point to itself, got %q", self.Path)
+	}
+}
+
+func TestLinkGraph_FindNote(t *testing.T) {
+	baseDir := setupLinkVault(t)
+	writeTestNote(t, filepath.Join(baseDir, "archive", "ideas.md"), "old")
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	krapp := filepath.Join(baseDir, "projects", "krapp.md")
+	for _, arg := range []string{"krapp", "Krapp CLI", "[[krapp]]", "projects/krapp.md", krapp} {
+		path, err := graph.FindNote(arg)
+		if err != nil || path != krapp {
+			t.Errorf("FindNote(%q) = %q, %v", arg, path, err)
+		}
+	}
+	if _, err := graph.FindNote("ideas"); err == nil {
+		t.Error("expected error for ambiguous name")
+	}
+	if _, err := graph.FindNote("nothing"); err == nil {
+		t.Error("expected error for unknown note")
+	}
+}
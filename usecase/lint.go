@@ -0,0 +1,486 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// Types of frontmatter fields in a schema.
+const (
+	FieldTypeString  = "string"
+	FieldTypeNumber  = "number"
+	FieldTypeInteger = "integer"
+	FieldTypeBoolean = "boolean"
+	FieldTypeList    = "list"
+	FieldTypeMap     = "map"
+	FieldTypeDate    = "date"
+)
+
+// DefaultDateFormat is the date format used when a date field has none.
+const DefaultDateFormat = "%Y-%m-%d"
+
+// Rules reported by LintNote.
+const (
+	LintRuleSyntax     = "syntax"
+	LintRuleRequired   = "required"
+	LintRuleType       = "type"
+	LintRuleEnum       = "enum"
+	LintRuleDateFormat = "date-format"
+	LintRuleKeyCase    = "key-case"
+)
+
+// FieldSchema describes the value of a frontmatter key.
+type FieldSchema struct {
+	Type   string   // 空なら型を問わない
+	Enum   []string // 許される値
+	Format string   // dateの書式（strftime形式、既定は%Y-%m-%d）
+}
+
+// FrontMatterSchema is the frontmatter convention of a folder. Schemas are
+// keyed by folder relative to the base directory, "." being the whole vault.
+type FrontMatterSchema struct {
+	Required []string
+	Fields   map[string]FieldSchema
+}
+
+// SchemaForPath returns the schema that applies to the note at path: the
+// schemas of every folder containing it, with deeper folders overriding
+// field definitions and adding required keys.
+func SchemaForPath(schemas map[string]FrontMatterSchema, baseDir, path string) FrontMatterSchema {
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		rel = path
+	}
+	dir := filepath.ToSlash(filepath.Dir(rel))
+
+	var folders []string
+	for folder := range schemas {
+		f := filepath.ToSlash(filepath.Clean(folder))
+		if f == "." || f == "" || dir == f || strings.HasPrefix(dir, f+"/") {
+			folders = append(folders, folder)
+		}
+	}
+	// 浅いフォルダから順に重ねる
+	sort.Slice(folders, func(i, j int) bool {
+		return schemaDepth(folders[i]) < schemaDepth(folders[j])
+	})
+
+	merged := FrontMatterSchema{Fields: map[string]FieldSchema{}}
+	for _, folder := range folders {
+		schema := schemas[folder]
+		for _, key := range schema.Required {
+			if !containsString(merged.Required, key) {
+				merged.Required = append(merged.Required, key)
+			}
+		}
+		for key, field := range schema.Fields {
+			merged.Fields[key] = field
+		}
+	}
+	return merged
+}
+
+func schemaDepth(folder string) int {
+	folder = filepath.ToSlash(filepath.Clean(folder))
+	if folder == "." || folder == "" {
+		return 0
+	}
+	return strings.Count(folder, "/") + 1
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// LintIssue is a violation of the schema found in a note.
+type LintIssue struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Key     string `json:"key,omitempty"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"`
+	Fixed   bool   `json:"fixed,omitempty"`
+}
+
+// LintNote checks the frontmatter of note against schema.
+func LintNote(note *models.Note, schema FrontMatterSchema) []LintIssue {
+	issues := []LintIssue{}
+	add := func(key, rule, message string, fixable bool) {
+		// ないキーはfrontmatterの先頭の行で報告する
+		line := max(note.FrontMatterKeyLine(key), 1)
+		issues = append(issues, LintIssue{
+			Path:    note.FilePath,
+			Line:    line,
+			Key:     key,
+			Rule:    rule,
+			Message: message,
+			Fixable: fixable,
+		})
+	}
+	fm := note.FrontMatter
+
+	// 大文字・小文字だけが違うキー（Created と created など）
+	miscased := map[string]string{}
+	for _, key := range sortedKeys(fm) {
+		name, ok := schemaKeyFor(schema, key)
+		if !ok || name == key {
+			continue
+		}
+		miscased[name] = key
+		_, exists := fm[name]
+		add(key, LintRuleKeyCase, fmt.Sprintf("key %q should be written %q", key, name), !exists || sameValue(fm[key], fm[name]))
+	}
+
+	for _, key := range schema.Required {
+		if _, ok := fm[key]; !ok && miscased[key] == "" {
+			add(key, LintRuleRequired, fmt.Sprintf("required key %q is missing", key), false)
+		}
+	}
+
+	for _, key := range sortedFieldKeys(schema.Fields) {
+		field := schema.Fields[key]
+		written := key
+		value, ok := fm[key]
+		if !ok && miscased[key] != "" {
+			written = miscased[key]
+			value, ok = fm[written]
+		}
+		if !ok || value == nil {
+			continue
+		}
+		if field.Type == FieldTypeDate {
+			if fixed, isDate, err := normalizeDate(value, field.Format); err != nil {
+				rule := LintRuleType
+				if isDate {
+					rule = LintRuleDateFormat
+				}
+				add(written, rule, err.Error(), fixed != "")
+			}
+			continue
+		}
+		if field.Type != "" && !hasFieldType(value, field.Type) {
+			add(written, LintRuleType, fmt.Sprintf("%s should be a %s, got %s", written, field.Type, FormatFrontMatterValue(value)), false)
+			continue
+		}
+		if len(field.Enum) > 0 {
+			if _, exact, ok := enumValue(value, field.Enum); !exact {
+				add(written, LintRuleEnum, fmt.Sprintf("%s should be one of %s, got %s", written, strings.Join(field.Enum, ", "), FormatFrontMatterValue(value)), ok)
+			}
+		}
+	}
+	return issues
+}
+
+// FixNote applies the safe fixes for the issues LintNote reports: key
+// casing, date formats and the case of enum values. It returns the issues
+// with Fixed set on those it fixed.
+func FixNote(note *models.Note, schema FrontMatterSchema) []LintIssue {
+	// キー名を先に直し、直したキーの値をあらためて検査する
+	var fixed []LintIssue
+	for _, issue := range LintNote(note, schema) {
+		if issue.Rule != LintRuleKeyCase || !issue.Fixable {
+			continue
+		}
+		name, _ := schemaKeyFor(schema, issue.Key)
+		if _, exists := note.FrontMatter[name]; exists {
+			delete(note.FrontMatter, issue.Key)
+		} else {
+			note.RenameFrontMatterKey(issue.Key, name)
+		}
+		issue.Fixed = true
+		fixed = append(fixed, issue)
+	}
+
+	issues := LintNote(note, schema)
+	for i, issue := range issues {
+		if !issue.Fixable {
+			continue
+		}
+		key, _ := schemaKeyFor(schema, issue.Key)
+		field := schema.Fields[key]
+		switch issue.Rule {
+		case LintRuleDateFormat:
+			if date, _, _ := normalizeDate(note.FrontMatter[issue.Key], field.Format); date != "" {
+				note.FrontMatter[issue.Key] = date
+				issues[i].Fixed = true
+			}
+		case LintRuleEnum:
+			if value, _, ok := enumValue(note.FrontMatter[issue.Key], field.Enum); ok {
+				note.FrontMatter[issue.Key] = value
+				issues[i].Fixed = true
+			}
+		}
+	}
+	return append(fixed, issues...)
+}
+
+// schemaKeyFor returns the schema key that key refers to, ignoring case.
+func schemaKeyFor(schema FrontMatterSchema, key string) (string, bool) {
+	if _, ok := schema.Fields[key]; ok || containsString(schema.Required, key) {
+		return key, true
+	}
+	for name := range schema.Fields {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	for _, name := range schema.Required {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func hasFieldType(value any, fieldType string) bool {
+	switch fieldType {
+	case FieldTypeString:
+		_, ok := value.(string)
+		return ok
+	case FieldTypeNumber:
+		switch value.(type) {
+		case int, int64, float64:
+			return true
+		}
+		return false
+	case FieldTypeInteger:
+		switch value.(type) {
+		case int, int64:
+			return true
+		}
+		return false
+	case FieldTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case FieldTypeList:
+		switch value.(type) {
+		case []any, []string:
+			return true
+		}
+		return false
+	case FieldTypeMap:
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+// enumValue returns the allowed value matching value, whether it matched
+// exactly, and whether a match ignoring case was found.
+func enumValue(value any, enum []string) (string, bool, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return "", false, false
+	}
+	for _, allowed := range enum {
+		if s == allowed {
+			return allowed, true, true
+		}
+	}
+	for _, allowed := range enum {
+		if strings.EqualFold(s, allowed) {
+			return allowed, false, true
+		}
+	}
+	return "", false, false
+}
+
+// dateLayouts are the forms a date is accepted in before being rewritten
+// to the schema format.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"2006.01.02",
+	"20060102",
+	"2006-1-2",
+	"2006/1/2",
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+}
+
+// normalizeDate checks that value is a date written in format. When it is
+// not, isDate tells whether it is a date in another form and fixed is the
+// value rewritten in format, or "" when that would lose information.
+func normalizeDate(value any, format string) (fixed string, isDate bool, err error) {
+	if format == "" {
+		format = DefaultDateFormat
+	}
+	layout, err := strftimeLayout(format)
+	if err != nil {
+		return "", false, err
+	}
+
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+		if layout == "2006-01-02" && v.Equal(v.Truncate(24*time.Hour)) {
+			// 引用符なしの日付はそのままでよい
+			return "", true, nil
+		}
+	case string:
+		if parsed, err := time.ParseInLocation(layout, v, time.Local); err == nil && parsed.Format(layout) == v {
+			return "", true, nil
+		}
+		parsed, ok := parseAnyDate(v)
+		if !ok {
+			return "", false, fmt.Errorf("%q is not a date", v)
+		}
+		t = parsed
+	default:
+		return "", false, fmt.Errorf("%s is not a date", FormatFrontMatterValue(value))
+	}
+
+	formatted := t.Format(layout)
+	// 時刻を含まない書式に時刻つきの値を入れると情報が落ちるので直さない
+	if back, err := time.ParseInLocation(layout, formatted, t.Location()); err != nil || !back.Equal(t) {
+		return "", true, fmt.Errorf("%s does not fit the date format %s", FormatFrontMatterValue(value), format)
+	}
+	return formatted, true, fmt.Errorf("%s should be written as %s", FormatFrontMatterValue(value), formatted)
+}
+
+func parseAnyDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// strftimeLayout converts a strftime format, as used by the path patterns,
+// to a Go time layout.
+func strftimeLayout(format string) (string, error) {
+	directives := map[byte]string{
+		'Y': "2006", 'y': "06", 'm': "01", 'd': "02",
+		'H': "15", 'M': "04", 'S': "05", 'z': "-0700", 'Z': "MST",
+		'b': "Jan", 'B': "January", 'a': "Mon", 'A': "Monday", '%': "%",
+	}
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if i+1 == len(format) {
+			return "", fmt.Errorf("date format %q ends with %%", format)
+		}
+		layout, ok := directives[format[i+1]]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c in date format %q", format[i+1], format)
+		}
+		b.WriteString(layout)
+		i++
+	}
+	return b.String(), nil
+}
+
+func sameValue(a, b any) bool {
+	return FormatFrontMatterValue(a) == FormatFrontMatterValue(b)
+}
+
+func sortedKeys(fm models.FrontMatter) []string {
+	keys := make([]string, 0, len(fm))
+	for key := range fm {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedFieldKeys(fields map[string]FieldSchema) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LintResult is the outcome of LintVault.
+type LintResult struct {
+	Issues []LintIssue
+	Edits  []NoteEdit // fixで書き換えるノート
+	Notes  int        // 検査したノートの数
+}
+
+// Remaining returns the number of issues that were not fixed.
+func (result *LintResult) Remaining() int {
+	count := 0
+	for _, issue := range result.Issues {
+		if !issue.Fixed {
+			count++
+		}
+	}
+	return count
+}
+
+// LintVault checks every note under baseDir, or the notes at paths when
+// given. With fix, the safe fixes are planned as Edits; they are written
+// by ApplyNoteEdits.
+func LintVault(baseDir string, paths []string, schemas map[string]FrontMatterSchema, fix bool) (*LintResult, error) {
+	if len(paths) == 0 {
+		err := walkNoteFiles(baseDir, true, func(path string, d fs.DirEntry) error {
+			paths = append(paths, path)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", baseDir, err)
+		}
+	}
+
+	result := &LintResult{Issues: []LintIssue{}, Edits: []NoteEdit{}}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		result.Notes++
+		note, err := models.ParseNote(string(raw))
+		if err != nil {
+			issue := LintIssue{Path: path, Line: 1, Rule: LintRuleSyntax, Message: err.Error()}
+			var fmErr *models.FrontMatterError
+			if errors.As(err, &fmErr) {
+				issue.Line = fmErr.Line
+				issue.Message = fmErr.Msg
+			}
+			result.Issues = append(result.Issues, issue)
+			continue
+		}
+		note.FilePath = path
+		if note.FrontMatter == nil {
+			note.FrontMatter = models.FrontMatter{}
+		}
+
+		schema := SchemaForPath(schemas, baseDir, path)
+		if !fix {
+			result.Issues = append(result.Issues, LintNote(note, schema)...)
+			continue
+		}
+		result.Issues = append(result.Issues, FixNote(note, schema)...)
+		after, err := note.ToString()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fix %s: %w", path, err)
+		}
+		if after != string(raw) {
+			result.Edits = append(result.Edits, NoteEdit{Path: path, Before: string(raw), After: after})
+		}
+	}
+	return result, nil
+}
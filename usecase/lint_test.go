@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+func testSchemas() map[string]FrontMatterSchema {
+	return map[string]FrontMatterSchema{
+		".": {
+			Required: []string{"created"},
+			Fields: map[string]FieldSchema{
+				"created": {Type: FieldTypeDate},
+				"tags":    {Type: FieldTypeList},
+			},
+		},
+		"inbox": {
+			Required: []string{"status"},
+			Fields: map[string]FieldSchema{
+				"status": {Type: FieldTypeString, Enum: []string{"new", "done"}},
+			},
+		},
+		"inbox/deep": {
+			Fields: map[string]FieldSchema{
+				"created": {Type: FieldTypeDate, Format: "%Y/%m/%d"},
+			},
+		},
+	}
+}
+
+func TestSchemaForPath(t *testing.T) {
+	schemas := testSchemas()
+	base := "/vault"
+
+	root := SchemaForPath(schemas, base, "/vault/note.md")
+	if !reflect.DeepEqual(root.Required, []string{"created"}) || len(root.Fields) != 2 {
+		t.Errorf("unexpected root schema: %+v", root)
+	}
+
+	deep := SchemaForPath(schemas, base, "/vault/inbox/deep/note.md")
+	if !reflect.DeepEqual(deep.Required, []string{"created", "status"}) {
+		t.Errorf("unexpected required keys: %v", deep.Required)
+	}
+	if deep.Fields["created"].Format != "%Y/%m/%d" {
+		t.Errorf("deeper folder should override the field: %+v", deep.Fields["created"])
+	}
+	if _, ok := deep.Fields["status"]; !ok {
+		t.Error("fields of parent folders should be inherited")
+	}
+
+	// inboxという名前で始まるだけのフォルダには適用しない
+	other := SchemaForPath(schemas, base, "/vault/inbox2/note.md")
+	if _, ok := other.Fields["status"]; ok {
+		t.Errorf("inbox schema should not apply to inbox2: %+v", other)
+	}
+}
+
+func lintRules(issues []LintIssue) []string {
+	var rules []string
+	for _, issue := range issues {
+		rules = append(rules, issue.Key+":"+issue.Rule)
+	}
+	return rules
+}
+
+func TestLintNote(t *testing.T) {
+	schema := SchemaForPath(testSchemas(), "/vault", "/vault/inbox/a.md")
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"valid", "---\ncreated: 2024-01-05\nstatus: new\ntags: [a]\n---\nbody", nil},
+		{"missing", "---\ntags: []\n---\nbody", []string{"created:required", "status:required"}},
+		{"type", "---\ncreated: 2024-01-05\nstatus: new\ntags: a\n---\n", []string{"tags:type"}},
+		{"enum", "---\ncreated: 2024-01-05\nstatus: wip\n---\n", []string{"status:enum"}},
+		{"enum case", "---\ncreated: 2024-01-05\nstatus: New\n---\n", []string{"status:enum"}},
+		{"date format", "---\ncreated: 2024/01/05\nstatus: new\n---\n", []string{"created:date-format"}},
+		{"not a date", "---\ncreated: someday\nstatus: new\n---\n", []string{"created:type"}},
+		{"key case", "---\nCreated: 2024-01-05\nstatus: new\n---\n", []string{"Created:key-case"}},
+	}
+	for _, tt := range tests {
+		note, err := models.ParseNote(tt.content)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := lintRules(LintNote(note, schema))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLintNote_Line(t *testing.T) {
+	schema := SchemaForPath(testSchemas(), "/vault", "/vault/inbox/a.md")
+	note, err := models.ParseNote("---\ncreated: 2024-01-05\nstatus: wip\n---\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := LintNote(note, schema)
+	if len(issues) != 1 || issues[0].Line != 3 {
+		t.Errorf("expected the issue on line 3, got %+v", issues)
+	}
+}
+
+func TestFixNote(t *testing.T) {
+	schema := SchemaForPath(testSchemas(), "/vault", "/vault/inbox/a.md")
+	note, err := models.ParseNote("---\n# 作成日\nCreated: 2024/1/5\nstatus: Done\ntags: a\n---\nbody\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues := FixNote(note, schema)
+
+	var remaining []string
+	for _, issue := range issues {
+		if !issue.Fixed {
+			remaining = append(remaining, issue.Key+":"+issue.Rule)
+		}
+	}
+	if !reflect.DeepEqual(remaining, []string{"tags:type"}) {
+		t.Errorf("unexpected remaining issues: %v", remaining)
+	}
+
+	got, err := note.ToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "---\n# 作成日\ncreated: \"2024-01-05\"\nstatus: done\ntags: a\n---\nbody\n"
+	if got != want {
+		t.Errorf("unexpected note:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFixNote_DuplicateKeyCase(t *testing.T) {
+	schema := SchemaForPath(testSchemas(), "/vault", "/vault/note.md")
+	note, err := models.ParseNote("---\ncreated: 2024-01-05\nCreated: 2024-02-01\n---\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 値の違う同名キーはどちらを残すか決められない
+	issues := FixNote(note, schema)
+	if len(issues) != 1 || issues[0].Fixable || issues[0].Fixed {
+		t.Errorf("expected an unfixable key-case issue, got %+v", issues)
+	}
+}
+
+func TestLintVault(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "ok.md"), "---\ncreated: 2024-01-05\n---\nbody")
+	writeTestNote(t, filepath.Join(baseDir, "inbox", "a.md"), "---\ncreated: 2024.01.05\nstatus: NEW\n---\nbody")
+	writeTestNote(t, filepath.Join(baseDir, "broken.md"), "---\ncreated: [\n---\nbody")
+
+	result, err := LintVault(baseDir, nil, testSchemas(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Notes != 3 || len(result.Edits) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	got := lintRules(result.Issues)
+	want := []string{":syntax", "created:date-format", "status:enum"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	result, err = LintVault(baseDir, nil, testSchemas(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Edits) != 1 || result.Remaining() != 1 {
+		t.Fatalf("expected one edit and the syntax error left, got %+v", result)
+	}
+	if err := ApplyNoteEdits(result.Edits, nil); err != nil {
+		t.Fatal(err)
+	}
+	fixed := readTestNote(t, filepath.Join(baseDir, "inbox", "a.md"))
+	if !strings.Contains(fixed, "created: \"2024-01-05\"") || !strings.Contains(fixed, "status: new") {
+		t.Errorf("note was not fixed:\n%s", fixed)
+	}
+}
+
+func TestStrftimeLayout(t *testing.T) {
+	layout, err := strftimeLayout("%Y/%m/%d %H:%M")
+	if err != nil || layout != "2006/01/02 15:04" {
+		t.Errorf("got %q, %v", layout, err)
+	}
+	if _, err := strftimeLayout("%Q"); err == nil {
+		t.Error("expected an error for an unknown directive")
+	}
+}
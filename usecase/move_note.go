@@ -0,0 +1,332 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// NoteMovePlan describes moving a note and the link rewrites it needs.
+type NoteMovePlan struct {
+	From    string
+	To      string
+	Changes []LinkChange      // 書き換えるリンク
+	edits   map[string]string // ファイルパス → 書き換え後の内容
+}
+
+// LinkChange is a single link rewritten by a move.
+type LinkChange struct {
+	Source string // リンクを含むファイル（移動前のパス）
+	Line   int
+	Old    string
+	New    string
+}
+
+// markdownPathEscaper escapes the characters that would end a markdown link target.
+var markdownPathEscaper = strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29")
+
+// PlanNoteMove plans moving the note at from to dest and rewriting every
+// link to it so that they keep resolving. dest may be an existing
+// directory, in which case the file name is kept; ".md" is added when dest
+// has no extension.
+func PlanNoteMove(graph *LinkGraph, from, dest string) (*NoteMovePlan, error) {
+	notePath, ok := graph.lookupPath(from)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a note in %s", from, graph.BaseDir)
+	}
+	from = notePath
+	if info, err := os.Stat(dest); (err == nil && info.IsDir()) || strings.HasSuffix(dest, "/") {
+		dest = filepath.Join(dest, filepath.Base(from))
+	} else if filepath.Ext(dest) == "" {
+		dest += ".md"
+	}
+
+	// グラフと同じ形式（BaseDirからのパス）にそろえる
+	absBase, err := filepath.Abs(graph.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(absBase, absDest)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("destination %s is outside of %s", dest, graph.BaseDir)
+	}
+	dest = filepath.Join(graph.BaseDir, rel)
+	if dest == from {
+		return nil, errors.New("source and destination are the same")
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return nil, fmt.Errorf("destination %s already exists", dest)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	plan := &NoteMovePlan{From: from, To: dest, edits: map[string]string{}}
+	rewrites := map[string][]linkRewrite{}
+
+	// 移動するノート自身の相対リンク（ディレクトリが変わる場合と自分へのリンク）
+	sameDir := filepath.Dir(dest) == filepath.Dir(from)
+	for _, link := range graph.Links[from] {
+		newRaw := link.Raw
+		if link.Kind == models.LinkMarkdown && link.Resolved() && (!sameDir || link.Path == from) {
+			target := link.Path
+			if target == from {
+				target = dest
+			}
+			newRaw = rewriteMarkdownTarget(link, relativeLinkPath(dest, target))
+		} else if link.Path == from {
+			newRaw = graph.rewriteWikiLink(link, from, dest)
+		}
+		if newRaw != link.Raw {
+			rewrites[from] = append(rewrites[from], linkRewrite{link: link, raw: newRaw})
+		}
+	}
+
+	// 他のノートからのリンク
+	for _, link := range graph.Backlinks(from) {
+		if link.Source == from {
+			continue
+		}
+		var newRaw string
+		if link.Kind == models.LinkMarkdown {
+			newRaw = rewriteMarkdownTarget(link, relativeLinkPath(link.Source, dest))
+		} else {
+			newRaw = graph.rewriteWikiLink(link, from, dest)
+		}
+		if newRaw != link.Raw {
+			rewrites[link.Source] = append(rewrites[link.Source], linkRewrite{link: link, raw: newRaw})
+		}
+	}
+
+	for path, list := range rewrites {
+		plan.edits[path] = applyLinkRewrites(graph.raws[path], list)
+		for _, rewrite := range list {
+			plan.Changes = append(plan.Changes, LinkChange{
+				Source: path,
+				Line:   rewrite.link.Line,
+				Old:    rewrite.link.Raw,
+				New:    rewrite.raw,
+			})
+		}
+	}
+	sort.Slice(plan.Changes, func(i, j int) bool {
+		if plan.Changes[i].Source != plan.Changes[j].Source {
+			return plan.Changes[i].Source < plan.Changes[j].Source
+		}
+		return plan.Changes[i].Line < plan.Changes[j].Line
+	})
+	return plan, nil
+}
+
+type linkRewrite struct {
+	link ResolvedLink
+	raw  string
+}
+
+// applyLinkRewrites replaces the links in raw, right to left within each
+// line so that offsets stay valid.
+func applyLinkRewrites(raw string, rewrites []linkRewrite) string {
+	lines := strings.Split(raw, "\n")
+	sort.Slice(rewrites, func(i, j int) bool {
+		if rewrites[i].link.Line != rewrites[j].link.Line {
+			return rewrites[i].link.Line < rewrites[j].link.Line
+		}
+		return rewrites[i].link.Offset > rewrites[j].link.Offset
+	})
+	for _, rewrite := range rewrites {
+		i := rewrite.link.Line - 1
+		line := lines[i]
+		start := rewrite.link.Offset
+		end := start + len(rewrite.link.Raw)
+		if end > len(line) || line[start:end] != rewrite.link.Raw {
+			continue
+		}
+		lines[i] = line[:start] + rewrite.raw + line[end:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rewriteWikiLink returns the wikilink pointing to dest instead of from.
+// Links written with an alias of the note are kept as they are. A path is
+// used when the link had one or when the new name would be ambiguous.
+func (graph *LinkGraph) rewriteWikiLink(link ResolvedLink, from, dest string) string {
+	target := link.Target
+	fromBase := filepath.Base(from)
+	fromName := strings.TrimSuffix(fromBase, filepath.Ext(fromBase))
+	targetName := strings.TrimSuffix(filepath.Base(target), filepath.Ext(target))
+	if !strings.Contains(target, "/") && !strings.EqualFold(targetName, fromName) {
+		// 別名でのリンク
+		return link.Raw
+	}
+
+	destBase := filepath.Base(dest)
+	destName := strings.TrimSuffix(destBase, filepath.Ext(destBase))
+	withExt := strings.EqualFold(filepath.Ext(target), ".md")
+
+	usePath := strings.Contains(target, "/")
+	for _, other := range graph.names[strings.ToLower(destName)] {
+		if other != from {
+			usePath = true
+		}
+	}
+
+	var newTarget string
+	if usePath {
+		rel, _ := filepath.Rel(graph.BaseDir, dest)
+		newTarget = filepath.ToSlash(rel)
+		if !withExt {
+			newTarget = strings.TrimSuffix(newTarget, filepath.Ext(newTarget))
+		}
+	} else {
+		newTarget = destName
+		if withExt {
+			newTarget = destBase
+		}
+	}
+	return rewriteWikiTarget(link.Raw, newTarget)
+}
+
+// rewriteWikiTarget replaces the target of a raw wikilink, keeping the
+// heading and alias as written.
+func rewriteWikiTarget(raw, target string) string {
+	open := strings.Index(raw, "[[")
+	inner := raw[open+2 : len(raw)-2]
+	end := strings.IndexAny(inner, "#|")
+	if end < 0 {
+		end = len(inner)
+	}
+	return raw[:open+2] + target + inner[end:] + "]]"
+}
+
+// rewriteMarkdownTarget replaces the path of a raw markdown link, keeping
+// the heading and title.
+func rewriteMarkdownTarget(link ResolvedLink, path string) string {
+	raw := link.Raw
+	prefixEnd := strings.Index(raw, "](") + 2
+	rest := raw[prefixEnd:]
+	var end int
+	var target string
+	if strings.HasPrefix(rest, "<") {
+		end = strings.Index(rest, ">") + 1
+		target = "<" + path + headingSuffix(link.Heading) + ">"
+	} else {
+		end = strings.IndexAny(rest, " \t)")
+		target = markdownPathEscaper.Replace(path) + headingSuffix(link.Heading)
+	}
+	return raw[:prefixEnd] + target + rest[end:]
+}
+
+func headingSuffix(heading string) string {
+	if heading == "" {
+		return ""
+	}
+	return "#" + heading
+}
+
+// relativeLinkPath returns the path of target relative to the note at source.
+func relativeLinkPath(source, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(source), target)
+	if err != nil {
+		return filepath.ToSlash(target)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ApplyNoteMove rewrites the links and moves the note. Every rewritten file
+// is first written to a temporary file; if anything fails, the files
+// already replaced are restored so that the vault is left as it was.
+// Changes are recorded in tx, which may be nil. The graph is updated to
+// reflect the move.
+func ApplyNoteMove(graph *LinkGraph, plan *NoteMovePlan, tx *Transaction) error {
+	paths := make([]string, 0, len(plan.edits))
+	for path := range plan.edits {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// 一時ファイルに書き出す
+	tmpPaths := map[string]string{}
+	cleanup := func() {
+		for _, tmp := range tmpPaths {
+			os.Remove(tmp)
+		}
+	}
+	for _, path := range paths {
+		tmp := path + ".krapp-tmp"
+		if err := os.WriteFile(tmp, []byte(plan.edits[path]), 0644); err != nil {
+			cleanup()
+			return fmt.Errorf("failed to write %s: %w", tmp, err)
+		}
+		tmpPaths[path] = tmp
+	}
+
+	// 置き換える（失敗したら元に戻す）
+	var replaced []string
+	rollback := func() {
+		for _, path := range replaced {
+			os.WriteFile(path, []byte(graph.raws[path]), 0644)
+		}
+		cleanup()
+	}
+	for _, path := range paths {
+		if err := tx.RecordOverwrite(path); err != nil {
+			rollback()
+			return fmt.Errorf("failed to record journal: %w", err)
+		}
+		if err := os.Rename(tmpPaths[path], path); err != nil {
+			rollback()
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+		delete(tmpPaths, path)
+		replaced = append(replaced, path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(plan.To), 0755); err != nil {
+		rollback()
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(plan.From, plan.To); err != nil {
+		rollback()
+		return fmt.Errorf("failed to move %s: %w", plan.From, err)
+	}
+	if err := tx.RecordMove(plan.From, plan.To); err != nil {
+		return fmt.Errorf("moved but failed to record journal: %w", err)
+	}
+
+	// グラフを更新する
+	delete(graph.paths, plan.From)
+	delete(graph.Notes, plan.From)
+	delete(graph.raws, plan.From)
+	delete(graph.Failures, plan.From)
+	graph.paths[plan.To] = true
+	for _, path := range paths {
+		if path != plan.From {
+			graph.loadNote(path)
+		}
+	}
+	graph.loadNote(plan.To)
+	graph.reindex()
+	return nil
+}
+
+// MoveNoteWithLinks moves the note at from to dest and rewrites every link
+// to it. See PlanNoteMove and ApplyNoteMove.
+func MoveNoteWithLinks(graph *LinkGraph, from, dest string, tx *Transaction) (*NoteMovePlan, error) {
+	plan, err := PlanNoteMove(graph, from, dest)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyNoteMove(graph, plan, tx); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
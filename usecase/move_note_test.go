@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+func readTestNote(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestMoveNoteWithLinks_Rename(t *testing.T) {
+	baseDir := setupLinkVault(t)
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	from := filepath.Join(baseDir, "projects", "krapp.md")
+
+	plan, err := MoveNoteWithLinks(graph, from, filepath.Join(baseDir, "projects", "krapp-go"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	to := filepath.Join(baseDir, "projects", "krapp-go.md")
+	if plan.To != to {
+		t.Errorf("expected destination %s, got %s", to, plan.To)
+	}
+	if _, err := os.Stat(to); err != nil {
+		t.Fatalf("note not moved: %v", err)
+	}
+
+	daily := readTestNote(t, filepath.Join(baseDir, "daily", "2025-06-02.md"))
+	if !strings.Contains(daily, "[[krapp-go]]") {
+		t.Errorf("wikilink not rewritten: %q", daily)
+	}
+	ideas := readTestNote(t, filepath.Join(baseDir, "ideas.md"))
+	if !strings.Contains(ideas, "[[Krapp CLI|ツール]]") {
+		t.Errorf("alias link should be kept: %q", ideas)
+	}
+	if !strings.Contains(ideas, "[related](projects/krapp-go.md)") {
+		t.Errorf("markdown link not rewritten: %q", ideas)
+	}
+	if broken := graph.BrokenLinks(); len(broken) != 1 || broken[0].Target != "missing" {
+		t.Errorf("expected only the existing broken link, got %+v", broken)
+	}
+}
+
+func TestMoveNoteWithLinks_OtherDirectory(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "a", "note.md"), "[図](../assets/img.png) [[other]] [自分](note.md#見出し)\n")
+	writeTestNote(t, filepath.Join(baseDir, "assets", "img.png"), "png")
+	writeTestNote(t, filepath.Join(baseDir, "other.md"), "[[a/note]] と [note](a/note.md)\n")
+	writeTestNote(t, filepath.Join(baseDir, "b", "note.md"), "同名のノート\n")
+	os.MkdirAll(filepath.Join(baseDir, "c", "d"), 0755)
+
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := MoveNoteWithLinks(graph, filepath.Join(baseDir, "a", "note.md"), filepath.Join(baseDir, "c", "d"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	moved := readTestNote(t, filepath.Join(baseDir, "c", "d", "note.md"))
+	if moved != "[図](../../assets/img.png) [[other]] [自分](note.md#見出し)\n" {
+		t.Errorf("links in moved note not rewritten: %q", moved)
+	}
+	other := readTestNote(t, filepath.Join(baseDir, "other.md"))
+	if other != "[[c/d/note]] と [note](c/d/note.md)\n" {
+		t.Errorf("backlinks not rewritten: %q", other)
+	}
+	if broken := graph.BrokenLinks(); len(broken) != 0 {
+		t.Errorf("expected no broken links, got %+v", broken)
+	}
+}
+
+func TestPlanNoteMove_Errors(t *testing.T) {
+	baseDir := setupLinkVault(t)
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	from := filepath.Join(baseDir, "projects", "krapp.md")
+
+	if _, err := PlanNoteMove(graph, from, filepath.Join(baseDir, "ideas.md")); err == nil {
+		t.Errorf("expected error for existing destination")
+	}
+	if _, err := PlanNoteMove(graph, from, filepath.Join(t.TempDir(), "krapp.md")); err == nil {
+		t.Errorf("expected error for destination outside of the vault")
+	}
+	if _, err := PlanNoteMove(graph, filepath.Join(baseDir, "nothing.md"), filepath.Join(baseDir, "x.md")); err == nil {
+		t.Errorf("expected error for unknown note")
+	}
+}
+
+func TestApplyNoteMove_Undo(t *testing.T) {
+	baseDir := setupLinkVault(t)
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	from := filepath.Join(baseDir, "projects", "krapp.md")
+	ideasPath := filepath.Join(baseDir, "ideas.md")
+	before := readTestNote(t, ideasPath)
+
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	tx := journal.Begin("mv")
+	if _, err := MoveNoteWithLinks(graph, from, filepath.Join(baseDir, "archive", "krapp.md"), tx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if readTestNote(t, ideasPath) == before {
+		t.Fatalf("expected ideas.md to be rewritten")
+	}
+
+	if err := journal.Undo(tx, nil); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if _, err := os.Stat(from); err != nil {
+		t.Errorf("note should be back at %s", from)
+	}
+	if after := readTestNote(t, ideasPath); after != before {
+		t.Errorf("links should be restored, got %q", after)
+	}
+}
+
+func TestApplyOrganizePlanWithLinks(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "n1.md"), "---\nlabel: diary\n---\n本文\n")
+	writeTestNote(t, filepath.Join(baseDir, "index.md"), "[[n1]] [n1](n1.md)\n")
+
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	note := *graph.Notes[filepath.Join(baseDir, "n1.md")]
+	plan := PlanOrganizeByLabel([]models.Note{note}, baseDir, LabelDirectoryMap{"diary": "diary"})
+
+	results := ApplyOrganizePlanWithLinks(plan, graph, nil)
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[0].Links != 1 {
+		t.Errorf("expected 1 rewritten link, got %d", results[0].Links)
+	}
+	index := readTestNote(t, filepath.Join(baseDir, "index.md"))
+	if index != "[[n1]] [n1](diary/n1.md)\n" {
+		t.Errorf("unexpected index.md: %q", index)
+	}
+}
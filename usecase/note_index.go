@@ -0,0 +1,222 @@
+package usecase
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// NoteIndex holds every note found under a vault directory.
+type NoteIndex struct {
+	BaseDir string
+	Notes   []*models.Note
+	// Failures はパースに失敗したファイルとそのエラー
+	Failures map[string]error
+}
+
+// NoteQuery describes filters and ordering for NoteIndex.Find.
+// Zero values mean "no filter".
+type NoteQuery struct {
+	Status  string
+	Label   string
+	Tags    []string // すべてのタグ（子のタグを含む）を持つノートのみ
+	Since   time.Time
+	Until   time.Time
+	SortBy  string // created, status, label, path
+	Reverse bool
+}
+
+// BuildNoteIndex walks baseDir and loads every markdown note in it.
+// Hidden directories such as .git or .obsidian are skipped. Files that
+// cannot be parsed are recorded in Failures instead of aborting the walk.
+func BuildNoteIndex(baseDir string) (*NoteIndex, error) {
+	return LoadNotesInDir(baseDir, true)
+}
+
+// LoadNotesInDir loads the markdown notes directly in dir, or in the whole
+// tree below it when recursive is true.
+func LoadNotesInDir(dir string, recursive bool) (*NoteIndex, error) {
+	index := &NoteIndex{
+		BaseDir:  dir,
+		Notes:    []*models.Note{},
+		Failures: map[string]error{},
+	}
+	err := walkNoteFiles(dir, recursive, func(path string, d fs.DirEntry) error {
+		note, err := models.LoadNoteFromFile(path)
+		if err != nil {
+			index.Failures[path] = err
+			return nil
+		}
+		index.Notes = append(index.Notes, note)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	sort.Slice(index.Notes, func(i, j int) bool {
+		return index.Notes[i].FilePath < index.Notes[j].FilePath
+	})
+	return index, nil
+}
+
+// walkNoteFiles calls fn for every markdown note under dir, skipping hidden
+// files and directories. Subdirectories are entered only when recursive is true.
+func walkNoteFiles(dir string, recursive bool, fn func(path string, d fs.DirEntry) error) error {
+	return walkVaultFiles(dir, recursive, func(path string, d fs.DirEntry) error {
+		if !isNoteFile(d.Name()) {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+// walkVaultFiles is like walkNoteFiles but also visits attachments and
+// other non-hidden files.
+func walkVaultFiles(dir string, recursive bool, fn func(path string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == dir {
+				return nil
+			}
+			if !recursive || strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+// isNoteFile reports whether name looks like a markdown note.
+func isNoteFile(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	return strings.ToLower(filepath.Ext(name)) == ".md"
+}
+
+// Find returns the notes matching query, ordered by query.SortBy.
+func (index *NoteIndex) Find(query NoteQuery) ([]*models.Note, error) {
+	less, err := noteSortFunc(query.SortBy, query.Reverse)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []*models.Note{}
+	for _, note := range index.Notes {
+		if query.Matches(note) {
+			matched = append(matched, note)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return less(matched[i], matched[j])
+	})
+	return matched, nil
+}
+
+// Matches reports whether the note satisfies every filter in the query.
+func (query NoteQuery) Matches(note *models.Note) bool {
+	if query.Status != "" {
+		status, err := note.FrontMatter.Status()
+		if err != nil || status != query.Status {
+			return false
+		}
+	}
+	if query.Label != "" {
+		label, err := note.FrontMatter.Label()
+		if err != nil || label != query.Label {
+			return false
+		}
+	}
+	if len(query.Tags) > 0 && !hasAllTags(note.AllTags(), query.Tags) {
+		return false
+	}
+	if !query.Since.IsZero() || !query.Until.IsZero() {
+		created, err := note.FrontMatter.Created()
+		if err != nil {
+			return false
+		}
+		if !query.Since.IsZero() && created.Before(query.Since) {
+			return false
+		}
+		if !query.Until.IsZero() && created.After(query.Until) {
+			return false
+		}
+	}
+	return true
+}
+
+// noteSortFunc returns the ordering for the given sort key, descending when
+// reverse is true. Notes without the value come last in both directions.
+func noteSortFunc(key string, reverse bool) (func(a, b *models.Note) bool, error) {
+	byPath := func(a, b *models.Note) bool {
+		if reverse {
+			return a.FilePath > b.FilePath
+		}
+		return a.FilePath < b.FilePath
+	}
+	switch key {
+	case "", "path":
+		return byPath, nil
+	case "created":
+		return func(a, b *models.Note) bool {
+			createdA, errA := a.FrontMatter.Created()
+			createdB, errB := b.FrontMatter.Created()
+			// createdのないノートは末尾に回す
+			if errA != nil || errB != nil {
+				if errA == nil {
+					return true
+				}
+				if errB == nil {
+					return false
+				}
+				return byPath(a, b)
+			}
+			if createdA.Equal(createdB) {
+				return byPath(a, b)
+			}
+			if reverse {
+				return createdA.After(createdB)
+			}
+			return createdA.Before(createdB)
+		}, nil
+	case "status", "label":
+		value := func(n *models.Note) string {
+			var v string
+			if key == "status" {
+				v, _ = n.FrontMatter.Status()
+			} else {
+				v, _ = n.FrontMatter.Label()
+			}
+			return v
+		}
+		return func(a, b *models.Note) bool {
+			va, vb := value(a), value(b)
+			if va == vb {
+				return byPath(a, b)
+			}
+			// 値のないノートは末尾に回す
+			if va == "" || vb == "" {
+				return vb == ""
+			}
+			if reverse {
+				return va > vb
+			}
+			return va < vb
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown sort key: %s", key)
+	}
+}
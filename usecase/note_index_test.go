@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestNote(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write note: %v", err)
+	}
+}
+
+func setupTestVault(t *testing.T) string {
+	t.Helper()
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "inbox", "a.md"), "---\ncreated: \"2025-06-03\"\nstatus: new\ntags: [meeting, work]\n---\nA")
+	writeTestNote(t, filepath.Join(baseDir, "inbox", "b.md"), "---\ncreated: \"2025-05-20\"\nstatus: new\ntags: meeting\n---\nB")
+	writeTestNote(t, filepath.Join(baseDir, "daily", "c.md"), "---\ncreated: \"2025-06-10\"\nstatus: done\nlabel: diary\n---\nC #work/review")
+	writeTestNote(t, filepath.Join(baseDir, "daily", "d.md"), "no frontmatter")
+	writeTestNote(t, filepath.Join(baseDir, "daily", "memo.txt"), "not a note")
+	writeTestNote(t, filepath.Join(baseDir, ".git", "e.md"), "hidden")
+	writeTestNote(t, filepath.Join(baseDir, "broken.md"), "---\ncreated: [\n")
+	return baseDir
+}
+
+func TestBuildNoteIndex(t *testing.T) {
+	baseDir := setupTestVault(t)
+	index, err := BuildNoteIndex(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(index.Notes) != 4 {
+		t.Errorf("expected 4 notes, got %d", len(index.Notes))
+	}
+	if _, ok := index.Failures[filepath.Join(baseDir, "broken.md")]; !ok {
+		t.Errorf("expected broken.md to be recorded as failure")
+	}
+}
+
+func TestNoteIndexFind(t *testing.T) {
+	baseDir := setupTestVault(t)
+	index, err := BuildNoteIndex(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		query    NoteQuery
+		expected []string
+	}{
+		{"status", NoteQuery{Status: "new", SortBy: "created"}, []string{"inbox/b.md", "inbox/a.md"}},
+		{"tag", NoteQuery{Tags: []string{"meeting", "work"}}, []string{"inbox/a.md"}},
+		{"inline child tag", NoteQuery{Tags: []string{"#work"}}, []string{"daily/c.md", "inbox/a.md"}},
+		{"label", NoteQuery{Label: "diary"}, []string{"daily/c.md"}},
+		{"since", NoteQuery{Since: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), SortBy: "created", Reverse: true}, []string{"daily/c.md", "inbox/a.md"}},
+		{"created sort puts missing last", NoteQuery{SortBy: "created"}, []string{"inbox/b.md", "inbox/a.md", "daily/c.md", "daily/d.md"}},
+		{"reverse keeps missing last", NoteQuery{SortBy: "created", Reverse: true}, []string{"daily/c.md", "inbox/a.md", "inbox/b.md", "daily/d.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := index.Find(tt.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(notes) != len(tt.expected) {
+				t.Fatalf("expected %d notes, got %d", len(tt.expected), len(notes))
+			}
+			for i, note := range notes {
+				expected := filepath.Join(baseDir, tt.expected[i])
+				if note.FilePath != expected {
+					t.Errorf("notes[%d] = %s, want %s", i, note.FilePath, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNoteIndexFind_UnknownSortKey(t *testing.T) {
+	index := &NoteIndex{}
+	if _, err := index.Find(NoteQuery{SortBy: "unknown"}); err == nil {
+		t.Error("expected error for unknown sort key")
+	}
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// TemplateConfig is implemented by configs that provide a directory of body
+// templates. It is optional: without it notes are created with an empty body.
+type TemplateConfig interface {
+	GetTemplateDir() string
+}
+
+// NoteTemplateData is the data available in note templates, e.g.
+// {{.Date}}, {{.Weekday}}, {{.Title}} or {{.YesterdayLink}}.
+type NoteTemplateData struct {
+	Now           time.Time
+	Date          string // 2006-01-02
+	Time          string // 15:04
+	Year          string
+	Month         string
+	Day           string
+	Weekday       string // Monday
+	WeekdayJa     string // 月
+	Title         string
+	Yesterday     string // 前日の日付
+	Tomorrow      string // 翌日の日付
+	YesterdayLink string // [[前日のデイリーノート]]
+	TomorrowLink  string // [[翌日のデイリーノート]]
+	Week          string // ISO週番号（01〜53）
+	WeekYear      string // ISO週の年
+	ISOWeek       string // 2006-W01
+	PeriodStart   string // 定期ノートの期間の初日
+	PeriodEnd     string // 定期ノートの期間の最終日
+	PreviousLink  string // [[前の期間のノート]]
+	NextLink      string // [[次の期間のノート]]
+}
+
+var japaneseWeekdays = []string{"日", "月", "火", "水", "木", "金", "土"}
+
+// NewNoteTemplateData builds template data for a note created at now.
+func NewNoteTemplateData(now time.Time, title string) NoteTemplateData {
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	tomorrow := now.AddDate(0, 0, 1).Format("2006-01-02")
+	weekYear, week := now.ISOWeek()
+	return NoteTemplateData{
+		Now:           now,
+		Date:          now.Format("2006-01-02"),
+		Time:          now.Format("15:04"),
+		Year:          now.Format("2006"),
+		Month:         now.Format("01"),
+		Day:           now.Format("02"),
+		Weekday:       now.Weekday().String(),
+		WeekdayJa:     japaneseWeekdays[now.Weekday()],
+		Title:         title,
+		Yesterday:     yesterday,
+		Tomorrow:      tomorrow,
+		YesterdayLink: "[[" + yesterday + "]]",
+		TomorrowLink:  "[[" + tomorrow + "]]",
+		Week:          fmt.Sprintf("%02d", week),
+		WeekYear:      fmt.Sprintf("%04d", weekYear),
+		ISOWeek:       fmt.Sprintf("%04d-W%02d", weekYear, week),
+		PeriodStart:   now.Format("2006-01-02"),
+		PeriodEnd:     now.Format("2006-01-02"),
+		PreviousLink:  "[[" + yesterday + "]]",
+		NextLink:      "[[" + tomorrow + "]]",
+	}
+}
+
+// resolveTemplatePath returns the template file for name in the template
+// directory of cfg. When name is empty, defaultName is used and a missing
+// file is not an error; an empty path is returned instead.
+func resolveTemplatePath(cfg any, name, defaultName string) (string, error) {
+	optional := name == ""
+	if optional {
+		name = defaultName
+	}
+
+	var dir string
+	if templateCfg, ok := cfg.(TemplateConfig); ok {
+		dir = templateCfg.GetTemplateDir()
+	}
+	if dir == "" {
+		if optional {
+			return "", nil
+		}
+		return "", errors.New("template_dir is not configured")
+	}
+
+	path := filepath.Join(dir, name)
+	if filepath.Ext(name) == "" {
+		path += ".md"
+	}
+	if _, err := os.Stat(path); err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("template %s not found: %w", name, err)
+	}
+	return path, nil
+}
+
+// RenderNoteTemplate executes the template file at path and splits the
+// result into frontmatter and body.
+func RenderNoteTemplate(path string, data NoteTemplateData) (models.FrontMatter, string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+	rendered, err := executeTemplate(filepath.Base(path), string(raw), data)
+	if err != nil {
+		return nil, "", err
+	}
+	note, err := models.ParseNote(rendered)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse template %s: %w", path, err)
+	}
+	return note.FrontMatter, note.Content, nil
+}
+
+// ExpandFrontMatterTemplate expands template expressions in the string
+// values of fm, including strings inside lists and nested maps.
+func ExpandFrontMatterTemplate(fm map[string]any, data NoteTemplateData) (models.FrontMatter, error) {
+	expanded := models.FrontMatter{}
+	for key, value := range fm {
+		v, err := expandTemplateValue(key, value, data)
+		if err != nil {
+			return nil, err
+		}
+		expanded[key] = v
+	}
+	return expanded, nil
+}
+
+func expandTemplateValue(name string, value any, data NoteTemplateData) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		return executeTemplate(name, v, data)
+	case []string:
+		result := make([]string, len(v))
+		for i, item := range v {
+			expanded, err := expandTemplateValue(name, item, data)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = expanded.(string)
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			expanded, err := expandTemplateValue(name, item, data)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = expanded
+		}
+		return result, nil
+	case map[string]any:
+		result := map[string]any{}
+		for key, item := range v {
+			expanded, err := expandTemplateValue(name, item, data)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = expanded
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+func executeTemplate(name, text string, data NoteTemplateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// buildNoteFromTemplate assembles the frontmatter and body of a new note:
+// created date, then the frontmatter template from config, then the template
+// file (if any), each with template expressions expanded.
+func buildNoteFromTemplate(cfg any, fmTemplate map[string]any, templateName, defaultName string, data NoteTemplateData) (models.FrontMatter, string, error) {
+	fm := models.FrontMatter{}
+
+	// 作成日時を設定
+	fm.SetCreated(data.Now)
+
+	// テンプレートの属性を追加
+	if fmTemplate != nil {
+		expanded, err := ExpandFrontMatterTemplate(fmTemplate, data)
+		if err != nil {
+			return nil, "", err
+		}
+		for key, value := range expanded {
+			fm[key] = value
+		}
+	}
+
+	// 本文テンプレート
+	path, err := resolveTemplatePath(cfg, templateName, defaultName)
+	if err != nil {
+		return nil, "", err
+	}
+	if path == "" {
+		return fm, "", nil
+	}
+	fileFM, body, err := RenderNoteTemplate(path, data)
+	if err != nil {
+		return nil, "", err
+	}
+	for key, value := range fileFM {
+		fm[key] = value
+	}
+	return fm, body, nil
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+type testTemplateConfig struct {
+	testDailyConfig
+	inboxDir      string
+	inboxTemplate map[string]any
+	templateDir   string
+}
+
+func (c *testTemplateConfig) GetInboxDir() string              { return c.inboxDir }
+func (c *testTemplateConfig) GetInboxTemplate() map[string]any { return c.inboxTemplate }
+func (c *testTemplateConfig) GetTemplateDir() string           { return c.templateDir }
+
+func newTestTemplateConfig(t *testing.T) *testTemplateConfig {
+	t.Helper()
+	baseDir := t.TempDir()
+	return &testTemplateConfig{
+		testDailyConfig: testDailyConfig{baseDir: baseDir, dailyNoteDir: "daily"},
+		inboxDir:        "inbox",
+		templateDir:     filepath.Join(baseDir, "templates"),
+	}
+}
+
+func TestCreateDailyNote_BodyTemplate(t *testing.T) {
+	cfg := newTestTemplateConfig(t)
+	cfg.dailyTemplate = map[string]any{
+		"title": "{{.Date}}の日記",
+		"tags":  []any{"daily", "{{.Year}}"},
+	}
+	writeTestNote(t, filepath.Join(cfg.templateDir, "daily.md"), "---\nweekday: \"{{.Weekday}}\"\n---\n# {{.Date}} ({{.WeekdayJa}})\n\n前日: {{.YesterdayLink}}\n翌日: {{.TomorrowLink}}\n")
+
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	path, err := CreateDailyNote(cfg, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	note, err := models.LoadNoteFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+
+	if !strings.Contains(note.Content, "# 2025-06-02 (月)") {
+		t.Errorf("body template not expanded: %q", note.Content)
+	}
+	if !strings.Contains(note.Content, "前日: [[2025-06-01]]") || !strings.Contains(note.Content, "翌日: [[2025-06-03]]") {
+		t.Errorf("links not expanded: %q", note.Content)
+	}
+	if note.FrontMatter["title"] != "2025-06-02の日記" {
+		t.Errorf("frontmatter template not expanded: %v", note.FrontMatter["title"])
+	}
+	if note.FrontMatter["weekday"] != "Monday" {
+		t.Errorf("template file frontmatter not merged: %v", note.FrontMatter["weekday"])
+	}
+	if tags := note.FrontMatter.Tags(); len(tags) != 2 || tags[1] != "2025" {
+		t.Errorf("list values not expanded: %v", tags)
+	}
+}
+
+func TestCreateInboxNote_NamedTemplate(t *testing.T) {
+	cfg := newTestTemplateConfig(t)
+	writeTestNote(t, filepath.Join(cfg.templateDir, "meeting.md"), "# {{.Title}}\n\n## 参加者\n")
+
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	path, err := CreateInboxNoteWithTemplate(cfg, now, "定例", "meeting", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read note: %v", err)
+	}
+	if !strings.Contains(string(data), "# 定例\n\n## 参加者") {
+		t.Errorf("named template not applied: %q", data)
+	}
+
+	if _, err := CreateInboxNoteWithTemplate(cfg, now, "other", "missing", nil); err == nil {
+		t.Error("expected error for missing named template")
+	}
+}
+
+func TestCreateInboxNote_NoTemplateDir(t *testing.T) {
+	cfg := &testInboxConfig{baseDir: t.TempDir(), inboxDir: "inbox"}
+	now := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	if _, err := CreateInboxNote(cfg, now, "plain"); err != nil {
+		t.Fatalf("default template should be optional: %v", err)
+	}
+	if _, err := CreateInboxNoteWithTemplate(cfg, now, "plain2", "meeting", nil); err == nil {
+		t.Error("expected error when template_dir is not configured")
+	}
+}
+
+func TestRenderNoteTemplate_Error(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.md")
+	writeTestNote(t, path, "{{.Unknown}}")
+	if _, _, err := RenderNoteTemplate(path, NewNoteTemplateData(time.Now(), "")); err == nil {
+		t.Error("expected error for unknown template variable")
+	}
+}
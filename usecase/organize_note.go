@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
@@ -11,52 +12,202 @@ import (
 // ラベルとディレクトリのマッピング
 type LabelDirectoryMap map[string]string
 
-func OrganizeNotesByCreated(notes []models.Note, baseDirectory string) {
+// OrganizeMove is a single entry of an organize plan. A move with a
+// non-empty SkipReason is reported but not applied.
+type OrganizeMove struct {
+	Note        models.Note
+	Source      string
+	Destination string // 移動先のファイルパス
+	Reason      string
+	SkipReason  string
+}
+
+// OrganizeResult is the outcome of applying a single OrganizeMove.
+type OrganizeResult struct {
+	Move  OrganizeMove
+	Moved bool
+	Links int // 書き換えたリンクの数
+	Err   error
+}
+
+// OrganizeSummary counts the results of ApplyOrganizePlan.
+type OrganizeSummary struct {
+	Moved   int
+	Skipped int
+	Failed  int
+}
+
+// PlanOrganizeByCreated plans moving each note into baseDirectory/YYYY/MM
+// according to its created date, newest first.
+func PlanOrganizeByCreated(notes []models.Note, baseDirectory string) []OrganizeMove {
 	// Sort notes by CreatedAt in descending order
-	sort.Slice(notes, func(i, j int) bool {
-		createdI, errI := notes[i].FrontMatter.Created()
-		createdJ, errJ := notes[j].FrontMatter.Created()
+	sorted := append([]models.Note(nil), notes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		createdI, errI := sorted[i].FrontMatter.Created()
+		createdJ, errJ := sorted[j].FrontMatter.Created()
 		if errI != nil || errJ != nil {
 			return false // Treat invalid dates as equal
 		}
 		return createdI.After(createdJ) // Descending order
 	})
-	for _, note := range notes {
+
+	plan := make([]OrganizeMove, 0, len(sorted))
+	planned := map[string]bool{}
+	for _, note := range sorted {
+		move := OrganizeMove{Note: note, Source: note.FilePath}
 		created, err := note.FrontMatter.Created()
 		if err != nil {
-			continue // Skip notes without a valid created date
-		}
-		path := filepath.Join(created.Format("2006"), created.Format("01"))
-		fullPath := filepath.Join(baseDirectory, path)
-		err = os.MkdirAll(fullPath, 0755)
-		if err != nil {
-			continue // Skip notes if the directory cannot be created
-		}
-		err = note.MoveFile(fullPath)
-		if err != nil {
-			continue // Skip notes that cannot be moved
+			move.SkipReason = err.Error()
+			plan = append(plan, move)
+			continue
 		}
+		dir := filepath.Join(baseDirectory, created.Format("2006"), created.Format("01"))
+		move.Reason = "created: " + created.Format("2006-01-02")
+		plan = append(plan, planDestination(move, dir, planned))
 	}
+	return plan
 }
 
-func OrganizeNotesByLabel(notes []models.Note, baseDirectory string, labelDirectoryMap LabelDirectoryMap) {
-	// Sort notes by label
+// PlanOrganizeByLabel plans moving each note into the directory mapped to
+// its label in labelDirectoryMap.
+func PlanOrganizeByLabel(notes []models.Note, baseDirectory string, labelDirectoryMap LabelDirectoryMap) []OrganizeMove {
+	plan := make([]OrganizeMove, 0, len(notes))
+	planned := map[string]bool{}
 	for _, note := range notes {
+		move := OrganizeMove{Note: note, Source: note.FilePath}
 		label, err := note.FrontMatter.Label()
 		if err != nil {
-			continue // Skip notes without a valid label
+			move.SkipReason = err.Error()
+			plan = append(plan, move)
+			continue
 		}
 		if label == "" {
-			continue // Skip notes without a label
+			move.SkipReason = "label is empty"
+			plan = append(plan, move)
+			continue
 		}
 		path, ok := labelDirectoryMap[label]
 		if !ok {
-			continue // Skip notes with labels not in the map
+			move.SkipReason = fmt.Sprintf("no directory mapped for label %q", label)
+			plan = append(plan, move)
+			continue
 		}
-		fullPath := filepath.Join(baseDirectory, path)
-		err = note.MoveFile(fullPath)
-		if err != nil {
-			continue // Skip notes that cannot be moved
+		move.Reason = "label: " + label
+		plan = append(plan, planDestination(move, filepath.Join(baseDirectory, path), planned))
+	}
+	return plan
+}
+
+// planDestination fills in the destination of a move into dir and marks
+// moves that would be no-ops or overwrite an existing file as skipped.
+// planned holds the destinations of the earlier moves of the same plan, so
+// that two notes with the same name are not moved to the same file.
+func planDestination(move OrganizeMove, dir string, planned map[string]bool) OrganizeMove {
+	move.Destination = filepath.Join(dir, filepath.Base(move.Source))
+	if filepath.Clean(move.Destination) == filepath.Clean(move.Source) {
+		move.SkipReason = "already in place"
+		return move
+	}
+	if _, err := os.Lstat(move.Destination); err == nil {
+		move.SkipReason = "destination already exists"
+		return move
+	}
+	if planned[filepath.Clean(move.Destination)] {
+		move.SkipReason = "destination already planned"
+		return move
+	}
+	planned[filepath.Clean(move.Destination)] = true
+	return move
+}
+
+// ApplyOrganizePlan performs every move of the plan that is not skipped and
+// returns one result per entry. A failed move does not stop the others.
+// Moves are recorded in tx, which may be nil.
+func ApplyOrganizePlan(plan []OrganizeMove, tx *Transaction) []OrganizeResult {
+	return ApplyOrganizePlanWithLinks(plan, nil, tx)
+}
+
+// ApplyOrganizePlanWithLinks is like ApplyOrganizePlan but also rewrites
+// the links to each moved note found in graph. Notes that are not in graph,
+// or every note when graph is nil, are moved as plain files.
+func ApplyOrganizePlanWithLinks(plan []OrganizeMove, graph *LinkGraph, tx *Transaction) []OrganizeResult {
+	results := make([]OrganizeResult, 0, len(plan))
+	for _, move := range plan {
+		result := OrganizeResult{Move: move}
+		if move.SkipReason != "" {
+			results = append(results, result)
+			continue
+		}
+		if graph.hasNote(move.Source) {
+			notePlan, err := PlanNoteMove(graph, move.Source, move.Destination)
+			if err == nil {
+				err = ApplyNoteMove(graph, notePlan, tx)
+			}
+			if err != nil {
+				result.Err = err
+			} else {
+				result.Moved = true
+				result.Links = len(notePlan.Changes)
+			}
+			results = append(results, result)
+			continue
+		}
+		dir := filepath.Dir(move.Destination)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			result.Err = fmt.Errorf("failed to create directory %s: %w", dir, err)
+			results = append(results, result)
+			continue
 		}
+		// 計画の後にできたファイルも上書きしない
+		if _, err := os.Lstat(move.Destination); err == nil {
+			result.Err = fmt.Errorf("destination %s already exists", move.Destination)
+			results = append(results, result)
+			continue
+		}
+		note := move.Note
+		if err := note.MoveFile(dir); err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		result.Moved = true
+		if err := tx.RecordMove(move.Source, note.FilePath); err != nil {
+			result.Err = fmt.Errorf("moved but failed to record journal: %w", err)
+		}
+		results = append(results, result)
 	}
+	return results
+}
+
+// hasNote reports whether path is a note of graph, which may be nil.
+func (graph *LinkGraph) hasNote(path string) bool {
+	if graph == nil {
+		return false
+	}
+	_, ok := graph.lookupPath(path)
+	return ok
+}
+
+// SummarizeOrganizeResults counts moved, skipped and failed entries.
+func SummarizeOrganizeResults(results []OrganizeResult) OrganizeSummary {
+	var summary OrganizeSummary
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			summary.Failed++
+		case result.Moved:
+			summary.Moved++
+		default:
+			summary.Skipped++
+		}
+	}
+	return summary
+}
+
+func OrganizeNotesByCreated(notes []models.Note, baseDirectory string) []OrganizeResult {
+	return ApplyOrganizePlan(PlanOrganizeByCreated(notes, baseDirectory), nil)
+}
+
+func OrganizeNotesByLabel(notes []models.Note, baseDirectory string, labelDirectoryMap LabelDirectoryMap) []OrganizeResult {
+	return ApplyOrganizePlan(PlanOrganizeByLabel(notes, baseDirectory, labelDirectoryMap), nil)
 }
@@ -64,3 +64,113 @@ func TestOrganizeNotesByLabel(t *testing.T) {
 		t.Fatalf("note3 should not be moved: %v", err)
 	}
 }
+
+func TestPlanOrganizeByLabel_SkipReasons(t *testing.T) {
+	baseDir := t.TempDir()
+	labelMap := LabelDirectoryMap{"diary": "diary"}
+
+	inPlace := createTempNote(t, baseDir, "in-place.md", models.FrontMatter{"label": "diary"})
+	os.MkdirAll(filepath.Join(baseDir, "diary"), 0755)
+	os.Rename(inPlace.FilePath, filepath.Join(baseDir, "diary", "in-place.md"))
+	inPlace.FilePath = filepath.Join(baseDir, "diary", "in-place.md")
+
+	noLabel := createTempNote(t, baseDir, "no-label.md", models.FrontMatter{})
+	unmapped := createTempNote(t, baseDir, "unmapped.md", models.FrontMatter{"label": "other"})
+	movable := createTempNote(t, baseDir, "movable.md", models.FrontMatter{"label": "diary"})
+
+	plan := PlanOrganizeByLabel([]models.Note{inPlace, noLabel, unmapped, movable}, baseDir, labelMap)
+	if len(plan) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(plan))
+	}
+	for i, move := range plan[:3] {
+		if move.SkipReason == "" {
+			t.Errorf("plan[%d] (%s) should be skipped", i, move.Source)
+		}
+	}
+	if plan[3].SkipReason != "" || plan[3].Destination != filepath.Join(baseDir, "diary", "movable.md") {
+		t.Errorf("unexpected plan for movable note: %+v", plan[3])
+	}
+
+	// 計画を作るだけではファイルは動かない
+	if _, err := os.Stat(movable.FilePath); err != nil {
+		t.Fatalf("planning should not move files: %v", err)
+	}
+}
+
+func TestApplyOrganizePlan_ReportsFailures(t *testing.T) {
+	baseDir := t.TempDir()
+	note := createTempNote(t, baseDir, "gone.md", models.FrontMatter{"created": "2025-06-01"})
+	plan := PlanOrganizeByCreated([]models.Note{note}, baseDir)
+	os.Remove(note.FilePath)
+
+	results := ApplyOrganizePlan(plan, nil)
+	summary := SummarizeOrganizeResults(results)
+	if summary.Failed != 1 || summary.Moved != 0 {
+		t.Errorf("expected 1 failure, got %+v", summary)
+	}
+	if results[0].Err == nil {
+		t.Errorf("expected error to be reported")
+	}
+}
+
+func TestPlanOrganizeByCreated_DuplicateDestination(t *testing.T) {
+	baseDir := t.TempDir()
+	os.MkdirAll(filepath.Join(baseDir, "a"), 0755)
+	os.MkdirAll(filepath.Join(baseDir, "b"), 0755)
+	first := createTempNote(t, baseDir, filepath.Join("a", "x.md"), models.FrontMatter{"created": "2025-06-02"})
+	second := createTempNote(t, baseDir, filepath.Join("b", "x.md"), models.FrontMatter{"created": "2025-06-01"})
+
+	plan := PlanOrganizeByCreated([]models.Note{first, second}, baseDir)
+	if plan[0].SkipReason != "" || plan[1].SkipReason != "destination already planned" {
+		t.Fatalf("the second note should be skipped: %+v", plan)
+	}
+
+	// 計画の後に移動先ができても上書きしない
+	dest := filepath.Join(baseDir, "2025", "06", "x.md")
+	os.MkdirAll(filepath.Dir(dest), 0755)
+	os.WriteFile(dest, []byte("keep"), 0644)
+	results := ApplyOrganizePlan(plan, nil)
+	if results[0].Err == nil || results[0].Moved {
+		t.Errorf("existing destination should not be overwritten: %+v", results[0])
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "keep" {
+		t.Errorf("destination was overwritten: %q", data)
+	}
+}
+
+func TestApplyOrganizePlanWithLinks_KeepsExistingDestination(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "inbox", "a", "x.md"), "---\ncreated: \"2025-06-01\"\n---\nA")
+	writeTestNote(t, filepath.Join(baseDir, "inbox", "b", "x.md"), "---\ncreated: \"2025-06-02\"\n---\nB")
+
+	index, err := LoadNotesInDir(filepath.Join(baseDir, "inbox"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := make([]models.Note, len(index.Notes))
+	for i, note := range index.Notes {
+		notes[i] = *note
+	}
+	plan := PlanOrganizeByCreated(notes, baseDir)
+	graph, err := BuildLinkGraph(baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 計画の後に移動先ができた場合も、上書きせずに失敗として報告する
+	dest := filepath.Join(baseDir, "2025", "06", "x.md")
+	writeTestNote(t, dest, "keep")
+	results := ApplyOrganizePlanWithLinks(plan, graph, nil)
+	summary := SummarizeOrganizeResults(results)
+	if summary.Moved != 0 || summary.Failed != 1 || summary.Skipped != 1 {
+		t.Errorf("unexpected summary %+v: %+v", summary, results)
+	}
+	if got := readTestNote(t, dest); got != "keep" {
+		t.Errorf("destination was overwritten: %q", got)
+	}
+	for _, name := range []string{"a", "b"} {
+		if _, err := os.Stat(filepath.Join(baseDir, "inbox", name, "x.md")); err != nil {
+			t.Errorf("%s/x.md should stay: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// デフォルトのパスパターン
+const (
+	DefaultDailyPathPattern     = "%Y/%m/%Y-%m-%d"
+	DefaultInboxFilenamePattern = "%Y-%m-%d-{{.Title}}"
+	DefaultIssueFilenamePattern = "%Y-%m-%d-issue-{{.Number}}-{{.Slug}}"
+	maxSlugLength               = 50
+	crockfordBase32             = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// PathPatternData is the data a path pattern is rendered with. Besides the
+// fields, the methods Year, Month, Day, Hour, Minute, Second, Date,
+// WeekYear, Week, Title and Slug can be used in Go template patterns.
+type PathPatternData struct {
+	Time   time.Time
+	Number int    // issue番号など
+	ULID   string // 時刻順に並ぶ一意なID
+
+	title string
+}
+
+// NewPathPatternData returns the data for a note created at t with title.
+func NewPathPatternData(t time.Time, title string) PathPatternData {
+	return PathPatternData{Time: t, ULID: newULID(t), title: title}
+}
+
+func (d PathPatternData) Year() string   { return d.Time.Format("2006") }
+func (d PathPatternData) Month() string  { return d.Time.Format("01") }
+func (d PathPatternData) Day() string    { return d.Time.Format("02") }
+func (d PathPatternData) Hour() string   { return d.Time.Format("15") }
+func (d PathPatternData) Minute() string { return d.Time.Format("04") }
+func (d PathPatternData) Second() string { return d.Time.Format("05") }
+func (d PathPatternData) Date() string   { return d.Time.Format("2006-01-02") }
+
+// WeekYear returns the ISO 8601 week-numbering year.
+func (d PathPatternData) WeekYear() string {
+	year, _ := d.Time.ISOWeek()
+	return fmt.Sprintf("%04d", year)
+}
+
+// Week returns the ISO 8601 week number.
+func (d PathPatternData) Week() string {
+	_, week := d.Time.ISOWeek()
+	return fmt.Sprintf("%02d", week)
+}
+
+// Title returns the title with the characters that cannot be used in file
+// names replaced.
+func (d PathPatternData) Title() string {
+	return safeFilename(d.title)
+}
+
+// Slug returns the title as a lowercase, hyphen-separated slug.
+func (d PathPatternData) Slug() string {
+	slug := sanitizeFilename(d.title)
+	if utf8.RuneCountInString(slug) > maxSlugLength {
+		slug = strings.Trim(string([]rune(slug)[:maxSlugLength]), "-")
+	}
+	return slug
+}
+
+// strftimeDirectives are the %-directives accepted in path patterns.
+var strftimeDirectives = map[byte]func(t time.Time) string{
+	'Y': func(t time.Time) string { return t.Format("2006") },
+	'y': func(t time.Time) string { return t.Format("06") },
+	'm': func(t time.Time) string { return t.Format("01") },
+	'd': func(t time.Time) string { return t.Format("02") },
+	'H': func(t time.Time) string { return t.Format("15") },
+	'M': func(t time.Time) string { return t.Format("04") },
+	'S': func(t time.Time) string { return t.Format("05") },
+	'j': func(t time.Time) string { return fmt.Sprintf("%03d", t.YearDay()) },
+	'a': func(t time.Time) string { return t.Format("Mon") },
+	'A': func(t time.Time) string { return t.Format("Monday") },
+	'b': func(t time.Time) string { return t.Format("Jan") },
+	'B': func(t time.Time) string { return t.Format("January") },
+	'u': func(t time.Time) string { return strconv.Itoa((int(t.Weekday())+6)%7 + 1) },
+	'G': func(t time.Time) string {
+		year, _ := t.ISOWeek()
+		return fmt.Sprintf("%04d", year)
+	},
+	'V': func(t time.Time) string {
+		_, week := t.ISOWeek()
+		return fmt.Sprintf("%02d", week)
+	},
+	'%': func(t time.Time) string { return "%" },
+}
+
+// RenderPathPattern renders a note path from pattern. The pattern may use
+// strftime directives for the date (%Y, %m, %d, %G, %V, ...) and Go
+// template expressions for the rest, e.g.
+// "%Y/%m/{{.Date}}-{{.Slug}}" or "{{.ULID}}". ".md" is appended when the
+// result has no extension.
+func RenderPathPattern(pattern string, data PathPatternData) (string, error) {
+	expanded, err := expandStrftime(pattern, data.Time)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(expanded)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render pattern %q: %w", pattern, err)
+	}
+
+	path := filepath.Clean(filepath.FromSlash(buf.String()))
+	if buf.Len() == 0 || strings.HasSuffix(buf.String(), "/") {
+		return "", fmt.Errorf("pattern %q does not produce a file name", pattern)
+	}
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("pattern %q must produce a relative path", pattern)
+	}
+	if filepath.Ext(path) == "" {
+		path += ".md"
+	}
+	return path, nil
+}
+
+func expandStrftime(pattern string, t time.Time) (string, error) {
+	var builder strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			builder.WriteByte(pattern[i])
+			continue
+		}
+		if i+1 >= len(pattern) {
+			return "", fmt.Errorf("incomplete directive at end of pattern %q", pattern)
+		}
+		render, ok := strftimeDirectives[pattern[i+1]]
+		if !ok {
+			return "", fmt.Errorf("unknown directive %%%c in pattern %q", pattern[i+1], pattern)
+		}
+		builder.WriteString(render(t))
+		i++
+	}
+	return builder.String(), nil
+}
+
+var (
+	unsafeFilenameChars = regexp.MustCompile(`[/\\:*?"<>|]`)
+	repeatedHyphens     = regexp.MustCompile(`-+`)
+)
+
+// safeFilename replaces the characters that cannot be used in file names
+// on Windows, macOS or Linux, keeping everything else (including Japanese).
+func safeFilename(name string) string {
+	name = unsafeFilenameChars.ReplaceAllString(name, "-")
+	name = repeatedHyphens.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-")
+}
+
+// sanitizeFilename turns s into a slug: letters and digits are kept
+// (lowercased), every other run of characters becomes a single hyphen.
+func sanitizeFilename(s string) string {
+	var builder strings.Builder
+	hyphen := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(unicode.ToLower(r))
+			hyphen = false
+			continue
+		}
+		if !hyphen && builder.Len() > 0 {
+			builder.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimRight(builder.String(), "-")
+}
+
+// newULID returns a ULID for t: a 48-bit millisecond timestamp followed by
+// 80 random bits, encoded in Crockford's base32.
+func newULID(t time.Time) string {
+	var id [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+	rand.Read(id[6:])
+
+	// 128ビットを先頭から5ビットずつ（先頭は2ビット分のパディング付き）エンコードする
+	encoded := make([]byte, 26)
+	var acc uint64
+	bits := 2 // 26文字 × 5ビット = 130ビット
+	pos := 0
+	for _, b := range id {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			encoded[pos] = crockfordBase32[(acc>>uint(bits))&0x1f]
+			pos++
+		}
+	}
+	return string(encoded)
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRenderPathPattern(t *testing.T) {
+	date := time.Date(2024, 12, 30, 9, 5, 0, 0, time.UTC) // ISO週では2025-W01
+	data := NewPathPatternData(date, "会議メモ: Q1/計画")
+	data.Number = 42
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{"%Y/%m/%Y-%m-%d", filepath.Join("2024", "12", "2024-12-30.md")},
+		{"%G/%G-W%V", filepath.Join("2025", "2025-W01.md")},
+		{"{{.Year}}/{{.Date}}-{{.Title}}", filepath.Join("2024", "2024-12-30-会議メモ- Q1-計画.md")},
+		{"%Y%m%d%H%M-{{.Slug}}", "202412300905-会議メモ-q1-計画.md"},
+		{"issue-{{.Number}}.txt", "issue-42.txt"},
+		{"{{.WeekYear}}-W{{.Week}} 100%%", "2025-W01 100%.md"},
+	}
+	for _, tt := range tests {
+		got, err := RenderPathPattern(tt.pattern, data)
+		if err != nil {
+			t.Errorf("RenderPathPattern(%q) returned error: %v", tt.pattern, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("RenderPathPattern(%q) = %q, want %q", tt.pattern, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderPathPattern_ULID(t *testing.T) {
+	date := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	first, err := RenderPathPattern("{{.ULID}}", NewPathPatternData(date, ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}\.md$`).MatchString(first) {
+		t.Errorf("unexpected ULID file name: %s", first)
+	}
+	second, _ := RenderPathPattern("{{.ULID}}", NewPathPatternData(date.Add(time.Millisecond), ""))
+	if first[:10] >= second[:10] {
+		t.Errorf("ULIDs should sort by time: %s >= %s", first, second)
+	}
+}
+
+func TestRenderPathPattern_Invalid(t *testing.T) {
+	data := NewPathPatternData(time.Now(), "title")
+	for _, pattern := range []string{"%Y/", "%Q", "100%", "{{.Unknown}}", "{{.Year", "../%Y", "/tmp/%Y", ""} {
+		if _, err := RenderPathPattern(pattern, data); err == nil {
+			t.Errorf("expected error for pattern %q", pattern)
+		}
+	}
+}
+
+func TestPathPatternData_SlugTruncated(t *testing.T) {
+	data := NewPathPatternData(time.Now(), "This is a very long title that should be truncated because it exceeds the maximum length")
+	if slug := data.Slug(); slug != "this-is-a-very-long-title-that-should-be-truncated" {
+		t.Errorf("unexpected slug: %s", slug)
+	}
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// Period is the span of time covered by a periodic note.
+type Period string
+
+const (
+	PeriodDaily   Period = "daily"
+	PeriodWeekly  Period = "weekly"
+	PeriodMonthly Period = "monthly"
+	PeriodYearly  Period = "yearly"
+)
+
+// Periods lists every supported period, shortest first.
+var Periods = []Period{PeriodDaily, PeriodWeekly, PeriodMonthly, PeriodYearly}
+
+// PeriodicNoteSettings configures where and how the notes of one period are created.
+type PeriodicNoteSettings struct {
+	Dir             string         // base_dirからのディレクトリ
+	FilenamePattern string         // RenderPathPatternの形式
+	FrontMatter     map[string]any // frontmatterのテンプレート
+	Template        string         // 本文テンプレート名（空なら期間名）
+}
+
+// PeriodicConfig provides the settings of each period.
+type PeriodicConfig interface {
+	GetBaseDir() string
+	GetPeriodicNoteSettings(period Period) PeriodicNoteSettings
+}
+
+// 期間ごとのデフォルト設定
+var defaultPeriodicNoteSettings = map[Period]PeriodicNoteSettings{
+	PeriodDaily:   {Dir: "daily", FilenamePattern: DefaultDailyPathPattern},
+	PeriodWeekly:  {Dir: "weekly", FilenamePattern: "%G/%G-W%V"},
+	PeriodMonthly: {Dir: "monthly", FilenamePattern: "%Y/%Y-%m"},
+	PeriodYearly:  {Dir: "yearly", FilenamePattern: "%Y"},
+}
+
+// ParsePeriod converts a period name such as "weekly" into a Period.
+func ParsePeriod(name string) (Period, error) {
+	for _, period := range Periods {
+		if string(period) == strings.ToLower(name) {
+			return period, nil
+		}
+	}
+	return "", fmt.Errorf("unknown period: %s", name)
+}
+
+// DefaultPeriodicNoteSettings returns the built-in settings of period.
+func DefaultPeriodicNoteSettings(period Period) PeriodicNoteSettings {
+	return defaultPeriodicNoteSettings[period]
+}
+
+// withDefaults fills the empty directory and pattern with the defaults of period.
+func (settings PeriodicNoteSettings) withDefaults(period Period) PeriodicNoteSettings {
+	defaults := DefaultPeriodicNoteSettings(period)
+	if settings.Dir == "" {
+		settings.Dir = defaults.Dir
+	}
+	if settings.FilenamePattern == "" {
+		settings.FilenamePattern = defaults.FilenamePattern
+	}
+	return settings
+}
+
+// PeriodStart returns the first day of the period containing t. Weeks
+// start on Monday as in ISO 8601.
+func PeriodStart(period Period, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case PeriodWeekly:
+		offset := (int(day.Weekday()) + 6) % 7 // 月曜日からの日数
+		return day.AddDate(0, 0, -offset)
+	case PeriodMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case PeriodYearly:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return day
+	}
+}
+
+// ShiftPeriod returns the start of the period n periods away from the one containing t.
+func ShiftPeriod(period Period, t time.Time, n int) time.Time {
+	start := PeriodStart(period, t)
+	switch period {
+	case PeriodWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case PeriodMonthly:
+		return start.AddDate(0, n, 0)
+	case PeriodYearly:
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// PeriodicNotePath returns the file of the period containing date.
+func PeriodicNotePath(baseDir string, period Period, settings PeriodicNoteSettings, date time.Time) (string, error) {
+	settings = settings.withDefaults(period)
+	rel, err := RenderPathPattern(settings.FilenamePattern, NewPathPatternData(PeriodStart(period, date), ""))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, settings.Dir, rel), nil
+}
+
+// CreatePeriodicNote creates the note of the period containing date and
+// returns its path. An existing note is left untouched. templateName
+// overrides the body template configured for the period. The creation is
+// recorded in tx, which may be nil.
+func CreatePeriodicNote(cfg PeriodicConfig, period Period, date time.Time, templateName string, tx *Transaction) (string, error) {
+	return createPeriodicNote(cfg, cfg.GetBaseDir(), period, cfg.GetPeriodicNoteSettings(period), date, templateName, tx)
+}
+
+// createPeriodicNote is shared with CreateDailyNote. cfg is only consulted
+// for the template directory.
+func createPeriodicNote(cfg any, baseDir string, period Period, settings PeriodicNoteSettings, date time.Time, templateName string, tx *Transaction) (string, error) {
+	filePath, err := PeriodicNotePath(baseDir, period, settings, date)
+	if err != nil {
+		return "", fmt.Errorf("ファイル名の生成に失敗: %w", err)
+	}
+
+	// 既存のファイルがある場合は、そのパスを返す
+	if _, err := os.Stat(filePath); err == nil {
+		return filePath, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", fmt.Errorf("ディレクトリ作成に失敗: %w", err)
+	}
+
+	data, err := newPeriodicTemplateData(baseDir, period, settings, date)
+	if err != nil {
+		return "", err
+	}
+	if templateName == "" {
+		templateName = settings.Template
+	}
+
+	// テンプレートから初期frontmatterと本文を作成
+	fm, body, err := buildNoteFromTemplate(cfg, settings.FrontMatter, templateName, string(period), data)
+	if err != nil {
+		return "", fmt.Errorf("テンプレートの適用に失敗: %w", err)
+	}
+
+	note, err := models.CreateNewNoteWithFrontMatter(models.NewNoteWithFrontMatter{
+		Content:     body,
+		FilePath:    filePath,
+		WriteFile:   true,
+		FrontMatter: fm,
+	})
+	if err != nil {
+		return "", fmt.Errorf("ノートの保存に失敗: %w", err)
+	}
+	if err := tx.RecordCreate(note.FilePath); err != nil {
+		return note.FilePath, fmt.Errorf("操作の記録に失敗: %w", err)
+	}
+	return note.FilePath, nil
+}
+
+// newPeriodicTemplateData builds the template data of a periodic note. The
+// title is the note's file name and the previous/next links point to the
+// notes of the neighbouring periods.
+func newPeriodicTemplateData(baseDir string, period Period, settings PeriodicNoteSettings, date time.Time) (NoteTemplateData, error) {
+	noteName := func(t time.Time) (string, error) {
+		path, err := PeriodicNotePath(baseDir, period, settings, t)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), nil
+	}
+	title, err := noteName(date)
+	if err != nil {
+		return NoteTemplateData{}, err
+	}
+	previous, err := noteName(ShiftPeriod(period, date, -1))
+	if err != nil {
+		return NoteTemplateData{}, err
+	}
+	next, err := noteName(ShiftPeriod(period, date, 1))
+	if err != nil {
+		return NoteTemplateData{}, err
+	}
+
+	start := PeriodStart(period, date)
+	data := NewNoteTemplateData(date, title)
+	data.PeriodStart = start.Format("2006-01-02")
+	data.PeriodEnd = ShiftPeriod(period, date, 1).AddDate(0, 0, -1).Format("2006-01-02")
+	data.PreviousLink = "[[" + previous + "]]"
+	data.NextLink = "[[" + next + "]]"
+	return data, nil
+}
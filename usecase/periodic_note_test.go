@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+type testPeriodicConfig struct {
+	baseDir     string
+	templateDir string
+	settings    map[Period]PeriodicNoteSettings
+}
+
+func (c *testPeriodicConfig) GetBaseDir() string     { return c.baseDir }
+func (c *testPeriodicConfig) GetTemplateDir() string { return c.templateDir }
+func (c *testPeriodicConfig) GetPeriodicNoteSettings(period Period) PeriodicNoteSettings {
+	return c.settings[period]
+}
+
+func TestPeriodStart(t *testing.T) {
+	date := time.Date(2025, 6, 5, 15, 30, 0, 0, time.UTC) // 木曜日
+	tests := []struct {
+		period   Period
+		expected string
+	}{
+		{PeriodDaily, "2025-06-05"},
+		{PeriodWeekly, "2025-06-02"},
+		{PeriodMonthly, "2025-06-01"},
+		{PeriodYearly, "2025-01-01"},
+	}
+	for _, tt := range tests {
+		if got := PeriodStart(tt.period, date).Format("2006-01-02"); got != tt.expected {
+			t.Errorf("PeriodStart(%s) = %s, want %s", tt.period, got, tt.expected)
+		}
+	}
+
+	// 日曜日は前の週に属する
+	sunday := time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)
+	if got := PeriodStart(PeriodWeekly, sunday).Format("2006-01-02"); got != "2025-06-02" {
+		t.Errorf("week of Sunday should start on 2025-06-02, got %s", got)
+	}
+}
+
+func TestCreatePeriodicNote_Defaults(t *testing.T) {
+	cfg := &testPeriodicConfig{baseDir: t.TempDir()}
+	date := time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		period   Period
+		expected string
+	}{
+		{PeriodWeekly, filepath.Join("weekly", "2025", "2025-W23.md")},
+		{PeriodMonthly, filepath.Join("monthly", "2025", "2025-06.md")},
+		{PeriodYearly, filepath.Join("yearly", "2025.md")},
+	}
+	for _, tt := range tests {
+		path, err := CreatePeriodicNote(cfg, tt.period, date, "", nil)
+		if err != nil {
+			t.Fatalf("CreatePeriodicNote(%s) returned error: %v", tt.period, err)
+		}
+		if expected := filepath.Join(cfg.baseDir, tt.expected); path != expected {
+			t.Errorf("CreatePeriodicNote(%s) = %s, want %s", tt.period, path, expected)
+		}
+	}
+}
+
+func TestCreatePeriodicNote_Settings(t *testing.T) {
+	baseDir := t.TempDir()
+	cfg := &testPeriodicConfig{
+		baseDir:     baseDir,
+		templateDir: filepath.Join(baseDir, "templates"),
+		settings: map[Period]PeriodicNoteSettings{
+			PeriodWeekly: {
+				Dir:             "reviews",
+				FilenamePattern: "%G-W%V",
+				FrontMatter:     map[string]any{"week": "{{.ISOWeek}}"},
+				Template:        "review",
+			},
+		},
+	}
+	writeTestNote(t, filepath.Join(cfg.templateDir, "review.md"), "# {{.Title}}\n{{.PeriodStart}}〜{{.PeriodEnd}}\n{{.PreviousLink}} / {{.NextLink}}\n")
+
+	date := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	path, err := CreatePeriodicNote(cfg, PeriodWeekly, date, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := filepath.Join(baseDir, "reviews", "2025-W01.md"); path != expected {
+		t.Fatalf("expected %s, got %s", expected, path)
+	}
+
+	note, err := models.LoadNoteFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	if note.FrontMatter["week"] != "2025-W01" {
+		t.Errorf("expected week 2025-W01, got %v", note.FrontMatter["week"])
+	}
+	for _, want := range []string{"# 2025-W01", "2024-12-30〜2025-01-05", "[[2024-W52]] / [[2025-W02]]"} {
+		if !strings.Contains(note.Content, want) {
+			t.Errorf("expected body to contain %q, got %q", want, note.Content)
+		}
+	}
+
+	// 既存のノートはそのまま返す
+	again, err := CreatePeriodicNote(cfg, PeriodWeekly, date.AddDate(0, 0, 3), "", nil)
+	if err != nil || again != path {
+		t.Errorf("expected existing note %s, got %s (err: %v)", path, again, err)
+	}
+}
+
+func TestCreateDailyNote_PastDate(t *testing.T) {
+	cfg := &testDailyConfig{baseDir: t.TempDir(), dailyNoteDir: "daily"}
+	date := time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)
+	path, err := CreateDailyNote(cfg, date)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	note, err := models.LoadNoteFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to load note: %v", err)
+	}
+	created, err := note.FrontMatter.Created()
+	if err != nil || created.Format("2006-01-02") != "2023-01-15" {
+		t.Errorf("expected created 2023-01-15, got %v (err: %v)", created, err)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	if period, err := ParsePeriod("Weekly"); err != nil || period != PeriodWeekly {
+		t.Errorf("expected weekly, got %s (err: %v)", period, err)
+	}
+	if _, err := ParsePeriod("hourly"); err == nil {
+		t.Error("expected error for unknown period")
+	}
+}
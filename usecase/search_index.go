@@ -0,0 +1,415 @@
+package usecase
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+const (
+	searchIndexVersion = 1
+	// searchGramSize は転置インデックスに使う文字n-gramの長さ
+	searchGramSize = 2
+	// searchSnippetContext はスニペットの前後に表示する文字数
+	searchSnippetContext = 30
+)
+
+// SearchIndex is a persistent inverted index of character n-grams over
+// the notes in a vault. Text is normalized (lowercased, whitespace removed)
+// so that Japanese sentences split by line breaks still match.
+type SearchIndex struct {
+	Version  int                        `json:"version"`
+	BaseDir  string                     `json:"base_dir"`
+	Docs     map[string]*SearchDocument `json:"docs"`     // ファイルパス → ドキュメント
+	Postings map[string]map[string]int  `json:"postings"` // n-gram → ファイルパス → 出現回数
+}
+
+// SearchDocument is a single indexed note.
+type SearchDocument struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+	Text    string    `json:"text"` // 正規化済みテキスト
+}
+
+// SearchUpdateStats reports what changed during SearchIndex.Update.
+type SearchUpdateStats struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+	Failures  map[string]error
+}
+
+// SearchResult is a single ranked hit.
+type SearchResult struct {
+	Path    string
+	Score   float64
+	Snippet SearchSnippet
+}
+
+// SearchSnippet is the text surrounding the first match of a result.
+type SearchSnippet struct {
+	Before string
+	Match  string
+	After  string
+}
+
+// SearchIndexPath returns the index file for baseDir inside cacheDir.
+// Each vault gets its own file keyed by the absolute base directory.
+func SearchIndexPath(cacheDir, baseDir string) string {
+	abs, err := filepath.Abs(baseDir)
+	if err != nil {
+		abs = baseDir
+	}
+	sum := sha1.Sum([]byte(abs))
+	return filepath.Join(cacheDir, "search-"+hex.EncodeToString(sum[:])[:12]+".json")
+}
+
+// NewSearchIndex returns an empty index for baseDir.
+func NewSearchIndex(baseDir string) *SearchIndex {
+	return &SearchIndex{
+		Version:  searchIndexVersion,
+		BaseDir:  baseDir,
+		Docs:     map[string]*SearchDocument{},
+		Postings: map[string]map[string]int{},
+	}
+}
+
+// LoadSearchIndex reads the index stored at indexPath. A missing file or an
+// index built by another version or for another vault yields an empty index.
+func LoadSearchIndex(indexPath, baseDir string) (*SearchIndex, error) {
+	data, err := os.ReadFile(indexPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewSearchIndex(baseDir), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search index %s: %w", indexPath, err)
+	}
+	var index SearchIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		// 壊れたインデックスは作り直す
+		return NewSearchIndex(baseDir), nil
+	}
+	if index.Version != searchIndexVersion || index.BaseDir != baseDir || index.Docs == nil || index.Postings == nil {
+		return NewSearchIndex(baseDir), nil
+	}
+	return &index, nil
+}
+
+// Save writes the index to indexPath atomically.
+func (index *SearchIndex) Save(indexPath string) error {
+	if err := os.MkdirAll(filepath.Dir(indexPath), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+	tmpPath := indexPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	return nil
+}
+
+// Update re-indexes notes whose modification time or size changed since the
+// last run and drops notes that no longer exist.
+func (index *SearchIndex) Update() (SearchUpdateStats, error) {
+	stats := SearchUpdateStats{Failures: map[string]error{}}
+	seen := map[string]bool{}
+
+	err := walkNoteFiles(index.BaseDir, true, func(path string, d fs.DirEntry) error {
+		seen[path] = true
+		info, err := d.Info()
+		if err != nil {
+			stats.Failures[path] = err
+			return nil
+		}
+		doc, exists := index.Docs[path]
+		if exists && doc.ModTime.Equal(info.ModTime()) && doc.Size == info.Size() {
+			stats.Unchanged++
+			return nil
+		}
+
+		note, err := models.LoadNoteFromFile(path)
+		if exists {
+			index.removeDocument(path)
+		}
+		if err != nil {
+			stats.Failures[path] = err
+			return nil
+		}
+		runes, _, _ := normalizeSearchText(searchableText(note))
+		index.addDocument(path, &SearchDocument{
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+			Text:    string(runes),
+		})
+		if exists {
+			stats.Updated++
+		} else {
+			stats.Added++
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to walk %s: %w", index.BaseDir, err)
+	}
+
+	for path := range index.Docs {
+		if !seen[path] {
+			index.removeDocument(path)
+			stats.Removed++
+		}
+	}
+	return stats, nil
+}
+
+func (index *SearchIndex) addDocument(path string, doc *SearchDocument) {
+	index.Docs[path] = doc
+	for gram, count := range searchGrams([]rune(doc.Text)) {
+		postings, ok := index.Postings[gram]
+		if !ok {
+			postings = map[string]int{}
+			index.Postings[gram] = postings
+		}
+		postings[path] = count
+	}
+}
+
+func (index *SearchIndex) removeDocument(path string) {
+	doc, ok := index.Docs[path]
+	if !ok {
+		return
+	}
+	for gram := range searchGrams([]rune(doc.Text)) {
+		delete(index.Postings[gram], path)
+		if len(index.Postings[gram]) == 0 {
+			delete(index.Postings, gram)
+		}
+	}
+	delete(index.Docs, path)
+}
+
+// Search returns notes containing every whitespace-separated term of query,
+// ranked by a tf-idf score. At most limit results are returned when limit > 0.
+func (index *SearchIndex) Search(query string, limit int) ([]SearchResult, error) {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		runes, _, _ := normalizeSearchText(field)
+		if len(runes) > 0 {
+			terms = append(terms, string(runes))
+		}
+	}
+	if len(terms) == 0 {
+		return nil, errors.New("empty search query")
+	}
+
+	scores := map[string]float64{}
+	for i, term := range terms {
+		counts := index.termCounts(term)
+		if len(counts) == 0 {
+			return []SearchResult{}, nil
+		}
+		idf := math.Log(1 + float64(len(index.Docs))/float64(len(counts)))
+		next := map[string]float64{}
+		for path, count := range counts {
+			if _, ok := scores[path]; i > 0 && !ok {
+				continue
+			}
+			next[path] = scores[path] + (1+math.Log(float64(count)))*idf
+		}
+		scores = next
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for path, score := range scores {
+		results = append(results, SearchResult{Path: path, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	// スニペットは表示する結果だけファイルを読み直して作る
+	for i := range results {
+		note, err := models.LoadNoteFromFile(results[i].Path)
+		if err != nil {
+			continue
+		}
+		results[i].Snippet = makeSearchSnippet(searchableText(note), terms[0])
+	}
+	return results, nil
+}
+
+// termCounts returns the number of occurrences of a normalized term per document.
+func (index *SearchIndex) termCounts(term string) map[string]int {
+	runes := []rune(term)
+	var candidates []string
+	if len(runes) < searchGramSize {
+		for path := range index.Docs {
+			candidates = append(candidates, path)
+		}
+	} else {
+		// 最初のn-gramのポスティングを他のn-gramで絞り込む
+		grams := searchGrams(runes)
+		var smallest map[string]int
+		for gram := range grams {
+			postings := index.Postings[gram]
+			if smallest == nil || len(postings) < len(smallest) {
+				smallest = postings
+			}
+		}
+		for path := range smallest {
+			matched := true
+			for gram := range grams {
+				if _, ok := index.Postings[gram][path]; !ok {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				candidates = append(candidates, path)
+			}
+		}
+	}
+
+	counts := map[string]int{}
+	for _, path := range candidates {
+		// n-gramの一致だけでは連続しているとは限らないので本文で確認する
+		if count := strings.Count(index.Docs[path].Text, term); count > 0 {
+			counts[path] = count
+		}
+	}
+	return counts
+}
+
+// searchableText returns the text indexed for a note: the file name,
+// frontmatter values and the body.
+func searchableText(note *models.Note) string {
+	var builder strings.Builder
+	builder.WriteString(strings.TrimSuffix(filepath.Base(note.FilePath), filepath.Ext(note.FilePath)))
+	builder.WriteString("\n")
+
+	keys := make([]string, 0, len(note.FrontMatter))
+	for key := range note.FrontMatter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch v := note.FrontMatter[key].(type) {
+		case nil:
+			continue
+		case []any:
+			for _, item := range v {
+				builder.WriteString(fmt.Sprint(item))
+				builder.WriteString(" ")
+			}
+		default:
+			builder.WriteString(fmt.Sprint(v))
+		}
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(note.Content)
+	return builder.String()
+}
+
+// normalizeSearchText lowercases text, folds full-width ASCII and drops
+// whitespace. It also returns the byte range in text of each normalized rune.
+func normalizeSearchText(text string) (runes []rune, starts []int, ends []int) {
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		size := utf8.RuneLen(r)
+		// 全角英数記号を半角に寄せる
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		runes = append(runes, unicode.ToLower(r))
+		starts = append(starts, i)
+		ends = append(ends, i+size)
+	}
+	return runes, starts, ends
+}
+
+// searchGrams counts the character n-grams in runes.
+func searchGrams(runes []rune) map[string]int {
+	grams := map[string]int{}
+	for i := 0; i+searchGramSize <= len(runes); i++ {
+		grams[string(runes[i:i+searchGramSize])]++
+	}
+	return grams
+}
+
+// makeSearchSnippet cuts the text around the first occurrence of term.
+func makeSearchSnippet(text, term string) SearchSnippet {
+	runes, starts, ends := normalizeSearchText(text)
+	termRunes := []rune(term)
+	pos := indexRunes(runes, termRunes)
+	if pos < 0 {
+		return SearchSnippet{}
+	}
+	start := starts[pos]
+	end := ends[pos+len(termRunes)-1]
+
+	before := []rune(text[:start])
+	if len(before) > searchSnippetContext {
+		before = append([]rune("…"), before[len(before)-searchSnippetContext:]...)
+	}
+	after := []rune(text[end:])
+	if len(after) > searchSnippetContext {
+		after = append(after[:searchSnippetContext], []rune("…")...)
+	}
+	return SearchSnippet{
+		Before: flattenSnippet(string(before)),
+		Match:  flattenSnippet(text[start:end]),
+		After:  flattenSnippet(string(after)),
+	}
+}
+
+func indexRunes(runes, sub []rune) int {
+	for i := 0; i+len(sub) <= len(runes); i++ {
+		matched := true
+		for j := range sub {
+			if runes[i+j] != sub[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return i
+		}
+	}
+	return -1
+}
+
+// snippetReplacer puts a snippet on a single line.
+var snippetReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+func flattenSnippet(s string) string {
+	return snippetReplacer.Replace(s)
+}
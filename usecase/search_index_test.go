@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSearchIndex_JapaneseAcrossLineBreak(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "a.md"), "---\ntags: [会議]\n---\n今日は新しい機能の設\n計を考えた。")
+	writeTestNote(t, filepath.Join(baseDir, "b.md"), "設計とは関係のないメモ")
+
+	index := NewSearchIndex(baseDir)
+	if _, err := index.Update(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, err := index.Search("機能の設計", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != filepath.Join(baseDir, "a.md") {
+		t.Fatalf("expected only a.md, got %+v", results)
+	}
+	if results[0].Snippet.Match != "機能の設 計" {
+		t.Errorf("unexpected snippet match %q", results[0].Snippet.Match)
+	}
+
+	// frontmatterの値も検索対象
+	results, err = index.Search("会議", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected frontmatter value to match, got %d results", len(results))
+	}
+}
+
+func TestSearchIndex_Ranking(t *testing.T) {
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "once.md"), "golang memo")
+	writeTestNote(t, filepath.Join(baseDir, "many.md"), "Golang golang ＧＯＬＡＮＧ")
+	writeTestNote(t, filepath.Join(baseDir, "none.md"), "python memo")
+
+	index := NewSearchIndex(baseDir)
+	if _, err := index.Update(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results, err := index.Search("golang", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Path != filepath.Join(baseDir, "many.md") {
+		t.Errorf("expected many.md to rank first, got %s", results[0].Path)
+	}
+
+	results, err = index.Search("golang memo", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Path != filepath.Join(baseDir, "once.md") {
+		t.Errorf("expected all terms to match only once.md, got %+v", results)
+	}
+
+	if _, err := index.Search("  ", 0); err == nil {
+		t.Error("expected error for empty query")
+	}
+}
+
+func TestSearchIndex_IncrementalUpdate(t *testing.T) {
+	baseDir := t.TempDir()
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+	pathA := filepath.Join(baseDir, "a.md")
+	pathB := filepath.Join(baseDir, "b.md")
+	writeTestNote(t, pathA, "first version")
+	writeTestNote(t, pathB, "another note")
+
+	index, err := LoadSearchIndex(indexPath, baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stats, err := index.Update()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Added != 2 {
+		t.Errorf("expected 2 added, got %d", stats.Added)
+	}
+	if err := index.Save(indexPath); err != nil {
+		t.Fatalf("failed to save index: %v", err)
+	}
+
+	// a.mdを更新し、b.mdを削除
+	writeTestNote(t, pathA, "second version")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(pathA, future, future); err != nil {
+		t.Fatalf("failed to change mtime: %v", err)
+	}
+	os.Remove(pathB)
+	writeTestNote(t, filepath.Join(baseDir, "c.md"), "third note")
+
+	index, err = LoadSearchIndex(indexPath, baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(index.Docs) != 2 {
+		t.Fatalf("expected saved index to contain 2 docs, got %d", len(index.Docs))
+	}
+	stats, err = index.Update()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Added != 1 || stats.Updated != 1 || stats.Removed != 1 || stats.Unchanged != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if results, _ := index.Search("first", 0); len(results) != 0 {
+		t.Errorf("stale text should not match")
+	}
+	if results, _ := index.Search("second", 0); len(results) != 1 {
+		t.Errorf("updated text should match")
+	}
+	if _, ok := index.Postings["an"]; ok {
+		t.Errorf("postings of removed note should be dropped")
+	}
+}
+
+func TestLoadSearchIndex_OtherVault(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+	index := NewSearchIndex("/vault/a")
+	index.addDocument("/vault/a/x.md", &SearchDocument{Text: "abc"})
+	if err := index.Save(indexPath); err != nil {
+		t.Fatalf("failed to save index: %v", err)
+	}
+	loaded, err := LoadSearchIndex(indexPath, "/vault/b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(loaded.Docs) != 0 {
+		t.Errorf("index of another vault should be discarded")
+	}
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+// TagNode is a tag in the tag tree. "project/krapp" is the child "krapp"
+// of "project".
+type TagNode struct {
+	Name     string // この階層の名前
+	Path     string // 親を含むタグ名（"project/krapp"）
+	Count    int    // このタグを付けたノートの数
+	Total    int    // このタグか子孫のタグを付けたノートの数
+	Children []*TagNode
+}
+
+// BuildTagTree collects the frontmatter and inline tags of the notes into
+// a tree. The returned root has no name. Tags are grouped ignoring case;
+// the first spelling found is used.
+func BuildTagTree(notes []*models.Note) *TagNode {
+	root := &TagNode{}
+	nodes := map[string]*TagNode{"": root}
+	for _, note := range notes {
+		counted := map[*TagNode]bool{}
+		for _, tag := range note.AllTags() {
+			parent := root
+			segments := strings.Split(strings.Trim(tag, "/"), "/")
+			for i, segment := range segments {
+				path := strings.Join(segments[:i+1], "/")
+				node, ok := nodes[strings.ToLower(path)]
+				if !ok {
+					node = &TagNode{Name: segment, Path: path}
+					nodes[strings.ToLower(path)] = node
+					parent.Children = append(parent.Children, node)
+				}
+				// 同じノートを親のタグで二重に数えない
+				if !counted[node] {
+					counted[node] = true
+					node.Total++
+				}
+				parent = node
+			}
+			parent.Count++
+		}
+	}
+	sortTagNodes(root)
+	return root
+}
+
+func sortTagNodes(node *TagNode) {
+	sort.Slice(node.Children, func(i, j int) bool {
+		return strings.ToLower(node.Children[i].Name) < strings.ToLower(node.Children[j].Name)
+	})
+	for _, child := range node.Children {
+		sortTagNodes(child)
+	}
+}
+
+// Find returns the node of the tag, or nil when no note has it.
+func (node *TagNode) Find(tag string) *TagNode {
+	tag = strings.ToLower(strings.Trim(strings.TrimPrefix(tag, "#"), "/"))
+	if strings.ToLower(node.Path) == tag {
+		return node
+	}
+	for _, child := range node.Children {
+		if models.TagMatches(tag, child.Path) {
+			return child.Find(tag)
+		}
+	}
+	return nil
+}
+
+// TagEdit is the rewrite of one note by a tag rename.
+type TagEdit struct {
+	Path    string
+	Count   int // 書き換えたタグの数
+	content string
+}
+
+// PlanTagRename plans renaming each of the tags in from, with their
+// children, to to in every note of the index. Merging several tags into
+// one is a rename with more than one source.
+func PlanTagRename(index *NoteIndex, from []string, to string) ([]TagEdit, error) {
+	to = strings.Trim(strings.TrimPrefix(to, "#"), "/")
+	if to == "" || strings.ContainsAny(to, " \t#,") {
+		return nil, fmt.Errorf("invalid tag name %q", to)
+	}
+	for _, tag := range from {
+		if models.TagMatches(to, tag) {
+			return nil, fmt.Errorf("cannot rename %s to itself or its child %s", tag, to)
+		}
+	}
+
+	edits := []TagEdit{}
+	for _, note := range index.Notes {
+		raw, err := os.ReadFile(note.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", note.FilePath, err)
+		}
+		content := string(raw)
+		total := 0
+		for _, tag := range from {
+			var count int
+			content, count = models.RenameTag(content, tag, to)
+			total += count
+		}
+		if total > 0 {
+			edits = append(edits, TagEdit{Path: note.FilePath, Count: total, content: content})
+		}
+	}
+	return edits, nil
+}
+
+// ApplyTagRename writes the notes rewritten by PlanTagRename, recording
+// them in tx, which may be nil.
+func ApplyTagRename(edits []TagEdit, tx *Transaction) error {
+	for _, edit := range edits {
+		if err := tx.RecordOverwrite(edit.Path); err != nil {
+			return fmt.Errorf("failed to record journal: %w", err)
+		}
+		if err := os.WriteFile(edit.Path, []byte(edit.content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", edit.Path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setupTagVault(t *testing.T) *NoteIndex {
+	t.Helper()
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "a.md"), "---\ntags: [project/krapp, idea]\n---\n#project/krapp/cli\n")
+	writeTestNote(t, filepath.Join(baseDir, "b.md"), "---\ntags: memo\n---\n#Idea と #project\n")
+	writeTestNote(t, filepath.Join(baseDir, "c.md"), "タグなし\n")
+	index, err := BuildNoteIndex(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return index
+}
+
+func TestBuildTagTree(t *testing.T) {
+	index := setupTagVault(t)
+	tree := BuildTagTree(index.Notes)
+
+	if len(tree.Children) != 3 {
+		t.Fatalf("expected 3 top-level tags, got %+v", tree.Children)
+	}
+	idea := tree.Children[0]
+	if idea.Name != "idea" || idea.Count != 2 || idea.Total != 2 {
+		t.Errorf("unexpected idea node: %+v", idea)
+	}
+	project := tree.Find("#project")
+	if project == nil || project.Count != 1 || project.Total != 2 {
+		t.Fatalf("unexpected project node: %+v", project)
+	}
+	krapp := tree.Find("project/krapp")
+	if krapp == nil || krapp.Path != "project/krapp" || krapp.Total != 1 || len(krapp.Children) != 1 {
+		t.Errorf("unexpected project/krapp node: %+v", krapp)
+	}
+	if tree.Find("nothing") != nil {
+		t.Errorf("expected nil for unknown tag")
+	}
+}
+
+func TestTagRename(t *testing.T) {
+	index := setupTagVault(t)
+	edits, err := PlanTagRename(index, []string{"idea", "memo"}, "note")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(edits) != 2 || edits[0].Count != 1 || edits[1].Count != 2 {
+		t.Fatalf("unexpected edits: %+v", edits)
+	}
+
+	journal := NewJournal(filepath.Join(t.TempDir(), "journal"))
+	tx := journal.Begin("tags merge")
+	if err := ApplyTagRename(edits, tx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := filepath.Join(index.BaseDir, "b.md")
+	if data, _ := os.ReadFile(b); string(data) != "---\ntags: note\n---\n#note と #project\n" {
+		t.Errorf("unexpected b.md: %q", data)
+	}
+
+	if err := journal.Undo(tx, nil); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if data, _ := os.ReadFile(b); string(data) != "---\ntags: memo\n---\n#Idea と #project\n" {
+		t.Errorf("b.md should be restored, got %q", data)
+	}
+
+	if _, err := PlanTagRename(index, []string{"project"}, "project/sub"); err == nil {
+		t.Errorf("expected error when renaming a tag into its child")
+	}
+	if _, err := PlanTagRename(index, []string{"idea"}, "two words"); err == nil {
+		t.Errorf("expected error for invalid tag name")
+	}
+}
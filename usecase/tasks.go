@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ishida722/krapp-go/models"
+)
+
+const (
+	// taskIDLength はタスクIDとして表示するハッシュの桁数
+	taskIDLength = 7
+	// minTaskIDPrefix はIDの前方一致に必要な最小の桁数
+	minTaskIDPrefix = 4
+)
+
+// タスクのグループ化の方法
+const (
+	TaskGroupByNote = "note"
+	TaskGroupByDue  = "due"
+)
+
+// VaultTask is a task together with the note it was found in. ID is
+// derived from the note path and the task text, so it stays the same when
+// lines are added or the task is checked.
+type VaultTask struct {
+	models.Task
+	Path string // ノートのファイルパス
+	ID   string
+}
+
+// TaskGroup is a set of tasks sharing a note or a due date.
+type TaskGroup struct {
+	Key   string
+	Tasks []VaultTask
+}
+
+// TaskQuery selects tasks. The zero value selects every open task.
+type TaskQuery struct {
+	IncludeClosed bool      // 完了・キャンセル済みも含める
+	Tag           string    // このタグを持つタスクのみ
+	DueBefore     time.Time // この日以前が期日のタスクのみ
+}
+
+// Matches reports whether task satisfies the query.
+func (query TaskQuery) Matches(task VaultTask) bool {
+	if !query.IncludeClosed && !task.IsOpen() {
+		return false
+	}
+	if query.Tag != "" {
+		found := false
+		for _, t := range task.Tags {
+			if models.TagMatches(t, query.Tag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !query.DueBefore.IsZero() {
+		// タイムゾーンの違いを避けるため日付の文字列で比較する
+		if !task.HasDue() || task.Due.Format("2006-01-02") > query.DueBefore.Format("2006-01-02") {
+			return false
+		}
+	}
+	return true
+}
+
+// CollectTasks returns the tasks of every note under baseDir. Files that
+// cannot be read are reported in the returned map.
+func CollectTasks(baseDir string) ([]VaultTask, map[string]error, error) {
+	var tasks []VaultTask
+	failures := map[string]error{}
+	err := walkNoteFiles(baseDir, true, func(path string, d fs.DirEntry) error {
+		fileTasks, err := loadFileTasks(baseDir, path)
+		if err != nil {
+			failures[path] = err
+			return nil
+		}
+		tasks = append(tasks, fileTasks...)
+		return nil
+	})
+	if err != nil {
+		return nil, failures, fmt.Errorf("failed to walk %s: %w", baseDir, err)
+	}
+	return tasks, failures, nil
+}
+
+// loadFileTasks parses the tasks of a single note file.
+func loadFileTasks(baseDir, path string) ([]VaultTask, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+
+	var tasks []VaultTask
+	seen := map[string]int{}
+	for _, task := range models.ParseFileTasks(string(raw)) {
+		// 同じ本文のタスクは出現順で区別する
+		occurrence := seen[task.Text]
+		seen[task.Text]++
+		tasks = append(tasks, VaultTask{
+			Task: task,
+			Path: path,
+			ID:   taskID(rel, task.Text, occurrence),
+		})
+	}
+	return tasks, nil
+}
+
+func taskID(relPath, text string, occurrence int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s\x00%s\x00%d", relPath, text, occurrence)))
+	return hex.EncodeToString(sum[:])[:taskIDLength]
+}
+
+// FilterTasks returns the tasks matching query.
+func FilterTasks(tasks []VaultTask, query TaskQuery) []VaultTask {
+	result := []VaultTask{}
+	for _, task := range tasks {
+		if query.Matches(task) {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// GroupTasks groups tasks by note path or by due date. Notes are sorted by
+// path, due dates ascending with tasks without a due date last. Within a
+// group tasks are ordered by priority, then by position.
+func GroupTasks(tasks []VaultTask, by string) ([]TaskGroup, error) {
+	var keyOf func(task VaultTask) string
+	switch by {
+	case TaskGroupByNote, "":
+		keyOf = func(task VaultTask) string { return task.Path }
+	case TaskGroupByDue:
+		keyOf = func(task VaultTask) string {
+			if !task.HasDue() {
+				return ""
+			}
+			return task.Due.Format("2006-01-02")
+		}
+	default:
+		return nil, fmt.Errorf("unknown group: %s (note, due)", by)
+	}
+
+	groups := map[string]*TaskGroup{}
+	for _, task := range tasks {
+		key := keyOf(task)
+		group, ok := groups[key]
+		if !ok {
+			group = &TaskGroup{Key: key}
+			groups[key] = group
+		}
+		group.Tasks = append(group.Tasks, task)
+	}
+
+	result := make([]TaskGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.SliceStable(group.Tasks, func(i, j int) bool {
+			a, b := group.Tasks[i], group.Tasks[j]
+			if a.Priority != b.Priority {
+				return a.Priority > b.Priority
+			}
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+			return a.Line < b.Line
+		})
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		// 期日なしのグループは最後
+		if result[i].Key == "" || result[j].Key == "" {
+			return result[j].Key == "" && result[i].Key != ""
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result, nil
+}
+
+// FindTask returns the task whose ID is id or starts with id.
+func FindTask(tasks []VaultTask, id string) (VaultTask, error) {
+	if len(id) < minTaskIDPrefix {
+		return VaultTask{}, fmt.Errorf("task id must be at least %d characters", minTaskIDPrefix)
+	}
+	var found []VaultTask
+	for _, task := range tasks {
+		if task.ID == id {
+			return task, nil
+		}
+		if strings.HasPrefix(task.ID, id) {
+			found = append(found, task)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return VaultTask{}, fmt.Errorf("task %s not found", id)
+	case 1:
+		return found[0], nil
+	default:
+		return VaultTask{}, fmt.Errorf("task id %s is ambiguous", id)
+	}
+}
+
+// ToggleTask marks the task with the given ID done, or open again when it
+// is already done. Only the line of the task is rewritten; the overwritten
+// file is recorded in tx, which may be nil.
+func ToggleTask(baseDir, id string, tx *Transaction) (VaultTask, error) {
+	tasks, _, err := CollectTasks(baseDir)
+	if err != nil {
+		return VaultTask{}, err
+	}
+	task, err := FindTask(tasks, id)
+	if err != nil {
+		return VaultTask{}, err
+	}
+
+	status := models.TaskDone
+	if task.Status == models.TaskDone {
+		status = models.TaskOpen
+	}
+	if err := setTaskStatusInFile(task, status, tx); err != nil {
+		return VaultTask{}, err
+	}
+	task.Status = status
+	return task, nil
+}
+
+// setTaskStatusInFile rewrites the checkbox of task in its file.
+func setTaskStatusInFile(task VaultTask, status models.TaskStatus, tx *Transaction) error {
+	raw, err := os.ReadFile(task.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", task.Path, err)
+	}
+	lines := strings.Split(string(raw), "\n")
+	if task.Line < 1 || task.Line > len(lines) {
+		return errors.New("task line is out of range")
+	}
+	line, ok := models.SetTaskStatus(lines[task.Line-1], status)
+	if !ok {
+		return fmt.Errorf("%s:%d is no longer a task", task.Path, task.Line)
+	}
+	lines[task.Line-1] = line
+
+	if err := tx.RecordOverwrite(task.Path); err != nil {
+		return fmt.Errorf("failed to record journal: %w", err)
+	}
+	if err := os.WriteFile(task.Path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", task.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupTaskVault(t *testing.T) string {
+	t.Helper()
+	baseDir := t.TempDir()
+	writeTestNote(t, filepath.Join(baseDir, "daily", "2025-06-02.md"), "---\ncreated: 2025-06-02\n---\n# 06-02\n- [ ] 資料を作る 📅 2025-06-10 #work\n- [x] 済み\n- [ ] 買い物 🔼\n")
+	writeTestNote(t, filepath.Join(baseDir, "inbox", "idea.md"), "- [ ] 調べる 📅 2025-06-05 ⏫\n- [ ] 買い物 🔼\n")
+	return baseDir
+}
+
+func TestCollectTasks(t *testing.T) {
+	baseDir := setupTaskVault(t)
+	tasks, failures, err := CollectTasks(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failures) != 0 {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+	if len(tasks) != 5 {
+		t.Fatalf("expected 5 tasks, got %d", len(tasks))
+	}
+
+	ids := map[string]bool{}
+	for _, task := range tasks {
+		if len(task.ID) != taskIDLength {
+			t.Errorf("unexpected id %q", task.ID)
+		}
+		if ids[task.ID] {
+			t.Errorf("duplicate id %s", task.ID)
+		}
+		ids[task.ID] = true
+	}
+
+	open := FilterTasks(tasks, TaskQuery{})
+	if len(open) != 4 {
+		t.Errorf("expected 4 open tasks, got %d", len(open))
+	}
+	work := FilterTasks(tasks, TaskQuery{Tag: "#work"})
+	if len(work) != 1 || work[0].Description != "資料を作る #work" {
+		t.Errorf("unexpected tag filter result: %+v", work)
+	}
+	due := FilterTasks(tasks, TaskQuery{DueBefore: time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC)})
+	if len(due) != 1 || due[0].Description != "調べる" {
+		t.Errorf("unexpected due filter result: %+v", due)
+	}
+}
+
+func TestGroupTasks(t *testing.T) {
+	baseDir := setupTaskVault(t)
+	tasks, _, err := CollectTasks(baseDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	open := FilterTasks(tasks, TaskQuery{})
+
+	groups, err := GroupTasks(open, TaskGroupByDue)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys := []string{}
+	for _, group := range groups {
+		keys = append(keys, group.Key)
+	}
+	if strings.Join(keys, ",") != "2025-06-05,2025-06-10," {
+		t.Errorf("unexpected group order: %v", keys)
+	}
+
+	groups, err = GroupTasks(open, TaskGroupByNote)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 2 || !strings.HasSuffix(groups[0].Key, "2025-06-02.md") {
+		t.Fatalf("unexpected note groups: %+v", groups)
+	}
+	// 優先度の高いタスクが先
+	if groups[1].Tasks[0].Description != "調べる" {
+		t.Errorf("expected high priority task first, got %s", groups[1].Tasks[0].Description)
+	}
+
+	if _, err := GroupTasks(open, "tag"); err == nil {
+		t.Error("expected error for unknown group")
+	}
+}
+
+func TestToggleTask(t *testing.T) {
+	baseDir := setupTaskVault(t)
+	tasks, _, _ := CollectTasks(baseDir)
+	var target VaultTask
+	for _, task := range tasks {
+		if task.Description == "資料を作る #work" {
+			target = task
+		}
+	}
+
+	journal := NewJournal(t.TempDir())
+	tx := journal.Begin("tasks toggle")
+	toggled, err := ToggleTask(baseDir, target.ID[:5], tx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if toggled.ID != target.ID {
+		t.Errorf("toggled %s, want %s", toggled.ID, target.ID)
+	}
+
+	data, _ := os.ReadFile(target.Path)
+	expected := "---\ncreated: 2025-06-02\n---\n# 06-02\n- [x] 資料を作る 📅 2025-06-10 #work\n- [x] 済み\n- [ ] 買い物 🔼\n"
+	if string(data) != expected {
+		t.Errorf("unexpected file content:\n%s", data)
+	}
+
+	// IDはチェック状態が変わっても同じ
+	if _, err := ToggleTask(baseDir, target.ID, tx); err != nil {
+		t.Fatalf("unexpected error toggling back: %v", err)
+	}
+	data, _ = os.ReadFile(target.Path)
+	if !strings.Contains(string(data), "- [ ] 資料を作る") {
+		t.Errorf("task was not reopened:\n%s", data)
+	}
+
+	if err := journal.Undo(tx, nil); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+	data, _ = os.ReadFile(target.Path)
+	if !strings.Contains(string(data), "- [ ] 資料を作る") {
+		t.Errorf("undo did not restore the file:\n%s", data)
+	}
+
+	if _, err := ToggleTask(baseDir, "zzzz", nil); err == nil {
+		t.Error("expected error for unknown id")
+	}
+	if _, err := ToggleTask(baseDir, "ab", nil); err == nil {
+		t.Error("expected error for too short id")
+	}
+}